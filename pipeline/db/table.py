@@ -1,11 +1,16 @@
 import sqlalchemy_utils as su
+from sqlalchemy import BigInteger
+from sqlalchemy import Boolean
 from sqlalchemy import Column
 from sqlalchemy import DateTime
 from sqlalchemy import Float
 from sqlalchemy import Index
 from sqlalchemy import Integer
 from sqlalchemy import String
+from sqlalchemy import text
 from sqlalchemy import UniqueConstraint
+from sqlalchemy.dialects.postgresql import JSONB
+from sqlalchemy.dialects.postgresql import UUID
 from sqlalchemy.orm import DeclarativeBase
 
 
@@ -66,3 +71,66 @@ class NycTrip(Base):
     total_amount = Column(Float)
     congestion_surcharge = Column(Float)
     airport_fee = Column(Float)
+
+
+class IngestionProfile(Base):
+    __tablename__ = "ingestion_profile"
+    __table_args__ = (
+        Index(
+            "ix_ingestion_profile_column",
+            "table_name",
+            "column_name",
+            "ingested_at",
+        ),
+    )
+    id = Column(BigInteger, autoincrement=True, primary_key=True)
+    table_name = Column(String, nullable=False)
+    file_name = Column(String, nullable=False)
+    remote_file_path = Column(String)
+    ingested_at = Column(DateTime, nullable=False)
+    ingested_by = Column(String)
+    column_name = Column(String, nullable=False)
+    value_count = Column(BigInteger, nullable=False)
+    null_count = Column(BigInteger, nullable=False)
+    distinct_estimate = Column(BigInteger, nullable=False)
+    min_value = Column(Float)
+    max_value = Column(Float)
+    mean_value = Column(Float)
+    min_time = Column(DateTime)
+    max_time = Column(DateTime)
+    histogram = Column(JSONB)
+
+
+class ProcessorJob(Base):
+    __tablename__ = "processor_job"
+    __table_args__ = (
+        Index("ix_processor_job_state", "state", "created_at"),
+        # a second submit of an input that is still queued or running returns the same job
+        Index(
+            "ux_processor_job_active_input",
+            "input_file",
+            unique=True,
+            postgresql_where=text("state IN ('queued', 'running')"),
+        ),
+    )
+    id = Column(UUID(as_uuid=True), primary_key=True)
+    dataset = Column(String, nullable=False)
+    input_file = Column(String, nullable=False)
+    remote_file_path = Column(String)
+    options = Column(JSONB)
+    state = Column(String, nullable=False)
+    submitted_by = Column(String)
+    attempts = Column(Integer, nullable=False, default=0)
+    cancel_requested = Column(Boolean, nullable=False, default=False)
+    # set before the first batch is written, such a job is never run again from scratch
+    writes_started = Column(
+        Boolean, nullable=False, default=False, server_default=text("false")
+    )
+    result = Column(JSONB)
+    progress = Column(JSONB)
+    error_code = Column(String)
+    error = Column(String)
+    created_at = Column(DateTime, nullable=False)
+    started_at = Column(DateTime)
+    heartbeat_at = Column(DateTime)
+    finished_at = Column(DateTime)
@@ -102,11 +102,14 @@ def transform(self: TaskBase, etl_state: ETLState):
         f"Type: {etl_state.conf.type_str}, Start transforming file {etl_state.remote_file_path}"
     )
 
-    respose = self.connect_grpc(etl_state)
+    respose = self.connect_grpc(etl_state, self.request.id)
     print(f"total rows -> {respose.total_rows}")
     print(f"dropped rows -> {respose.dropped_rows}")
     print(f"processed rows -> {respose.processed_rows}")
     print(f"inserted rows -> {respose.inserted_rows}")
+    print(f"batch retries -> {respose.batch_retries}")
+    print(f"chunks written -> {len(respose.chunks)}")
+    print(f"profiled columns -> {len(respose.profile.columns)}")
     print(f"Total ETL Duration -> {etl_state.total_process}")
 
     logger.info(
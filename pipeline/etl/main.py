@@ -1,7 +1,27 @@
 import argparse
 
 from pipeline.etl.config import ETLType
+from pipeline.protos.transform_pb2 import JobState
+from pipeline.protos.transform_pb2 import WatchJobRequest
+from pipeline.protos.transform_pb2_grpc import TransformServiceStub
+from pipeline.worker_app import TaskBase
 from pipeline.worker_app import app
+from pipeline.worker_app import describe_progress
+
+
+def watch_job(job_id: str) -> None:
+    with TaskBase.grpc_channel([]) as channel:
+        stub = TransformServiceStub(channel)
+        events = stub.WatchJob(
+            WatchJobRequest(id=job_id), metadata=TaskBase.grpc_metadata()
+        )
+        for event in events:
+            if event.HasField("summary"):
+                job = event.summary
+                print(f"{JobState.Name(job.state)} {job.error_code} {job.error}")
+                print(job.result)
+                return
+            print(describe_progress(event))
 
 
 def main() -> None:
@@ -15,6 +35,9 @@ def main() -> None:
         ],
     )
 
+    parser_watch = subparsers.add_parser("watch")
+    parser_watch.add_argument("job_id")
+
     args, unknownargs = parser.parse_known_args()
 
     if args.subparser_name == "run" and args.mode == "start-etl":
@@ -23,6 +46,8 @@ def main() -> None:
         app.signature("pipeline.etl.etl.remote_check").apply_async(
             kwargs={"etl_type": etl_type}, queue="etl"
         )
+    elif args.subparser_name == "watch":
+        watch_job(args.job_id)
 
 
 if __name__ == "__main__":
@@ -0,0 +1,59 @@
+"""add ingestion_profile table
+
+Revision ID: 3c9e51b7a2d4
+Revises: f6aaf36dd238
+Create Date: 2026-10-19 10:12:41.208317
+
+"""
+from typing import Sequence
+from typing import Union
+
+import sqlalchemy as sa
+from alembic import op
+from sqlalchemy.dialects import postgresql
+
+
+# revision identifiers, used by Alembic.
+revision: str = "3c9e51b7a2d4"
+down_revision: Union[str, Sequence[str], None] = "f6aaf36dd238"
+branch_labels: Union[str, Sequence[str], None] = None
+depends_on: Union[str, Sequence[str], None] = None
+
+
+def upgrade() -> None:
+    """Upgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.create_table(
+        "ingestion_profile",
+        sa.Column("id", sa.BigInteger(), autoincrement=True, nullable=False),
+        sa.Column("table_name", sa.String(), nullable=False),
+        sa.Column("file_name", sa.String(), nullable=False),
+        sa.Column("remote_file_path", sa.String(), nullable=True),
+        sa.Column("ingested_at", sa.DateTime(), nullable=False),
+        sa.Column("column_name", sa.String(), nullable=False),
+        sa.Column("value_count", sa.BigInteger(), nullable=False),
+        sa.Column("null_count", sa.BigInteger(), nullable=False),
+        sa.Column("distinct_estimate", sa.BigInteger(), nullable=False),
+        sa.Column("min_value", sa.Float(), nullable=True),
+        sa.Column("max_value", sa.Float(), nullable=True),
+        sa.Column("mean_value", sa.Float(), nullable=True),
+        sa.Column("min_time", sa.DateTime(), nullable=True),
+        sa.Column("max_time", sa.DateTime(), nullable=True),
+        sa.Column("histogram", postgresql.JSONB(), nullable=True),
+        sa.PrimaryKeyConstraint("id"),
+    )
+    op.create_index(
+        "ix_ingestion_profile_column",
+        "ingestion_profile",
+        ["table_name", "column_name", "ingested_at"],
+        unique=False,
+    )
+    # ### end Alembic commands ###
+
+
+def downgrade() -> None:
+    """Downgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.drop_index("ix_ingestion_profile_column", table_name="ingestion_profile")
+    op.drop_table("ingestion_profile")
+    # ### end Alembic commands ###
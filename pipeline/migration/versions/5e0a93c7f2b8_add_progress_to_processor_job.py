@@ -0,0 +1,36 @@
+"""add progress to processor_job
+
+Revision ID: 5e0a93c7f2b8
+Revises: b41e7c92d5fa
+Create Date: 2026-10-19 16:48:55.102937
+
+"""
+from typing import Sequence
+from typing import Union
+
+import sqlalchemy as sa
+from alembic import op
+from sqlalchemy.dialects import postgresql
+
+
+# revision identifiers, used by Alembic.
+revision: str = "5e0a93c7f2b8"
+down_revision: Union[str, Sequence[str], None] = "b41e7c92d5fa"
+branch_labels: Union[str, Sequence[str], None] = None
+depends_on: Union[str, Sequence[str], None] = None
+
+
+def upgrade() -> None:
+    """Upgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.add_column(
+        "processor_job", sa.Column("progress", postgresql.JSONB(), nullable=True)
+    )
+    # ### end Alembic commands ###
+
+
+def downgrade() -> None:
+    """Downgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.drop_column("processor_job", "progress")
+    # ### end Alembic commands ###
@@ -0,0 +1,35 @@
+"""add ingested_by to ingestion_profile
+
+Revision ID: 8d1f0c7be5a3
+Revises: 3c9e51b7a2d4
+Create Date: 2026-10-19 14:03:27.518094
+
+"""
+from typing import Sequence
+from typing import Union
+
+import sqlalchemy as sa
+from alembic import op
+
+
+# revision identifiers, used by Alembic.
+revision: str = "8d1f0c7be5a3"
+down_revision: Union[str, Sequence[str], None] = "3c9e51b7a2d4"
+branch_labels: Union[str, Sequence[str], None] = None
+depends_on: Union[str, Sequence[str], None] = None
+
+
+def upgrade() -> None:
+    """Upgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.add_column(
+        "ingestion_profile", sa.Column("ingested_by", sa.String(), nullable=True)
+    )
+    # ### end Alembic commands ###
+
+
+def downgrade() -> None:
+    """Downgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.drop_column("ingestion_profile", "ingested_by")
+    # ### end Alembic commands ###
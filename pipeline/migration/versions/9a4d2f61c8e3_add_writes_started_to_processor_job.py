@@ -0,0 +1,41 @@
+"""add writes_started to processor_job
+
+Revision ID: 9a4d2f61c8e3
+Revises: 5e0a93c7f2b8
+Create Date: 2026-10-20 10:12:41.380527
+
+"""
+from typing import Sequence
+from typing import Union
+
+import sqlalchemy as sa
+from alembic import op
+
+
+# revision identifiers, used by Alembic.
+revision: str = "9a4d2f61c8e3"
+down_revision: Union[str, Sequence[str], None] = "5e0a93c7f2b8"
+branch_labels: Union[str, Sequence[str], None] = None
+depends_on: Union[str, Sequence[str], None] = None
+
+
+def upgrade() -> None:
+    """Upgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.add_column(
+        "processor_job",
+        sa.Column(
+            "writes_started",
+            sa.Boolean(),
+            server_default=sa.text("false"),
+            nullable=False,
+        ),
+    )
+    # ### end Alembic commands ###
+
+
+def downgrade() -> None:
+    """Downgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.drop_column("processor_job", "writes_started")
+    # ### end Alembic commands ###
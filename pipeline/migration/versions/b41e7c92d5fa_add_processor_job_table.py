@@ -0,0 +1,72 @@
+"""add processor_job table
+
+Revision ID: b41e7c92d5fa
+Revises: 8d1f0c7be5a3
+Create Date: 2026-10-19 15:21:08.734512
+
+"""
+from typing import Sequence
+from typing import Union
+
+import sqlalchemy as sa
+from alembic import op
+from sqlalchemy.dialects import postgresql
+
+
+# revision identifiers, used by Alembic.
+revision: str = "b41e7c92d5fa"
+down_revision: Union[str, Sequence[str], None] = "8d1f0c7be5a3"
+branch_labels: Union[str, Sequence[str], None] = None
+depends_on: Union[str, Sequence[str], None] = None
+
+
+def upgrade() -> None:
+    """Upgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.create_table(
+        "processor_job",
+        sa.Column("id", postgresql.UUID(as_uuid=True), nullable=False),
+        sa.Column("dataset", sa.String(), nullable=False),
+        sa.Column("input_file", sa.String(), nullable=False),
+        sa.Column("remote_file_path", sa.String(), nullable=True),
+        sa.Column("options", postgresql.JSONB(), nullable=True),
+        sa.Column("state", sa.String(), nullable=False),
+        sa.Column("submitted_by", sa.String(), nullable=True),
+        sa.Column("attempts", sa.Integer(), nullable=False),
+        sa.Column("cancel_requested", sa.Boolean(), nullable=False),
+        sa.Column("result", postgresql.JSONB(), nullable=True),
+        sa.Column("error_code", sa.String(), nullable=True),
+        sa.Column("error", sa.String(), nullable=True),
+        sa.Column("created_at", sa.DateTime(), nullable=False),
+        sa.Column("started_at", sa.DateTime(), nullable=True),
+        sa.Column("heartbeat_at", sa.DateTime(), nullable=True),
+        sa.Column("finished_at", sa.DateTime(), nullable=True),
+        sa.PrimaryKeyConstraint("id"),
+    )
+    op.create_index(
+        "ix_processor_job_state",
+        "processor_job",
+        ["state", "created_at"],
+        unique=False,
+    )
+    op.create_index(
+        "ux_processor_job_active_input",
+        "processor_job",
+        ["input_file"],
+        unique=True,
+        postgresql_where=sa.text("state IN ('queued', 'running')"),
+    )
+    # ### end Alembic commands ###
+
+
+def downgrade() -> None:
+    """Downgrade schema."""
+    # ### commands auto generated by Alembic - please adjust! ###
+    op.drop_index(
+        "ux_processor_job_active_input",
+        table_name="processor_job",
+        postgresql_where=sa.text("state IN ('queued', 'running')"),
+    )
+    op.drop_index("ix_processor_job_state", table_name="processor_job")
+    op.drop_table("processor_job")
+    # ### end Alembic commands ###
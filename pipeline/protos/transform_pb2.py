@@ -25,7 +25,7 @@ _sym_db = _symbol_database.Default()
 from google.protobuf import timestamp_pb2 as google_dot_protobuf_dot_timestamp__pb2
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0ftransform.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"@\n\x10InputFileRequest\x12\x12\n\ninput_file\x18\x01 \x01(\t\x12\x18\n\x10remote_file_path\x18\x02 \x01(\t\"\xca\x01\n\x13ProcessFileResponse\x12\x12\n\ntotal_rows\x18\x01 \x01(\x03\x12\x14\n\x0c\x64ropped_rows\x18\x02 \x01(\x03\x12\x16\n\x0eprocessed_rows\x18\x03 \x01(\x03\x12\x15\n\rinserted_rows\x18\x04 \x01(\x03\x12,\n\x08max_time\x18\x05 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12,\n\x08min_time\x18\x06 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\"+\n\x14InputFileTestRequest\x12\x13\n\x0blocation_id\x18\x01 \x01(\x03\"N\n\x17ProcessFileTestResponse\x12\x0f\n\x07\x62orough\x18\x01 \x01(\t\x12\x0c\n\x04zone\x18\x02 \x01(\t\x12\x14\n\x0cservice_zone\x18\x03 \x01(\t2\x94\x01\n\x10TransformService\x12;\n\x0eProcessNYCTrip\x12\x11.InputFileRequest\x1a\x14.ProcessFileResponse\"\x00\x12\x43\n\x0eProcessTesting\x12\x15.InputFileTestRequest\x1a\x18.ProcessFileTestResponse\"\x00\x42\x19Z\x17processor/protos;protosb\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0ftransform.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"c\n\x10InputFileRequest\x12\x12\n\ninput_file\x18\x01 \x01(\t\x12\x18\n\x10remote_file_path\x18\x02 \x01(\t\x12!\n\x07options\x18\x03 \x01(\x0b\x32\x10.PipelineOptions\"\x97\x01\n\x13ValidateFileRequest\x12\x14\n\ninput_file\x18\x01 \x01(\tH\x00\x12\x15\n\x0binline_data\x18\x02 \x01(\x0cH\x00\x12\x11\n\tfile_name\x18\x03 \x01(\t\x12!\n\x07options\x18\x04 \x01(\x0b\x32\x10.PipelineOptions\x12\x13\n\x0bsample_size\x18\x05 \x01(\x05\x42\x08\n\x06source\"G\n\x12PreviewFileRequest\x12\x12\n\ninput_file\x18\x01 \x01(\t\x12\x0e\n\x06member\x18\x02 \x01(\t\x12\r\n\x05limit\x18\x03 \x01(\x05\"a\n\x13PreviewFileResponse\x12\x0e\n\x06member\x18\x01 \x01(\t\x12\x0f\n\x07members\x18\x02 \x03(\t\x12\x0e\n\x06header\x18\x03 \x03(\t\x12\x19\n\x04rows\x18\x04 \x03(\x0b\x32\x0b.PreviewRow\"i\n\nPreviewRow\x12\x13\n\x0bline_number\x18\x01 \x01(\x03\x12\x12\n\nraw_fields\x18\x02 \x03(\t\x12\x15\n\x03row\x18\x03 \x01(\x0b\x32\x08.TripRow\x12\x1b\n\x06\x65rrors\x18\x04 \x03(\x0b\x32\x0b.FieldError\"O\n\nFieldError\x12\r\n\x05\x66ield\x18\x01 \x01(\t\x12\x0e\n\x06reason\x18\x02 \x01(\t\x12\x0f\n\x07message\x18\x03 \x01(\t\x12\x11\n\tdrops_row\x18\x04 \x01(\x08\"\xbc\x05\n\x07TripRow\x12\x0e\n\x06vendor\x18\x01 \x01(\t\x12/\n\x0bpickup_time\x18\x02 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x30\n\x0c\x64ropoff_time\x18\x03 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x17\n\x0fpassenger_count\x18\x04 \x01(\x03\x12\x15\n\rtrip_distance\x18\x05 \x01(\x01\x12\x1a\n\x12pu_location_region\x18\x06 \x01(\t\x12\x18\n\x10pu_location_zone\x18\x07 \x01(\t\x12\x1a\n\x12\x64o_location_region\x18\x08 \x01(\t\x12\x18\n\x10\x64o_location_zone\x18\t \x01(\t\x12\x14\n\x0cpayment_type\x18\n \x01(\t\x12\x18\n\x0b\x66\x61re_amount\x18\x0b \x01(\x01H\x00\x88\x01\x01\x12\x12\n\x05\x65xtra\x18\x0c \x01(\x01H\x01\x88\x01\x01\x12\x14\n\x07mta_tax\x18\r \x01(\x01H\x02\x88\x01\x01\x12\x17\n\ntip_amount\x18\x0e \x01(\x01H\x03\x88\x01\x01\x12\x19\n\x0ctolls_amount\x18\x0f \x01(\x01H\x04\x88\x01\x01\x12\"\n\x15improvement_surcharge\x18\x10 \x01(\x01H\x05\x88\x01\x01\x12\x19\n\x0ctotal_amount\x18\x11 \x01(\x01H\x06\x88\x01\x01\x12!\n\x14\x63ongestion_surcharge\x18\x12 \x01(\x01H\x07\x88\x01\x01\x12\x18\n\x0b\x61irport_fee\x18\x13 \x01(\x01H\x08\x88\x01\x01\x42\x0e\n\x0c_fare_amountB\x08\n\x06_extraB\n\n\x08_mta_taxB\r\n\x0b_tip_amountB\x0f\n\r_tolls_amountB\x18\n\x16_improvement_surchargeB\x0f\n\r_total_amountB\x17\n\x15_congestion_surchargeB\x0e\n\x0c_airport_fee\"\x99\x01\n\x0fPipelineOptions\x12\x16\n\x0eparser_workers\x18\x01 \x01(\x05\x12\x18\n\x10inserter_workers\x18\x02 \x01(\x05\x12\x12\n\nbatch_size\x18\x03 \x01(\x05\x12\x14\n\x0c\x63hannel_size\x18\x04 \x01(\x05\x12\x16\n\x0ereader_workers\x18\x05 \x01(\x05\x12\x12\n\nchunk_size\x18\x06 \x01(\x03\"\x83\x03\n\x13ProcessFileResponse\x12\x12\n\ntotal_rows\x18\x01 \x01(\x03\x12\x14\n\x0c\x64ropped_rows\x18\x02 \x01(\x03\x12\x16\n\x0eprocessed_rows\x18\x03 \x01(\x03\x12\x15\n\rinserted_rows\x18\x04 \x01(\x03\x12,\n\x08max_time\x18\x05 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12,\n\x08min_time\x18\x06 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x15\n\rbatch_retries\x18\x07 \x01(\x03\x12 \n\x08\x61\x64\x61ptive\x18\x08 \x01(\x0b\x32\x0e.AdaptiveStats\x12\x1b\n\x06\x63hunks\x18\t \x03(\x0b\x32\x0b.ChunkStats\x12\x19\n\x07profile\x18\n \x01(\x0b\x32\x08.Profile\x12!\n\x0c\x64rop_reasons\x18\x0b \x03(\x0b\x32\x0b.DropReason\x12#\n\rrejected_rows\x18\x0c \x03(\x0b\x32\x0c.RejectedRow\":\n\nDropReason\x12\r\n\x05\x66ield\x18\x01 \x01(\t\x12\x0e\n\x06reason\x18\x02 \x01(\t\x12\r\n\x05\x63ount\x18\x03 \x01(\x03\"e\n\x0bRejectedRow\x12\x0e\n\x06member\x18\x01 \x01(\t\x12\x13\n\x0bline_number\x18\x02 \x01(\x03\x12\r\n\x05\x66ield\x18\x03 \x01(\t\x12\x0e\n\x06reason\x18\x04 \x01(\t\x12\x12\n\nraw_fields\x18\x05 \x03(\t\"\xa1\x01\n\rAdaptiveStats\x12\x12\n\nbatch_size\x18\x01 \x01(\x03\x12\x16\n\x0emin_batch_size\x18\x02 \x01(\x03\x12\x16\n\x0emax_batch_size\x18\x03 \x01(\x03\x12\x19\n\x11\x62\x61tch_adjustments\x18\x04 \x01(\x03\x12\x14\n\x0cscale_events\x18\x05 \x01(\x03\x12\x1b\n\x06stages\x18\x06 \x03(\x0b\x32\x0b.StageStats\"G\n\nStageStats\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x15\n\rfinal_workers\x18\x02 \x01(\x03\x12\x14\n\x0cpeak_workers\x18\x03 \x01(\x03\"8\n\x07Profile\x12\x0c\n\x04rows\x18\x01 \x01(\x03\x12\x1f\n\x07\x63olumns\x18\x02 \x03(\x0b\x32\x0e.ColumnProfile\"\xf9\x01\n\rColumnProfile\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\r\n\x05\x63ount\x18\x02 \x01(\x03\x12\r\n\x05nulls\x18\x03 \x01(\x03\x12\x19\n\x11\x64istinct_estimate\x18\x04 \x01(\x03\x12\x0b\n\x03min\x18\x05 \x01(\x01\x12\x0b\n\x03max\x18\x06 \x01(\x01\x12\x0c\n\x04mean\x18\x07 \x01(\x01\x12,\n\x08min_time\x18\x08 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12,\n\x08max_time\x18\t \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x1d\n\thistogram\x18\n \x01(\x0b\x32\n.Histogram\"1\n\tHistogram\x12\x14\n\x0cupper_bounds\x18\x01 \x03(\x01\x12\x0e\n\x06\x63ounts\x18\x02 \x03(\x03\"\x7f\n\nChunkStats\x12)\n\x05start\x18\x01 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\'\n\x03\x65nd\x18\x02 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x0c\n\x04rows\x18\x03 \x01(\x03\x12\x0f\n\x07\x62\x61tches\x18\x04 \x01(\x03\"\xc9\x03\n\x03Job\x12\n\n\x02id\x18\x01 \x01(\t\x12\x18\n\x05state\x18\x02 \x01(\x0e\x32\t.JobState\x12\x0f\n\x07\x64\x61taset\x18\x03 \x01(\t\x12\x12\n\ninput_file\x18\x04 \x01(\t\x12\x18\n\x10remote_file_path\x18\x05 \x01(\t\x12!\n\x07options\x18\x06 \x01(\x0b\x32\x10.PipelineOptions\x12\x14\n\x0csubmitted_by\x18\x07 \x01(\t\x12\x10\n\x08\x61ttempts\x18\x08 \x01(\x05\x12.\n\ncreated_at\x18\t \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12.\n\nstarted_at\x18\n \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12/\n\x0b\x66inished_at\x18\x0b \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12$\n\x06result\x18\x0c \x01(\x0b\x32\x14.ProcessFileResponse\x12\x12\n\nerror_code\x18\r \x01(\t\x12\r\n\x05\x65rror\x18\x0e \x01(\t\x12\x18\n\x10\x63\x61ncel_requested\x18\x0f \x01(\x08\x12\x1e\n\x08progress\x18\x10 \x01(\x0b\x32\x0c.JobProgress\"\x1b\n\rGetJobRequest\x12\n\n\x02id\x18\x01 \x01(\t\"R\n\x0fListJobsRequest\x12\x18\n\x05state\x18\x01 \x01(\x0e\x32\t.JobState\x12\x11\n\tpage_size\x18\x02 \x01(\x05\x12\x12\n\npage_token\x18\x03 \x01(\t\"?\n\x10ListJobsResponse\x12\x12\n\x04jobs\x18\x01 \x03(\x0b\x32\x04.Job\x12\x17\n\x0fnext_page_token\x18\x02 \x01(\t\"\x1e\n\x10\x43\x61ncelJobRequest\x12\n\n\x02id\x18\x01 \x01(\t\"2\n\x0fWatchJobRequest\x12\n\n\x02id\x18\x01 \x01(\t\x12\x13\n\x0binterval_ms\x18\x02 \x01(\x05\"\xfd\x01\n\x0bJobProgress\x12\x0e\n\x06job_id\x18\x01 \x01(\t\x12\x18\n\x05state\x18\x02 \x01(\x0e\x32\t.JobState\x12\x12\n\nbytes_read\x18\x03 \x01(\x03\x12\x13\n\x0btotal_bytes\x18\x04 \x01(\x03\x12\x13\n\x0brows_parsed\x18\x05 \x01(\x03\x12\x14\n\x0crows_dropped\x18\x06 \x01(\x03\x12\x15\n\rrows_inserted\x18\x07 \x01(\x03\x12\x0e\n\x06member\x18\x08 \x01(\t\x12\x17\n\x0f\x65lapsed_seconds\x18\t \x01(\x01\x12\x19\n\x11remaining_seconds\x18\n \x01(\x01\x12\x15\n\x07summary\x18\x0b \x01(\x0b\x32\x04.Job\"P\n\rUploadRequest\x12#\n\x08metadata\x18\x01 \x01(\x0b\x32\x0f.UploadMetadataH\x00\x12\x0f\n\x05\x63hunk\x18\x02 \x01(\x0cH\x00\x42\t\n\x07payload\"\xb2\x01\n\x0eUploadMetadata\x12\x0f\n\x07\x64\x61taset\x18\x01 \x01(\t\x12\x11\n\tfile_name\x18\x02 \x01(\t\x12!\n\x0b\x63ompression\x18\x03 \x01(\x0e\x32\x0c.Compression\x12\x0e\n\x06sha256\x18\x04 \x01(\t\x12\x0c\n\x04size\x18\x05 \x01(\x03\x12\x18\n\x10remote_file_path\x18\x06 \x01(\t\x12!\n\x07options\x18\x07 \x01(\x0b\x32\x10.PipelineOptions\"\x1b\n\x19GetSchedulerStatusRequest\"\xc1\x01\n\x0fSchedulerStatus\x12\x13\n\x0bmax_running\x18\x01 \x01(\x05\x12\x12\n\nmax_queued\x18\x02 \x01(\x05\x12\x1b\n\x13retry_after_seconds\x18\x03 \x01(\x01\x12#\n\x08\x64\x61tasets\x18\x04 \x03(\x0b\x32\x11.DatasetAdmission\x12!\n\x07running\x18\x05 \x03(\x0b\x32\x10.AdmissionTicket\x12 \n\x06queued\x18\x06 \x03(\x0b\x32\x10.AdmissionTicket\"S\n\x10\x44\x61tasetAdmission\x12\x0f\n\x07\x64\x61taset\x18\x01 \x01(\t\x12\r\n\x05limit\x18\x02 \x01(\x05\x12\x0f\n\x07running\x18\x03 \x01(\x05\x12\x0e\n\x06queued\x18\x04 \x01(\x05\"y\n\x0f\x41\x64missionTicket\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0f\n\x07\x64\x61taset\x18\x02 \x01(\t\x12\x0e\n\x06source\x18\x03 \x01(\t\x12\x0e\n\x06\x63\x61ller\x18\x04 \x01(\t\x12)\n\x05since\x18\x05 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\"+\n\x14InputFileTestRequest\x12\x13\n\x0blocation_id\x18\x01 \x01(\x03\"N\n\x17ProcessFileTestResponse\x12\x0f\n\x07\x62orough\x18\x01 \x01(\t\x12\x0c\n\x04zone\x18\x02 \x01(\t\x12\x14\n\x0cservice_zone\x18\x03 \x01(\t*\x9a\x01\n\x08JobState\x12\x19\n\x15JOB_STATE_UNSPECIFIED\x10\x00\x12\x14\n\x10JOB_STATE_QUEUED\x10\x01\x12\x15\n\x11JOB_STATE_RUNNING\x10\x02\x12\x17\n\x13JOB_STATE_SUCCEEDED\x10\x03\x12\x14\n\x10JOB_STATE_FAILED\x10\x04\x12\x17\n\x13JOB_STATE_CANCELLED\x10\x05*9\n\x0b\x43ompression\x12\x14\n\x10\x43OMPRESSION_NONE\x10\x00\x12\x14\n\x10\x43OMPRESSION_GZIP\x10\x01*\xb7\x06\n\x0b\x45rrorReason\x12\x1c\n\x18\x45RROR_REASON_UNSPECIFIED\x10\x00\x12 \n\x1c\x45RROR_REASON_INVALID_REQUEST\x10\x01\x12 \n\x1c\x45RROR_REASON_INVALID_OPTIONS\x10\x02\x12&\n\"ERROR_REASON_UNSUPPORTED_FILE_TYPE\x10\x03\x12 \n\x1c\x45RROR_REASON_INVALID_ARCHIVE\x10\x04\x12 \n\x1c\x45RROR_REASON_INPUT_NOT_FOUND\x10\x05\x12!\n\x1d\x45RROR_REASON_MEMBER_NOT_FOUND\x10\x06\x12#\n\x1f\x45RROR_REASON_LOCATION_NOT_FOUND\x10\x07\x12\x1e\n\x1a\x45RROR_REASON_JOB_NOT_FOUND\x10\x08\x12 \n\x1c\x45RROR_REASON_SCHEMA_MISMATCH\x10\t\x12\x1d\n\x19\x45RROR_REASON_JOB_FINISHED\x10\n\x12\x1f\n\x1b\x45RROR_REASON_DUPLICATE_FILE\x10\x0b\x12\x1b\n\x17\x45RROR_REASON_LEASE_LOST\x10\x0c\x12%\n!ERROR_REASON_DATABASE_UNAVAILABLE\x10\r\x12\"\n\x1e\x45RROR_REASON_REDIS_UNAVAILABLE\x10\x0e\x12\x1e\n\x1a\x45RROR_REASON_MAP_NOT_READY\x10\x0f\x12\x1e\n\x1a\x45RROR_REASON_SHUTTING_DOWN\x10\x10\x12\x1b\n\x17\x45RROR_REASON_QUEUE_FULL\x10\x11\x12\"\n\x1e\x45RROR_REASON_ADMISSION_TIMEOUT\x10\x12\x12!\n\x1d\x45RROR_REASON_UPLOAD_TOO_LARGE\x10\x13\x12\"\n\x1e\x45RROR_REASON_CHECKSUM_MISMATCH\x10\x14\x12\"\n\x1e\x45RROR_REASON_UPLOAD_INCOMPLETE\x10\x15\x12\x19\n\x15\x45RROR_REASON_INTERNAL\x10\x16\x12!\n\x1d\x45RROR_REASON_PARTIALLY_LOADED\x10\x17\x32\xe7\x04\n\x10TransformService\x12;\n\x0eProcessNYCTrip\x12\x11.InputFileRequest\x1a\x14.ProcessFileResponse\"\x00\x12<\n\x0cValidateFile\x12\x14.ValidateFileRequest\x1a\x14.ProcessFileResponse\"\x00\x12:\n\x0bPreviewFile\x12\x13.PreviewFileRequest\x1a\x14.PreviewFileResponse\"\x00\x12&\n\tSubmitJob\x12\x11.InputFileRequest\x1a\x04.Job\"\x00\x12 \n\x06GetJob\x12\x0e.GetJobRequest\x1a\x04.Job\"\x00\x12\x31\n\x08ListJobs\x12\x10.ListJobsRequest\x1a\x11.ListJobsResponse\"\x00\x12&\n\tCancelJob\x12\x11.CancelJobRequest\x1a\x04.Job\"\x00\x12.\n\x08WatchJob\x12\x10.WatchJobRequest\x1a\x0c.JobProgress\"\x00\x30\x01\x12<\n\x10UploadAndProcess\x12\x0e.UploadRequest\x1a\x14.ProcessFileResponse\"\x00(\x01\x12\x44\n\x12GetSchedulerStatus\x12\x1a.GetSchedulerStatusRequest\x1a\x10.SchedulerStatus\"\x00\x12\x43\n\x0eProcessTesting\x12\x15.InputFileTestRequest\x1a\x18.ProcessFileTestResponse\"\x00\x42\x19Z\x17processor/protos;protosb\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -33,14 +33,76 @@ _builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, 'transform_pb2', _globals)
 if not _descriptor._USE_C_DESCRIPTORS:
   _globals['DESCRIPTOR']._loaded_options = None
   _globals['DESCRIPTOR']._serialized_options = b'Z\027processor/protos;protos'
+  _globals['_JOBSTATE']._serialized_start=4606
+  _globals['_JOBSTATE']._serialized_end=4760
+  _globals['_COMPRESSION']._serialized_start=4762
+  _globals['_COMPRESSION']._serialized_end=4819
+  _globals['_ERRORREASON']._serialized_start=4822
+  _globals['_ERRORREASON']._serialized_end=5645
   _globals['_INPUTFILEREQUEST']._serialized_start=52
-  _globals['_INPUTFILEREQUEST']._serialized_end=116
-  _globals['_PROCESSFILERESPONSE']._serialized_start=119
-  _globals['_PROCESSFILERESPONSE']._serialized_end=321
-  _globals['_INPUTFILETESTREQUEST']._serialized_start=323
-  _globals['_INPUTFILETESTREQUEST']._serialized_end=366
-  _globals['_PROCESSFILETESTRESPONSE']._serialized_start=368
-  _globals['_PROCESSFILETESTRESPONSE']._serialized_end=446
-  _globals['_TRANSFORMSERVICE']._serialized_start=449
-  _globals['_TRANSFORMSERVICE']._serialized_end=597
+  _globals['_INPUTFILEREQUEST']._serialized_end=151
+  _globals['_VALIDATEFILEREQUEST']._serialized_start=154
+  _globals['_VALIDATEFILEREQUEST']._serialized_end=305
+  _globals['_PREVIEWFILEREQUEST']._serialized_start=307
+  _globals['_PREVIEWFILEREQUEST']._serialized_end=378
+  _globals['_PREVIEWFILERESPONSE']._serialized_start=380
+  _globals['_PREVIEWFILERESPONSE']._serialized_end=477
+  _globals['_PREVIEWROW']._serialized_start=479
+  _globals['_PREVIEWROW']._serialized_end=584
+  _globals['_FIELDERROR']._serialized_start=586
+  _globals['_FIELDERROR']._serialized_end=665
+  _globals['_TRIPROW']._serialized_start=668
+  _globals['_TRIPROW']._serialized_end=1368
+  _globals['_PIPELINEOPTIONS']._serialized_start=1371
+  _globals['_PIPELINEOPTIONS']._serialized_end=1524
+  _globals['_PROCESSFILERESPONSE']._serialized_start=1527
+  _globals['_PROCESSFILERESPONSE']._serialized_end=1914
+  _globals['_DROPREASON']._serialized_start=1916
+  _globals['_DROPREASON']._serialized_end=1974
+  _globals['_REJECTEDROW']._serialized_start=1976
+  _globals['_REJECTEDROW']._serialized_end=2077
+  _globals['_ADAPTIVESTATS']._serialized_start=2080
+  _globals['_ADAPTIVESTATS']._serialized_end=2241
+  _globals['_STAGESTATS']._serialized_start=2243
+  _globals['_STAGESTATS']._serialized_end=2314
+  _globals['_PROFILE']._serialized_start=2316
+  _globals['_PROFILE']._serialized_end=2372
+  _globals['_COLUMNPROFILE']._serialized_start=2375
+  _globals['_COLUMNPROFILE']._serialized_end=2624
+  _globals['_HISTOGRAM']._serialized_start=2626
+  _globals['_HISTOGRAM']._serialized_end=2675
+  _globals['_CHUNKSTATS']._serialized_start=2677
+  _globals['_CHUNKSTATS']._serialized_end=2804
+  _globals['_JOB']._serialized_start=2807
+  _globals['_JOB']._serialized_end=3264
+  _globals['_GETJOBREQUEST']._serialized_start=3266
+  _globals['_GETJOBREQUEST']._serialized_end=3293
+  _globals['_LISTJOBSREQUEST']._serialized_start=3295
+  _globals['_LISTJOBSREQUEST']._serialized_end=3377
+  _globals['_LISTJOBSRESPONSE']._serialized_start=3379
+  _globals['_LISTJOBSRESPONSE']._serialized_end=3442
+  _globals['_CANCELJOBREQUEST']._serialized_start=3444
+  _globals['_CANCELJOBREQUEST']._serialized_end=3474
+  _globals['_WATCHJOBREQUEST']._serialized_start=3476
+  _globals['_WATCHJOBREQUEST']._serialized_end=3526
+  _globals['_JOBPROGRESS']._serialized_start=3529
+  _globals['_JOBPROGRESS']._serialized_end=3782
+  _globals['_UPLOADREQUEST']._serialized_start=3784
+  _globals['_UPLOADREQUEST']._serialized_end=3864
+  _globals['_UPLOADMETADATA']._serialized_start=3867
+  _globals['_UPLOADMETADATA']._serialized_end=4045
+  _globals['_GETSCHEDULERSTATUSREQUEST']._serialized_start=4047
+  _globals['_GETSCHEDULERSTATUSREQUEST']._serialized_end=4074
+  _globals['_SCHEDULERSTATUS']._serialized_start=4077
+  _globals['_SCHEDULERSTATUS']._serialized_end=4270
+  _globals['_DATASETADMISSION']._serialized_start=4272
+  _globals['_DATASETADMISSION']._serialized_end=4355
+  _globals['_ADMISSIONTICKET']._serialized_start=4357
+  _globals['_ADMISSIONTICKET']._serialized_end=4478
+  _globals['_INPUTFILETESTREQUEST']._serialized_start=4480
+  _globals['_INPUTFILETESTREQUEST']._serialized_end=4523
+  _globals['_PROCESSFILETESTRESPONSE']._serialized_start=4525
+  _globals['_PROCESSFILETESTRESPONSE']._serialized_end=4603
+  _globals['_TRANSFORMSERVICE']._serialized_start=5648
+  _globals['_TRANSFORMSERVICE']._serialized_end=6263
 # @@protoc_insertion_point(module_scope)
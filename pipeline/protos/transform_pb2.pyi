@@ -1,35 +1,516 @@
 import datetime
 from google.protobuf import timestamp_pb2 as _timestamp_pb2
+from google.protobuf.internal import containers as _containers
+from google.protobuf.internal import enum_type_wrapper as _enum_type_wrapper
 from google.protobuf import descriptor as _descriptor
 from google.protobuf import message as _message
-from collections.abc import Mapping as _Mapping
+from collections.abc import Iterable as _Iterable, Mapping as _Mapping
 from typing import ClassVar as _ClassVar, Optional as _Optional, Union as _Union
 
 DESCRIPTOR: _descriptor.FileDescriptor
 
+class JobState(int, metaclass=_enum_type_wrapper.EnumTypeWrapper):
+    __slots__ = ()
+    JOB_STATE_UNSPECIFIED: _ClassVar[JobState]
+    JOB_STATE_QUEUED: _ClassVar[JobState]
+    JOB_STATE_RUNNING: _ClassVar[JobState]
+    JOB_STATE_SUCCEEDED: _ClassVar[JobState]
+    JOB_STATE_FAILED: _ClassVar[JobState]
+    JOB_STATE_CANCELLED: _ClassVar[JobState]
+
+class Compression(int, metaclass=_enum_type_wrapper.EnumTypeWrapper):
+    __slots__ = ()
+    COMPRESSION_NONE: _ClassVar[Compression]
+    COMPRESSION_GZIP: _ClassVar[Compression]
+
+class ErrorReason(int, metaclass=_enum_type_wrapper.EnumTypeWrapper):
+    __slots__ = ()
+    ERROR_REASON_UNSPECIFIED: _ClassVar[ErrorReason]
+    ERROR_REASON_INVALID_REQUEST: _ClassVar[ErrorReason]
+    ERROR_REASON_INVALID_OPTIONS: _ClassVar[ErrorReason]
+    ERROR_REASON_UNSUPPORTED_FILE_TYPE: _ClassVar[ErrorReason]
+    ERROR_REASON_INVALID_ARCHIVE: _ClassVar[ErrorReason]
+    ERROR_REASON_INPUT_NOT_FOUND: _ClassVar[ErrorReason]
+    ERROR_REASON_MEMBER_NOT_FOUND: _ClassVar[ErrorReason]
+    ERROR_REASON_LOCATION_NOT_FOUND: _ClassVar[ErrorReason]
+    ERROR_REASON_JOB_NOT_FOUND: _ClassVar[ErrorReason]
+    ERROR_REASON_SCHEMA_MISMATCH: _ClassVar[ErrorReason]
+    ERROR_REASON_JOB_FINISHED: _ClassVar[ErrorReason]
+    ERROR_REASON_DUPLICATE_FILE: _ClassVar[ErrorReason]
+    ERROR_REASON_LEASE_LOST: _ClassVar[ErrorReason]
+    ERROR_REASON_DATABASE_UNAVAILABLE: _ClassVar[ErrorReason]
+    ERROR_REASON_REDIS_UNAVAILABLE: _ClassVar[ErrorReason]
+    ERROR_REASON_MAP_NOT_READY: _ClassVar[ErrorReason]
+    ERROR_REASON_SHUTTING_DOWN: _ClassVar[ErrorReason]
+    ERROR_REASON_QUEUE_FULL: _ClassVar[ErrorReason]
+    ERROR_REASON_ADMISSION_TIMEOUT: _ClassVar[ErrorReason]
+    ERROR_REASON_UPLOAD_TOO_LARGE: _ClassVar[ErrorReason]
+    ERROR_REASON_CHECKSUM_MISMATCH: _ClassVar[ErrorReason]
+    ERROR_REASON_UPLOAD_INCOMPLETE: _ClassVar[ErrorReason]
+    ERROR_REASON_INTERNAL: _ClassVar[ErrorReason]
+    ERROR_REASON_PARTIALLY_LOADED: _ClassVar[ErrorReason]
+JOB_STATE_UNSPECIFIED: JobState
+JOB_STATE_QUEUED: JobState
+JOB_STATE_RUNNING: JobState
+JOB_STATE_SUCCEEDED: JobState
+JOB_STATE_FAILED: JobState
+JOB_STATE_CANCELLED: JobState
+COMPRESSION_NONE: Compression
+COMPRESSION_GZIP: Compression
+ERROR_REASON_UNSPECIFIED: ErrorReason
+ERROR_REASON_INVALID_REQUEST: ErrorReason
+ERROR_REASON_INVALID_OPTIONS: ErrorReason
+ERROR_REASON_UNSUPPORTED_FILE_TYPE: ErrorReason
+ERROR_REASON_INVALID_ARCHIVE: ErrorReason
+ERROR_REASON_INPUT_NOT_FOUND: ErrorReason
+ERROR_REASON_MEMBER_NOT_FOUND: ErrorReason
+ERROR_REASON_LOCATION_NOT_FOUND: ErrorReason
+ERROR_REASON_JOB_NOT_FOUND: ErrorReason
+ERROR_REASON_SCHEMA_MISMATCH: ErrorReason
+ERROR_REASON_JOB_FINISHED: ErrorReason
+ERROR_REASON_DUPLICATE_FILE: ErrorReason
+ERROR_REASON_LEASE_LOST: ErrorReason
+ERROR_REASON_DATABASE_UNAVAILABLE: ErrorReason
+ERROR_REASON_REDIS_UNAVAILABLE: ErrorReason
+ERROR_REASON_MAP_NOT_READY: ErrorReason
+ERROR_REASON_SHUTTING_DOWN: ErrorReason
+ERROR_REASON_QUEUE_FULL: ErrorReason
+ERROR_REASON_ADMISSION_TIMEOUT: ErrorReason
+ERROR_REASON_UPLOAD_TOO_LARGE: ErrorReason
+ERROR_REASON_CHECKSUM_MISMATCH: ErrorReason
+ERROR_REASON_UPLOAD_INCOMPLETE: ErrorReason
+ERROR_REASON_INTERNAL: ErrorReason
+ERROR_REASON_PARTIALLY_LOADED: ErrorReason
+
 class InputFileRequest(_message.Message):
-    __slots__ = ("input_file", "remote_file_path")
+    __slots__ = ("input_file", "remote_file_path", "options")
     INPUT_FILE_FIELD_NUMBER: _ClassVar[int]
     REMOTE_FILE_PATH_FIELD_NUMBER: _ClassVar[int]
+    OPTIONS_FIELD_NUMBER: _ClassVar[int]
     input_file: str
     remote_file_path: str
-    def __init__(self, input_file: _Optional[str] = ..., remote_file_path: _Optional[str] = ...) -> None: ...
+    options: PipelineOptions
+    def __init__(self, input_file: _Optional[str] = ..., remote_file_path: _Optional[str] = ..., options: _Optional[_Union[PipelineOptions, _Mapping]] = ...) -> None: ...
+
+class ValidateFileRequest(_message.Message):
+    __slots__ = ("input_file", "inline_data", "file_name", "options", "sample_size")
+    INPUT_FILE_FIELD_NUMBER: _ClassVar[int]
+    INLINE_DATA_FIELD_NUMBER: _ClassVar[int]
+    FILE_NAME_FIELD_NUMBER: _ClassVar[int]
+    OPTIONS_FIELD_NUMBER: _ClassVar[int]
+    SAMPLE_SIZE_FIELD_NUMBER: _ClassVar[int]
+    input_file: str
+    inline_data: bytes
+    file_name: str
+    options: PipelineOptions
+    sample_size: int
+    def __init__(self, input_file: _Optional[str] = ..., inline_data: _Optional[bytes] = ..., file_name: _Optional[str] = ..., options: _Optional[_Union[PipelineOptions, _Mapping]] = ..., sample_size: _Optional[int] = ...) -> None: ...
+
+class PreviewFileRequest(_message.Message):
+    __slots__ = ("input_file", "member", "limit")
+    INPUT_FILE_FIELD_NUMBER: _ClassVar[int]
+    MEMBER_FIELD_NUMBER: _ClassVar[int]
+    LIMIT_FIELD_NUMBER: _ClassVar[int]
+    input_file: str
+    member: str
+    limit: int
+    def __init__(self, input_file: _Optional[str] = ..., member: _Optional[str] = ..., limit: _Optional[int] = ...) -> None: ...
+
+class PreviewFileResponse(_message.Message):
+    __slots__ = ("member", "members", "header", "rows")
+    MEMBER_FIELD_NUMBER: _ClassVar[int]
+    MEMBERS_FIELD_NUMBER: _ClassVar[int]
+    HEADER_FIELD_NUMBER: _ClassVar[int]
+    ROWS_FIELD_NUMBER: _ClassVar[int]
+    member: str
+    members: _containers.RepeatedScalarFieldContainer[str]
+    header: _containers.RepeatedScalarFieldContainer[str]
+    rows: _containers.RepeatedCompositeFieldContainer[PreviewRow]
+    def __init__(self, member: _Optional[str] = ..., members: _Optional[_Iterable[str]] = ..., header: _Optional[_Iterable[str]] = ..., rows: _Optional[_Iterable[_Union[PreviewRow, _Mapping]]] = ...) -> None: ...
+
+class PreviewRow(_message.Message):
+    __slots__ = ("line_number", "raw_fields", "row", "errors")
+    LINE_NUMBER_FIELD_NUMBER: _ClassVar[int]
+    RAW_FIELDS_FIELD_NUMBER: _ClassVar[int]
+    ROW_FIELD_NUMBER: _ClassVar[int]
+    ERRORS_FIELD_NUMBER: _ClassVar[int]
+    line_number: int
+    raw_fields: _containers.RepeatedScalarFieldContainer[str]
+    row: TripRow
+    errors: _containers.RepeatedCompositeFieldContainer[FieldError]
+    def __init__(self, line_number: _Optional[int] = ..., raw_fields: _Optional[_Iterable[str]] = ..., row: _Optional[_Union[TripRow, _Mapping]] = ..., errors: _Optional[_Iterable[_Union[FieldError, _Mapping]]] = ...) -> None: ...
+
+class FieldError(_message.Message):
+    __slots__ = ("field", "reason", "message", "drops_row")
+    FIELD_FIELD_NUMBER: _ClassVar[int]
+    REASON_FIELD_NUMBER: _ClassVar[int]
+    MESSAGE_FIELD_NUMBER: _ClassVar[int]
+    DROPS_ROW_FIELD_NUMBER: _ClassVar[int]
+    field: str
+    reason: str
+    message: str
+    drops_row: bool
+    def __init__(self, field: _Optional[str] = ..., reason: _Optional[str] = ..., message: _Optional[str] = ..., drops_row: _Optional[bool] = ...) -> None: ...
+
+class TripRow(_message.Message):
+    __slots__ = ("vendor", "pickup_time", "dropoff_time", "passenger_count", "trip_distance", "pu_location_region", "pu_location_zone", "do_location_region", "do_location_zone", "payment_type", "fare_amount", "extra", "mta_tax", "tip_amount", "tolls_amount", "improvement_surcharge", "total_amount", "congestion_surcharge", "airport_fee")
+    VENDOR_FIELD_NUMBER: _ClassVar[int]
+    PICKUP_TIME_FIELD_NUMBER: _ClassVar[int]
+    DROPOFF_TIME_FIELD_NUMBER: _ClassVar[int]
+    PASSENGER_COUNT_FIELD_NUMBER: _ClassVar[int]
+    TRIP_DISTANCE_FIELD_NUMBER: _ClassVar[int]
+    PU_LOCATION_REGION_FIELD_NUMBER: _ClassVar[int]
+    PU_LOCATION_ZONE_FIELD_NUMBER: _ClassVar[int]
+    DO_LOCATION_REGION_FIELD_NUMBER: _ClassVar[int]
+    DO_LOCATION_ZONE_FIELD_NUMBER: _ClassVar[int]
+    PAYMENT_TYPE_FIELD_NUMBER: _ClassVar[int]
+    FARE_AMOUNT_FIELD_NUMBER: _ClassVar[int]
+    EXTRA_FIELD_NUMBER: _ClassVar[int]
+    MTA_TAX_FIELD_NUMBER: _ClassVar[int]
+    TIP_AMOUNT_FIELD_NUMBER: _ClassVar[int]
+    TOLLS_AMOUNT_FIELD_NUMBER: _ClassVar[int]
+    IMPROVEMENT_SURCHARGE_FIELD_NUMBER: _ClassVar[int]
+    TOTAL_AMOUNT_FIELD_NUMBER: _ClassVar[int]
+    CONGESTION_SURCHARGE_FIELD_NUMBER: _ClassVar[int]
+    AIRPORT_FEE_FIELD_NUMBER: _ClassVar[int]
+    vendor: str
+    pickup_time: _timestamp_pb2.Timestamp
+    dropoff_time: _timestamp_pb2.Timestamp
+    passenger_count: int
+    trip_distance: float
+    pu_location_region: str
+    pu_location_zone: str
+    do_location_region: str
+    do_location_zone: str
+    payment_type: str
+    fare_amount: float
+    extra: float
+    mta_tax: float
+    tip_amount: float
+    tolls_amount: float
+    improvement_surcharge: float
+    total_amount: float
+    congestion_surcharge: float
+    airport_fee: float
+    def __init__(self, vendor: _Optional[str] = ..., pickup_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., dropoff_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., passenger_count: _Optional[int] = ..., trip_distance: _Optional[float] = ..., pu_location_region: _Optional[str] = ..., pu_location_zone: _Optional[str] = ..., do_location_region: _Optional[str] = ..., do_location_zone: _Optional[str] = ..., payment_type: _Optional[str] = ..., fare_amount: _Optional[float] = ..., extra: _Optional[float] = ..., mta_tax: _Optional[float] = ..., tip_amount: _Optional[float] = ..., tolls_amount: _Optional[float] = ..., improvement_surcharge: _Optional[float] = ..., total_amount: _Optional[float] = ..., congestion_surcharge: _Optional[float] = ..., airport_fee: _Optional[float] = ...) -> None: ...
+
+class PipelineOptions(_message.Message):
+    __slots__ = ("parser_workers", "inserter_workers", "batch_size", "channel_size", "reader_workers", "chunk_size")
+    PARSER_WORKERS_FIELD_NUMBER: _ClassVar[int]
+    INSERTER_WORKERS_FIELD_NUMBER: _ClassVar[int]
+    BATCH_SIZE_FIELD_NUMBER: _ClassVar[int]
+    CHANNEL_SIZE_FIELD_NUMBER: _ClassVar[int]
+    READER_WORKERS_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
+    parser_workers: int
+    inserter_workers: int
+    batch_size: int
+    channel_size: int
+    reader_workers: int
+    chunk_size: int
+    def __init__(self, parser_workers: _Optional[int] = ..., inserter_workers: _Optional[int] = ..., batch_size: _Optional[int] = ..., channel_size: _Optional[int] = ..., reader_workers: _Optional[int] = ..., chunk_size: _Optional[int] = ...) -> None: ...
 
 class ProcessFileResponse(_message.Message):
-    __slots__ = ("total_rows", "dropped_rows", "processed_rows", "inserted_rows", "max_time", "min_time")
+    __slots__ = ("total_rows", "dropped_rows", "processed_rows", "inserted_rows", "max_time", "min_time", "batch_retries", "adaptive", "chunks", "profile", "drop_reasons", "rejected_rows")
     TOTAL_ROWS_FIELD_NUMBER: _ClassVar[int]
     DROPPED_ROWS_FIELD_NUMBER: _ClassVar[int]
     PROCESSED_ROWS_FIELD_NUMBER: _ClassVar[int]
     INSERTED_ROWS_FIELD_NUMBER: _ClassVar[int]
     MAX_TIME_FIELD_NUMBER: _ClassVar[int]
     MIN_TIME_FIELD_NUMBER: _ClassVar[int]
+    BATCH_RETRIES_FIELD_NUMBER: _ClassVar[int]
+    ADAPTIVE_FIELD_NUMBER: _ClassVar[int]
+    CHUNKS_FIELD_NUMBER: _ClassVar[int]
+    PROFILE_FIELD_NUMBER: _ClassVar[int]
+    DROP_REASONS_FIELD_NUMBER: _ClassVar[int]
+    REJECTED_ROWS_FIELD_NUMBER: _ClassVar[int]
     total_rows: int
     dropped_rows: int
     processed_rows: int
     inserted_rows: int
     max_time: _timestamp_pb2.Timestamp
     min_time: _timestamp_pb2.Timestamp
-    def __init__(self, total_rows: _Optional[int] = ..., dropped_rows: _Optional[int] = ..., processed_rows: _Optional[int] = ..., inserted_rows: _Optional[int] = ..., max_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., min_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ...) -> None: ...
+    batch_retries: int
+    adaptive: AdaptiveStats
+    chunks: _containers.RepeatedCompositeFieldContainer[ChunkStats]
+    profile: Profile
+    drop_reasons: _containers.RepeatedCompositeFieldContainer[DropReason]
+    rejected_rows: _containers.RepeatedCompositeFieldContainer[RejectedRow]
+    def __init__(self, total_rows: _Optional[int] = ..., dropped_rows: _Optional[int] = ..., processed_rows: _Optional[int] = ..., inserted_rows: _Optional[int] = ..., max_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., min_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., batch_retries: _Optional[int] = ..., adaptive: _Optional[_Union[AdaptiveStats, _Mapping]] = ..., chunks: _Optional[_Iterable[_Union[ChunkStats, _Mapping]]] = ..., profile: _Optional[_Union[Profile, _Mapping]] = ..., drop_reasons: _Optional[_Iterable[_Union[DropReason, _Mapping]]] = ..., rejected_rows: _Optional[_Iterable[_Union[RejectedRow, _Mapping]]] = ...) -> None: ...
+
+class DropReason(_message.Message):
+    __slots__ = ("field", "reason", "count")
+    FIELD_FIELD_NUMBER: _ClassVar[int]
+    REASON_FIELD_NUMBER: _ClassVar[int]
+    COUNT_FIELD_NUMBER: _ClassVar[int]
+    field: str
+    reason: str
+    count: int
+    def __init__(self, field: _Optional[str] = ..., reason: _Optional[str] = ..., count: _Optional[int] = ...) -> None: ...
+
+class RejectedRow(_message.Message):
+    __slots__ = ("member", "line_number", "field", "reason", "raw_fields")
+    MEMBER_FIELD_NUMBER: _ClassVar[int]
+    LINE_NUMBER_FIELD_NUMBER: _ClassVar[int]
+    FIELD_FIELD_NUMBER: _ClassVar[int]
+    REASON_FIELD_NUMBER: _ClassVar[int]
+    RAW_FIELDS_FIELD_NUMBER: _ClassVar[int]
+    member: str
+    line_number: int
+    field: str
+    reason: str
+    raw_fields: _containers.RepeatedScalarFieldContainer[str]
+    def __init__(self, member: _Optional[str] = ..., line_number: _Optional[int] = ..., field: _Optional[str] = ..., reason: _Optional[str] = ..., raw_fields: _Optional[_Iterable[str]] = ...) -> None: ...
+
+class AdaptiveStats(_message.Message):
+    __slots__ = ("batch_size", "min_batch_size", "max_batch_size", "batch_adjustments", "scale_events", "stages")
+    BATCH_SIZE_FIELD_NUMBER: _ClassVar[int]
+    MIN_BATCH_SIZE_FIELD_NUMBER: _ClassVar[int]
+    MAX_BATCH_SIZE_FIELD_NUMBER: _ClassVar[int]
+    BATCH_ADJUSTMENTS_FIELD_NUMBER: _ClassVar[int]
+    SCALE_EVENTS_FIELD_NUMBER: _ClassVar[int]
+    STAGES_FIELD_NUMBER: _ClassVar[int]
+    batch_size: int
+    min_batch_size: int
+    max_batch_size: int
+    batch_adjustments: int
+    scale_events: int
+    stages: _containers.RepeatedCompositeFieldContainer[StageStats]
+    def __init__(self, batch_size: _Optional[int] = ..., min_batch_size: _Optional[int] = ..., max_batch_size: _Optional[int] = ..., batch_adjustments: _Optional[int] = ..., scale_events: _Optional[int] = ..., stages: _Optional[_Iterable[_Union[StageStats, _Mapping]]] = ...) -> None: ...
+
+class StageStats(_message.Message):
+    __slots__ = ("name", "final_workers", "peak_workers")
+    NAME_FIELD_NUMBER: _ClassVar[int]
+    FINAL_WORKERS_FIELD_NUMBER: _ClassVar[int]
+    PEAK_WORKERS_FIELD_NUMBER: _ClassVar[int]
+    name: str
+    final_workers: int
+    peak_workers: int
+    def __init__(self, name: _Optional[str] = ..., final_workers: _Optional[int] = ..., peak_workers: _Optional[int] = ...) -> None: ...
+
+class Profile(_message.Message):
+    __slots__ = ("rows", "columns")
+    ROWS_FIELD_NUMBER: _ClassVar[int]
+    COLUMNS_FIELD_NUMBER: _ClassVar[int]
+    rows: int
+    columns: _containers.RepeatedCompositeFieldContainer[ColumnProfile]
+    def __init__(self, rows: _Optional[int] = ..., columns: _Optional[_Iterable[_Union[ColumnProfile, _Mapping]]] = ...) -> None: ...
+
+class ColumnProfile(_message.Message):
+    __slots__ = ("name", "count", "nulls", "distinct_estimate", "min", "max", "mean", "min_time", "max_time", "histogram")
+    NAME_FIELD_NUMBER: _ClassVar[int]
+    COUNT_FIELD_NUMBER: _ClassVar[int]
+    NULLS_FIELD_NUMBER: _ClassVar[int]
+    DISTINCT_ESTIMATE_FIELD_NUMBER: _ClassVar[int]
+    MIN_FIELD_NUMBER: _ClassVar[int]
+    MAX_FIELD_NUMBER: _ClassVar[int]
+    MEAN_FIELD_NUMBER: _ClassVar[int]
+    MIN_TIME_FIELD_NUMBER: _ClassVar[int]
+    MAX_TIME_FIELD_NUMBER: _ClassVar[int]
+    HISTOGRAM_FIELD_NUMBER: _ClassVar[int]
+    name: str
+    count: int
+    nulls: int
+    distinct_estimate: int
+    min: float
+    max: float
+    mean: float
+    min_time: _timestamp_pb2.Timestamp
+    max_time: _timestamp_pb2.Timestamp
+    histogram: Histogram
+    def __init__(self, name: _Optional[str] = ..., count: _Optional[int] = ..., nulls: _Optional[int] = ..., distinct_estimate: _Optional[int] = ..., min: _Optional[float] = ..., max: _Optional[float] = ..., mean: _Optional[float] = ..., min_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., max_time: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., histogram: _Optional[_Union[Histogram, _Mapping]] = ...) -> None: ...
+
+class Histogram(_message.Message):
+    __slots__ = ("upper_bounds", "counts")
+    UPPER_BOUNDS_FIELD_NUMBER: _ClassVar[int]
+    COUNTS_FIELD_NUMBER: _ClassVar[int]
+    upper_bounds: _containers.RepeatedScalarFieldContainer[float]
+    counts: _containers.RepeatedScalarFieldContainer[int]
+    def __init__(self, upper_bounds: _Optional[_Iterable[float]] = ..., counts: _Optional[_Iterable[int]] = ...) -> None: ...
+
+class ChunkStats(_message.Message):
+    __slots__ = ("start", "end", "rows", "batches")
+    START_FIELD_NUMBER: _ClassVar[int]
+    END_FIELD_NUMBER: _ClassVar[int]
+    ROWS_FIELD_NUMBER: _ClassVar[int]
+    BATCHES_FIELD_NUMBER: _ClassVar[int]
+    start: _timestamp_pb2.Timestamp
+    end: _timestamp_pb2.Timestamp
+    rows: int
+    batches: int
+    def __init__(self, start: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., end: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., rows: _Optional[int] = ..., batches: _Optional[int] = ...) -> None: ...
+
+class Job(_message.Message):
+    __slots__ = ("id", "state", "dataset", "input_file", "remote_file_path", "options", "submitted_by", "attempts", "created_at", "started_at", "finished_at", "result", "error_code", "error", "cancel_requested", "progress")
+    ID_FIELD_NUMBER: _ClassVar[int]
+    STATE_FIELD_NUMBER: _ClassVar[int]
+    DATASET_FIELD_NUMBER: _ClassVar[int]
+    INPUT_FILE_FIELD_NUMBER: _ClassVar[int]
+    REMOTE_FILE_PATH_FIELD_NUMBER: _ClassVar[int]
+    OPTIONS_FIELD_NUMBER: _ClassVar[int]
+    SUBMITTED_BY_FIELD_NUMBER: _ClassVar[int]
+    ATTEMPTS_FIELD_NUMBER: _ClassVar[int]
+    CREATED_AT_FIELD_NUMBER: _ClassVar[int]
+    STARTED_AT_FIELD_NUMBER: _ClassVar[int]
+    FINISHED_AT_FIELD_NUMBER: _ClassVar[int]
+    RESULT_FIELD_NUMBER: _ClassVar[int]
+    ERROR_CODE_FIELD_NUMBER: _ClassVar[int]
+    ERROR_FIELD_NUMBER: _ClassVar[int]
+    CANCEL_REQUESTED_FIELD_NUMBER: _ClassVar[int]
+    PROGRESS_FIELD_NUMBER: _ClassVar[int]
+    id: str
+    state: JobState
+    dataset: str
+    input_file: str
+    remote_file_path: str
+    options: PipelineOptions
+    submitted_by: str
+    attempts: int
+    created_at: _timestamp_pb2.Timestamp
+    started_at: _timestamp_pb2.Timestamp
+    finished_at: _timestamp_pb2.Timestamp
+    result: ProcessFileResponse
+    error_code: str
+    error: str
+    cancel_requested: bool
+    progress: JobProgress
+    def __init__(self, id: _Optional[str] = ..., state: _Optional[_Union[JobState, str]] = ..., dataset: _Optional[str] = ..., input_file: _Optional[str] = ..., remote_file_path: _Optional[str] = ..., options: _Optional[_Union[PipelineOptions, _Mapping]] = ..., submitted_by: _Optional[str] = ..., attempts: _Optional[int] = ..., created_at: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., started_at: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., finished_at: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ..., result: _Optional[_Union[ProcessFileResponse, _Mapping]] = ..., error_code: _Optional[str] = ..., error: _Optional[str] = ..., cancel_requested: _Optional[bool] = ..., progress: _Optional[_Union[JobProgress, _Mapping]] = ...) -> None: ...
+
+class GetJobRequest(_message.Message):
+    __slots__ = ("id",)
+    ID_FIELD_NUMBER: _ClassVar[int]
+    id: str
+    def __init__(self, id: _Optional[str] = ...) -> None: ...
+
+class ListJobsRequest(_message.Message):
+    __slots__ = ("state", "page_size", "page_token")
+    STATE_FIELD_NUMBER: _ClassVar[int]
+    PAGE_SIZE_FIELD_NUMBER: _ClassVar[int]
+    PAGE_TOKEN_FIELD_NUMBER: _ClassVar[int]
+    state: JobState
+    page_size: int
+    page_token: str
+    def __init__(self, state: _Optional[_Union[JobState, str]] = ..., page_size: _Optional[int] = ..., page_token: _Optional[str] = ...) -> None: ...
+
+class ListJobsResponse(_message.Message):
+    __slots__ = ("jobs", "next_page_token")
+    JOBS_FIELD_NUMBER: _ClassVar[int]
+    NEXT_PAGE_TOKEN_FIELD_NUMBER: _ClassVar[int]
+    jobs: _containers.RepeatedCompositeFieldContainer[Job]
+    next_page_token: str
+    def __init__(self, jobs: _Optional[_Iterable[_Union[Job, _Mapping]]] = ..., next_page_token: _Optional[str] = ...) -> None: ...
+
+class CancelJobRequest(_message.Message):
+    __slots__ = ("id",)
+    ID_FIELD_NUMBER: _ClassVar[int]
+    id: str
+    def __init__(self, id: _Optional[str] = ...) -> None: ...
+
+class WatchJobRequest(_message.Message):
+    __slots__ = ("id", "interval_ms")
+    ID_FIELD_NUMBER: _ClassVar[int]
+    INTERVAL_MS_FIELD_NUMBER: _ClassVar[int]
+    id: str
+    interval_ms: int
+    def __init__(self, id: _Optional[str] = ..., interval_ms: _Optional[int] = ...) -> None: ...
+
+class JobProgress(_message.Message):
+    __slots__ = ("job_id", "state", "bytes_read", "total_bytes", "rows_parsed", "rows_dropped", "rows_inserted", "member", "elapsed_seconds", "remaining_seconds", "summary")
+    JOB_ID_FIELD_NUMBER: _ClassVar[int]
+    STATE_FIELD_NUMBER: _ClassVar[int]
+    BYTES_READ_FIELD_NUMBER: _ClassVar[int]
+    TOTAL_BYTES_FIELD_NUMBER: _ClassVar[int]
+    ROWS_PARSED_FIELD_NUMBER: _ClassVar[int]
+    ROWS_DROPPED_FIELD_NUMBER: _ClassVar[int]
+    ROWS_INSERTED_FIELD_NUMBER: _ClassVar[int]
+    MEMBER_FIELD_NUMBER: _ClassVar[int]
+    ELAPSED_SECONDS_FIELD_NUMBER: _ClassVar[int]
+    REMAINING_SECONDS_FIELD_NUMBER: _ClassVar[int]
+    SUMMARY_FIELD_NUMBER: _ClassVar[int]
+    job_id: str
+    state: JobState
+    bytes_read: int
+    total_bytes: int
+    rows_parsed: int
+    rows_dropped: int
+    rows_inserted: int
+    member: str
+    elapsed_seconds: float
+    remaining_seconds: float
+    summary: Job
+    def __init__(self, job_id: _Optional[str] = ..., state: _Optional[_Union[JobState, str]] = ..., bytes_read: _Optional[int] = ..., total_bytes: _Optional[int] = ..., rows_parsed: _Optional[int] = ..., rows_dropped: _Optional[int] = ..., rows_inserted: _Optional[int] = ..., member: _Optional[str] = ..., elapsed_seconds: _Optional[float] = ..., remaining_seconds: _Optional[float] = ..., summary: _Optional[_Union[Job, _Mapping]] = ...) -> None: ...
+
+class UploadRequest(_message.Message):
+    __slots__ = ("metadata", "chunk")
+    METADATA_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_FIELD_NUMBER: _ClassVar[int]
+    metadata: UploadMetadata
+    chunk: bytes
+    def __init__(self, metadata: _Optional[_Union[UploadMetadata, _Mapping]] = ..., chunk: _Optional[bytes] = ...) -> None: ...
+
+class UploadMetadata(_message.Message):
+    __slots__ = ("dataset", "file_name", "compression", "sha256", "size", "remote_file_path", "options")
+    DATASET_FIELD_NUMBER: _ClassVar[int]
+    FILE_NAME_FIELD_NUMBER: _ClassVar[int]
+    COMPRESSION_FIELD_NUMBER: _ClassVar[int]
+    SHA256_FIELD_NUMBER: _ClassVar[int]
+    SIZE_FIELD_NUMBER: _ClassVar[int]
+    REMOTE_FILE_PATH_FIELD_NUMBER: _ClassVar[int]
+    OPTIONS_FIELD_NUMBER: _ClassVar[int]
+    dataset: str
+    file_name: str
+    compression: Compression
+    sha256: str
+    size: int
+    remote_file_path: str
+    options: PipelineOptions
+    def __init__(self, dataset: _Optional[str] = ..., file_name: _Optional[str] = ..., compression: _Optional[_Union[Compression, str]] = ..., sha256: _Optional[str] = ..., size: _Optional[int] = ..., remote_file_path: _Optional[str] = ..., options: _Optional[_Union[PipelineOptions, _Mapping]] = ...) -> None: ...
+
+class GetSchedulerStatusRequest(_message.Message):
+    __slots__ = ()
+    def __init__(self) -> None: ...
+
+class SchedulerStatus(_message.Message):
+    __slots__ = ("max_running", "max_queued", "retry_after_seconds", "datasets", "running", "queued")
+    MAX_RUNNING_FIELD_NUMBER: _ClassVar[int]
+    MAX_QUEUED_FIELD_NUMBER: _ClassVar[int]
+    RETRY_AFTER_SECONDS_FIELD_NUMBER: _ClassVar[int]
+    DATASETS_FIELD_NUMBER: _ClassVar[int]
+    RUNNING_FIELD_NUMBER: _ClassVar[int]
+    QUEUED_FIELD_NUMBER: _ClassVar[int]
+    max_running: int
+    max_queued: int
+    retry_after_seconds: float
+    datasets: _containers.RepeatedCompositeFieldContainer[DatasetAdmission]
+    running: _containers.RepeatedCompositeFieldContainer[AdmissionTicket]
+    queued: _containers.RepeatedCompositeFieldContainer[AdmissionTicket]
+    def __init__(self, max_running: _Optional[int] = ..., max_queued: _Optional[int] = ..., retry_after_seconds: _Optional[float] = ..., datasets: _Optional[_Iterable[_Union[DatasetAdmission, _Mapping]]] = ..., running: _Optional[_Iterable[_Union[AdmissionTicket, _Mapping]]] = ..., queued: _Optional[_Iterable[_Union[AdmissionTicket, _Mapping]]] = ...) -> None: ...
+
+class DatasetAdmission(_message.Message):
+    __slots__ = ("dataset", "limit", "running", "queued")
+    DATASET_FIELD_NUMBER: _ClassVar[int]
+    LIMIT_FIELD_NUMBER: _ClassVar[int]
+    RUNNING_FIELD_NUMBER: _ClassVar[int]
+    QUEUED_FIELD_NUMBER: _ClassVar[int]
+    dataset: str
+    limit: int
+    running: int
+    queued: int
+    def __init__(self, dataset: _Optional[str] = ..., limit: _Optional[int] = ..., running: _Optional[int] = ..., queued: _Optional[int] = ...) -> None: ...
+
+class AdmissionTicket(_message.Message):
+    __slots__ = ("id", "dataset", "source", "caller", "since")
+    ID_FIELD_NUMBER: _ClassVar[int]
+    DATASET_FIELD_NUMBER: _ClassVar[int]
+    SOURCE_FIELD_NUMBER: _ClassVar[int]
+    CALLER_FIELD_NUMBER: _ClassVar[int]
+    SINCE_FIELD_NUMBER: _ClassVar[int]
+    id: str
+    dataset: str
+    source: str
+    caller: str
+    since: _timestamp_pb2.Timestamp
+    def __init__(self, id: _Optional[str] = ..., dataset: _Optional[str] = ..., source: _Optional[str] = ..., caller: _Optional[str] = ..., since: _Optional[_Union[datetime.datetime, _timestamp_pb2.Timestamp, _Mapping]] = ...) -> None: ...
 
 class InputFileTestRequest(_message.Message):
     __slots__ = ("location_id",)
@@ -38,6 +38,51 @@ class TransformServiceStub(object):
                 request_serializer=transform__pb2.InputFileRequest.SerializeToString,
                 response_deserializer=transform__pb2.ProcessFileResponse.FromString,
                 _registered_method=True)
+        self.ValidateFile = channel.unary_unary(
+                '/TransformService/ValidateFile',
+                request_serializer=transform__pb2.ValidateFileRequest.SerializeToString,
+                response_deserializer=transform__pb2.ProcessFileResponse.FromString,
+                _registered_method=True)
+        self.PreviewFile = channel.unary_unary(
+                '/TransformService/PreviewFile',
+                request_serializer=transform__pb2.PreviewFileRequest.SerializeToString,
+                response_deserializer=transform__pb2.PreviewFileResponse.FromString,
+                _registered_method=True)
+        self.SubmitJob = channel.unary_unary(
+                '/TransformService/SubmitJob',
+                request_serializer=transform__pb2.InputFileRequest.SerializeToString,
+                response_deserializer=transform__pb2.Job.FromString,
+                _registered_method=True)
+        self.GetJob = channel.unary_unary(
+                '/TransformService/GetJob',
+                request_serializer=transform__pb2.GetJobRequest.SerializeToString,
+                response_deserializer=transform__pb2.Job.FromString,
+                _registered_method=True)
+        self.ListJobs = channel.unary_unary(
+                '/TransformService/ListJobs',
+                request_serializer=transform__pb2.ListJobsRequest.SerializeToString,
+                response_deserializer=transform__pb2.ListJobsResponse.FromString,
+                _registered_method=True)
+        self.CancelJob = channel.unary_unary(
+                '/TransformService/CancelJob',
+                request_serializer=transform__pb2.CancelJobRequest.SerializeToString,
+                response_deserializer=transform__pb2.Job.FromString,
+                _registered_method=True)
+        self.WatchJob = channel.unary_stream(
+                '/TransformService/WatchJob',
+                request_serializer=transform__pb2.WatchJobRequest.SerializeToString,
+                response_deserializer=transform__pb2.JobProgress.FromString,
+                _registered_method=True)
+        self.UploadAndProcess = channel.stream_unary(
+                '/TransformService/UploadAndProcess',
+                request_serializer=transform__pb2.UploadRequest.SerializeToString,
+                response_deserializer=transform__pb2.ProcessFileResponse.FromString,
+                _registered_method=True)
+        self.GetSchedulerStatus = channel.unary_unary(
+                '/TransformService/GetSchedulerStatus',
+                request_serializer=transform__pb2.GetSchedulerStatusRequest.SerializeToString,
+                response_deserializer=transform__pb2.SchedulerStatus.FromString,
+                _registered_method=True)
         self.ProcessTesting = channel.unary_unary(
                 '/TransformService/ProcessTesting',
                 request_serializer=transform__pb2.InputFileTestRequest.SerializeToString,
@@ -54,6 +99,70 @@ class TransformServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def ValidateFile(self, request, context):
+        """Runs ProcessNYCTrip without writing to the database
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def PreviewFile(self, request, context):
+        """Parses the first rows of an input without writing or deleting it
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def SubmitJob(self, request, context):
+        """Queues a ProcessNYCTrip load and returns at once, the job survives processor restarts.
+        Submitting an input that is already queued or running returns the existing job.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def GetJob(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def ListJobs(self, request, context):
+        """Most recent jobs first
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def CancelJob(self, request, context):
+        """A queued job is cancelled at once, a running one stops its load and releases the input
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def WatchJob(self, request, context):
+        """Streams the progress of a job until it finishes, the last event carries the finished job
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def UploadAndProcess(self, request_iterator, context):
+        """Loads a file sent over the stream instead of staged in redis, the first message
+        carries the metadata and the next ones the file bytes in order
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def GetSchedulerStatus(self, request, context):
+        """Loads running on this processor and waiting for a slot, for operators
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
     def ProcessTesting(self, request, context):
         """For Testing Load Map
         """
@@ -69,6 +178,51 @@ def add_TransformServiceServicer_to_server(servicer, server):
                     request_deserializer=transform__pb2.InputFileRequest.FromString,
                     response_serializer=transform__pb2.ProcessFileResponse.SerializeToString,
             ),
+            'ValidateFile': grpc.unary_unary_rpc_method_handler(
+                    servicer.ValidateFile,
+                    request_deserializer=transform__pb2.ValidateFileRequest.FromString,
+                    response_serializer=transform__pb2.ProcessFileResponse.SerializeToString,
+            ),
+            'PreviewFile': grpc.unary_unary_rpc_method_handler(
+                    servicer.PreviewFile,
+                    request_deserializer=transform__pb2.PreviewFileRequest.FromString,
+                    response_serializer=transform__pb2.PreviewFileResponse.SerializeToString,
+            ),
+            'SubmitJob': grpc.unary_unary_rpc_method_handler(
+                    servicer.SubmitJob,
+                    request_deserializer=transform__pb2.InputFileRequest.FromString,
+                    response_serializer=transform__pb2.Job.SerializeToString,
+            ),
+            'GetJob': grpc.unary_unary_rpc_method_handler(
+                    servicer.GetJob,
+                    request_deserializer=transform__pb2.GetJobRequest.FromString,
+                    response_serializer=transform__pb2.Job.SerializeToString,
+            ),
+            'ListJobs': grpc.unary_unary_rpc_method_handler(
+                    servicer.ListJobs,
+                    request_deserializer=transform__pb2.ListJobsRequest.FromString,
+                    response_serializer=transform__pb2.ListJobsResponse.SerializeToString,
+            ),
+            'CancelJob': grpc.unary_unary_rpc_method_handler(
+                    servicer.CancelJob,
+                    request_deserializer=transform__pb2.CancelJobRequest.FromString,
+                    response_serializer=transform__pb2.Job.SerializeToString,
+            ),
+            'WatchJob': grpc.unary_stream_rpc_method_handler(
+                    servicer.WatchJob,
+                    request_deserializer=transform__pb2.WatchJobRequest.FromString,
+                    response_serializer=transform__pb2.JobProgress.SerializeToString,
+            ),
+            'UploadAndProcess': grpc.stream_unary_rpc_method_handler(
+                    servicer.UploadAndProcess,
+                    request_deserializer=transform__pb2.UploadRequest.FromString,
+                    response_serializer=transform__pb2.ProcessFileResponse.SerializeToString,
+            ),
+            'GetSchedulerStatus': grpc.unary_unary_rpc_method_handler(
+                    servicer.GetSchedulerStatus,
+                    request_deserializer=transform__pb2.GetSchedulerStatusRequest.FromString,
+                    response_serializer=transform__pb2.SchedulerStatus.SerializeToString,
+            ),
             'ProcessTesting': grpc.unary_unary_rpc_method_handler(
                     servicer.ProcessTesting,
                     request_deserializer=transform__pb2.InputFileTestRequest.FromString,
@@ -112,6 +266,249 @@ class TransformService(object):
             metadata,
             _registered_method=True)
 
+    @staticmethod
+    def ValidateFile(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/TransformService/ValidateFile',
+            transform__pb2.ValidateFileRequest.SerializeToString,
+            transform__pb2.ProcessFileResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def PreviewFile(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/TransformService/PreviewFile',
+            transform__pb2.PreviewFileRequest.SerializeToString,
+            transform__pb2.PreviewFileResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def SubmitJob(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/TransformService/SubmitJob',
+            transform__pb2.InputFileRequest.SerializeToString,
+            transform__pb2.Job.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def GetJob(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/TransformService/GetJob',
+            transform__pb2.GetJobRequest.SerializeToString,
+            transform__pb2.Job.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def ListJobs(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/TransformService/ListJobs',
+            transform__pb2.ListJobsRequest.SerializeToString,
+            transform__pb2.ListJobsResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def CancelJob(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/TransformService/CancelJob',
+            transform__pb2.CancelJobRequest.SerializeToString,
+            transform__pb2.Job.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def WatchJob(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_stream(
+            request,
+            target,
+            '/TransformService/WatchJob',
+            transform__pb2.WatchJobRequest.SerializeToString,
+            transform__pb2.JobProgress.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def UploadAndProcess(request_iterator,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.stream_unary(
+            request_iterator,
+            target,
+            '/TransformService/UploadAndProcess',
+            transform__pb2.UploadRequest.SerializeToString,
+            transform__pb2.ProcessFileResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def GetSchedulerStatus(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/TransformService/GetSchedulerStatus',
+            transform__pb2.GetSchedulerStatusRequest.SerializeToString,
+            transform__pb2.SchedulerStatus.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
     @staticmethod
     def ProcessTesting(request,
             target,
@@ -48,6 +48,13 @@ class GrpcSettings(BaseSettings):
     GRPC_HOST: str = config("GRPC_HOST", default="localhost")
     GRPC_PORT: int = config("GRPC_PORT", default=3000)
     GRPC_URL: str = f"{GRPC_HOST}:{GRPC_PORT}"
+    GRPC_TLS_CA_FILE: str = config("GRPC_TLS_CA_FILE", default="")
+    GRPC_TLS_CERT_FILE: str = config("GRPC_TLS_CERT_FILE", default="")
+    GRPC_TLS_KEY_FILE: str = config("GRPC_TLS_KEY_FILE", default="")
+    GRPC_AUTH_TOKEN: str = config("GRPC_AUTH_TOKEN", default="")
+    GRPC_JOB_PROGRESS_INTERVAL: float = config(
+        "GRPC_JOB_PROGRESS_INTERVAL", default=5.0
+    )
     GRPC_SERVICE_CONFIG: str = json.dumps(
         {
             "methodConfig": [
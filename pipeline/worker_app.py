@@ -1,20 +1,46 @@
 import datetime
 import logging
+import time
 
 import grpc
 import pysftp
 from celery import Celery
 from celery import Task
 from celery.signals import after_setup_logger
+from opentelemetry import propagate
 
 from pipeline.etl.state import ETLState
 from pipeline.protos.transform_pb2 import InputFileRequest
+from pipeline.protos.transform_pb2 import JobProgress
+from pipeline.protos.transform_pb2 import JobState
+from pipeline.protos.transform_pb2 import WatchJobRequest
 from pipeline.protos.transform_pb2_grpc import TransformServiceStub
 from pipeline.settings import settings
 
 logger = logging.getLogger(__name__)
 
 
+class ProcessorJobError(Exception):
+    pass
+
+
+def describe_progress(event: JobProgress) -> str:
+    state = JobState.Name(event.state).removeprefix("JOB_STATE_").lower()
+    if event.state == JobState.JOB_STATE_QUEUED:
+        return state
+    done = event.bytes_read / event.total_bytes if event.total_bytes else 0
+    remaining = (
+        f"{event.remaining_seconds:.0f}s left"
+        if event.remaining_seconds >= 0
+        else "estimating"
+    )
+    return (
+        f"{state} {event.member} {done:.0%} of {event.total_bytes} bytes, "
+        f"{event.rows_parsed} parsed, {event.rows_dropped} dropped, "
+        f"{event.rows_inserted} inserted, {remaining}"
+    )
+
+
 class TaskBase(Task):
     @staticmethod
     def connect_sftp(host_info: dict) -> pysftp.Connection:
@@ -33,21 +59,83 @@ class TaskBase(Task):
             raise ConnectionError(f"Failed to establish SFTP connection: {str(e)}")
 
     @staticmethod
-    def connect_grpc(etl_state: ETLState):
+    def grpc_channel(options):
+        if not settings.GRPC_TLS_CA_FILE:
+            return grpc.insecure_channel(settings.GRPC_URL, options=options)
+
+        def read(path):
+            if not path:
+                return None
+            with open(path, "rb") as f:
+                return f.read()
+
+        credentials = grpc.ssl_channel_credentials(
+            root_certificates=read(settings.GRPC_TLS_CA_FILE),
+            private_key=read(settings.GRPC_TLS_KEY_FILE),
+            certificate_chain=read(settings.GRPC_TLS_CERT_FILE),
+        )
+        return grpc.secure_channel(settings.GRPC_URL, credentials, options=options)
+
+    @staticmethod
+    def grpc_metadata(job_id=None):
+        metadata = []
+        if settings.GRPC_AUTH_TOKEN:
+            metadata.append(("authorization", f"Bearer {settings.GRPC_AUTH_TOKEN}"))
+        if job_id:
+            metadata.append(("x-job-id", job_id))
+        # continues the trace of the task in the processor when tracing is configured
+        carrier: dict[str, str] = {}
+        propagate.inject(carrier)
+        metadata.extend(carrier.items())
+        return tuple(metadata) or None
+
+    @staticmethod
+    def wait_for_job(stub: TransformServiceStub, job_id: str, metadata):
+        """Logs the progress of a job and returns it once finished, the watch is
+        resumed when the processor restarts"""
+        request = WatchJobRequest(
+            id=job_id, interval_ms=int(settings.GRPC_JOB_PROGRESS_INTERVAL * 1000)
+        )
+        while True:
+            try:
+                for event in stub.WatchJob(request, metadata=metadata):
+                    if event.HasField("summary"):
+                        return event.summary
+                    logger.info(f"Job {job_id}: {describe_progress(event)}")
+            except grpc.RpcError as rpc_error:
+                code = rpc_error.code()  # type: ignore
+                if code != grpc.StatusCode.UNAVAILABLE:
+                    raise
+                logger.warning(f"Processor unavailable, watching {job_id} again")
+                time.sleep(settings.GRPC_JOB_PROGRESS_INTERVAL)
+
+    @staticmethod
+    def connect_grpc(etl_state: ETLState, job_id: str | None = None):
+        """Submits the load as a processor job and follows it until it finishes,
+        the job keeps running when the connection drops"""
         options = []
         options.append(("grpc.service_config", settings.GRPC_SERVICE_CONFIG))
+        metadata = TaskBase.grpc_metadata(job_id)
         try:
-            with grpc.insecure_channel(settings.GRPC_URL, options=options) as channel:
+            with TaskBase.grpc_channel(options) as channel:
                 stub = TransformServiceStub(channel)
-                response = stub.ProcessNYCTrip(
+                job = stub.SubmitJob(
                     InputFileRequest(
                         input_file=etl_state.local_file_path,
                         remote_file_path=etl_state.remote_file_path,
                     ),
+                    metadata=metadata,
                 )
-            return response
+                logger.info(f"Submitted processor job {job.id}")
+                job = TaskBase.wait_for_job(stub, job.id, metadata)
         except grpc.RpcError as rpc_error:
             raise grpc.RpcError(str(rpc_error))
+        if job.state != JobState.JOB_STATE_SUCCEEDED:
+            state = JobState.Name(job.state)
+            raise ProcessorJobError(
+                f"Processor job {job.id} {state}: {job.error_code} {job.error}"
+            )
+        return job.result
 
 
 class CeleryConfig:
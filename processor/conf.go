@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap/zapcore"
@@ -48,9 +49,108 @@ func (e *Environment) UnmarshalText(text []byte) error {
 }
 
 type config struct {
-	DBUrl       string        `env:"DB_URL,notEmpty"`
-	RedisHost   string        `env:"REDIS_PM_HOST" envDefault:"redis"`
-	RedisDB     string        `env:"REDIS_PM_DB" envDefault:"6"`
-	LogLevel    zapcore.Level `env:"LOG_LEVEL" envDefault:"INFO"`
-	Environment Environment   `env:"ENVIRONMENT" envDefault:"development"`
+	DBUrl                 string        `env:"DB_URL,notEmpty"`
+	DBRetryMaxAttempts    int           `env:"DB_RETRY_MAX_ATTEMPTS" envDefault:"5"`
+	DBRetryInitialBackoff time.Duration `env:"DB_RETRY_INITIAL_BACKOFF" envDefault:"200ms"`
+	DBRetryMaxBackoff     time.Duration `env:"DB_RETRY_MAX_BACKOFF" envDefault:"10s"`
+	DBRetryMultiplier     float64       `env:"DB_RETRY_MULTIPLIER" envDefault:"2"`
+	DBMaxConns            int32         `env:"DB_MAX_CONNS" envDefault:"10"`
+	DBReservedConns       int32         `env:"DB_RESERVED_CONNS" envDefault:"2"`
+	DBMinConns            int32         `env:"DB_MIN_CONNS" envDefault:"0"`
+	DBMaxConnLifetime     time.Duration `env:"DB_MAX_CONN_LIFETIME" envDefault:"1h"`
+	DBMaxConnIdleTime     time.Duration `env:"DB_MAX_CONN_IDLE_TIME" envDefault:"30m"`
+	ReaderWorkers         int           `env:"READER_WORKERS"`
+	ReaderWorkersMax      int           `env:"READER_WORKERS_MAX" envDefault:"64"`
+	ChunkSize             int64         `env:"CHUNK_SIZE" envDefault:"8388608"`
+	ChunkSizeMax          int64         `env:"CHUNK_SIZE_MAX" envDefault:"67108864"`
+	ParserWorkers         int           `env:"PARSER_WORKERS" envDefault:"3"`
+	InserterWorkers       int           `env:"INSERTER_WORKERS" envDefault:"3"`
+	BatchSize             int           `env:"BATCH_SIZE" envDefault:"1000"`
+	ChannelSize           int           `env:"CHANNEL_SIZE" envDefault:"100"`
+	ChannelSizeMax        int           `env:"CHANNEL_SIZE_MAX" envDefault:"10000"`
+	AdaptiveEnabled       bool          `env:"ADAPTIVE_ENABLED" envDefault:"false"`
+	BatchSizeMin          int           `env:"BATCH_SIZE_MIN" envDefault:"100"`
+	BatchSizeMax          int           `env:"BATCH_SIZE_MAX" envDefault:"10000"`
+	BatchTargetLatency    time.Duration `env:"BATCH_TARGET_LATENCY" envDefault:"500ms"`
+	ParserWorkersMax      int           `env:"PARSER_WORKERS_MAX" envDefault:"8"`
+	InserterWorkersMax    int           `env:"INSERTER_WORKERS_MAX" envDefault:"8"`
+	AutoscaleInterval     time.Duration `env:"AUTOSCALE_INTERVAL" envDefault:"1s"`
+	ProfilePersist        bool          `env:"PROFILE_PERSIST" envDefault:"false"`
+	InlineMaxBytes        int           `env:"INLINE_MAX_BYTES" envDefault:"4194304"`
+	InputLeaseTTL         time.Duration `env:"INPUT_LEASE_TTL" envDefault:"60s"`
+	InputLeaseExtend      time.Duration `env:"INPUT_LEASE_EXTEND_INTERVAL" envDefault:"20s"`
+	InputMaxAttempts      int           `env:"INPUT_MAX_ATTEMPTS" envDefault:"3"`
+	UploadMaxBytes        int64         `env:"UPLOAD_MAX_BYTES" envDefault:"268435456"`
+	UploadMaxConcurrent   int           `env:"UPLOAD_MAX_CONCURRENT" envDefault:"2"`
+	UploadSpoolDir        string        `env:"UPLOAD_SPOOL_DIR"`
+	AdmissionMaxRunning   int           `env:"ADMISSION_MAX_RUNNING" envDefault:"4"`
+	AdmissionLimits       []string      `env:"ADMISSION_DATASET_LIMITS" envDefault:"nyc_trip=3"`
+	AdmissionMaxQueued    int           `env:"ADMISSION_MAX_QUEUED" envDefault:"16"`
+	AdmissionMaxWait      time.Duration `env:"ADMISSION_MAX_WAIT" envDefault:"30s"`
+	AdmissionRetryAfter   time.Duration `env:"ADMISSION_RETRY_AFTER" envDefault:"5s"`
+	JobWorkers            int           `env:"JOB_WORKERS" envDefault:"2"`
+	JobPollInterval       time.Duration `env:"JOB_POLL_INTERVAL" envDefault:"5s"`
+	JobHeartbeatInterval  time.Duration `env:"JOB_HEARTBEAT_INTERVAL" envDefault:"10s"`
+	JobStaleAfter         time.Duration `env:"JOB_STALE_AFTER" envDefault:"1m"`
+	JobMaxAttempts        int           `env:"JOB_MAX_ATTEMPTS" envDefault:"3"`
+	RedisURL              string        `env:"REDIS_URL"`
+	RedisHost             string        `env:"REDIS_PM_HOST" envDefault:"redis"`
+	RedisDB               int           `env:"REDIS_PM_DB" envDefault:"6"`
+	RedisUsername         string        `env:"REDIS_USERNAME"`
+	RedisPassword         string        `env:"REDIS_PASSWORD"`
+	RedisSentinelMaster   string        `env:"REDIS_SENTINEL_MASTER"`
+	RedisSentinelAddrs    []string      `env:"REDIS_SENTINEL_ADDRS"`
+	RedisSentinelUsername string        `env:"REDIS_SENTINEL_USERNAME"`
+	RedisSentinelPassword string        `env:"REDIS_SENTINEL_PASSWORD"`
+	RedisClusterAddrs     []string      `env:"REDIS_CLUSTER_ADDRS"`
+	RedisTLS              bool          `env:"REDIS_TLS" envDefault:"false"`
+	RedisTLSCAFile        string        `env:"REDIS_TLS_CA_FILE"`
+	RedisTLSCertFile      string        `env:"REDIS_TLS_CERT_FILE"`
+	RedisTLSKeyFile       string        `env:"REDIS_TLS_KEY_FILE"`
+	RedisTLSServerName    string        `env:"REDIS_TLS_SERVER_NAME"`
+	RedisTLSSkipVerify    bool          `env:"REDIS_TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+	RedisPoolSize         int           `env:"REDIS_POOL_SIZE" envDefault:"10"`
+	RedisMinIdleConns     int           `env:"REDIS_MIN_IDLE_CONNS" envDefault:"0"`
+	RedisDialTimeout      time.Duration `env:"REDIS_DIAL_TIMEOUT" envDefault:"5s"`
+	RedisReadTimeout      time.Duration `env:"REDIS_READ_TIMEOUT" envDefault:"3s"`
+	RedisWriteTimeout     time.Duration `env:"REDIS_WRITE_TIMEOUT" envDefault:"3s"`
+	RedisPoolTimeout      time.Duration `env:"REDIS_POOL_TIMEOUT" envDefault:"4s"`
+	GrpcListenAddress     string        `env:"GRPC_LISTEN_ADDRESS" envDefault:"0.0.0.0:3000"`
+	GrpcTLSCertFile       string        `env:"GRPC_TLS_CERT_FILE"`
+	GrpcTLSKeyFile        string        `env:"GRPC_TLS_KEY_FILE"`
+	GrpcTLSClientCAFile   string        `env:"GRPC_TLS_CLIENT_CA_FILE"`
+	GrpcTLSReloadInterval time.Duration `env:"GRPC_TLS_RELOAD_INTERVAL" envDefault:"30s"`
+	AuthEnabled           bool          `env:"AUTH_ENABLED" envDefault:"false"`
+	AuthRoles             []string      `env:"AUTH_ROLES" envDefault:"admin=*,loader=ProcessNYCTrip|ValidateFile|PreviewFile|ProcessTesting|SubmitJob|GetJob|ListJobs|CancelJob|WatchJob|UploadAndProcess,readonly=ProcessTesting|GetJob|ListJobs|WatchJob"`
+	AuthAPIKeys           []string      `env:"AUTH_API_KEYS"`
+	AuthJWTSecret         string        `env:"AUTH_JWT_SECRET"`
+	AuthJWTIssuer         string        `env:"AUTH_JWT_ISSUER"`
+	AuthJWTAudience       string        `env:"AUTH_JWT_AUDIENCE"`
+	MetricsListenAddress  string        `env:"METRICS_LISTEN_ADDRESS" envDefault:":9090"`
+	HealthCheckInterval   time.Duration `env:"HEALTH_CHECK_INTERVAL" envDefault:"5s"`
+	HealthCheckTimeout    time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	GrpcReflection        bool          `env:"GRPC_REFLECTION" envDefault:"false"`
+	ShutdownGracePeriod   time.Duration `env:"SHUTDOWN_GRACE_PERIOD" envDefault:"30s"`
+	TracingExporter       string        `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingServiceName    string        `env:"TRACING_SERVICE_NAME" envDefault:"processor"`
+	TracingOTLPEndpoint   string        `env:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure   bool          `env:"TRACING_OTLP_INSECURE" envDefault:"false"`
+	TracingFile           string        `env:"TRACING_FILE" envDefault:"traces.jsonl"`
+	TracingSampleRatio    float64       `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	LogLevel              zapcore.Level `env:"LOG_LEVEL" envDefault:"INFO"`
+	Environment           Environment   `env:"ENVIRONMENT" envDefault:"development"`
+}
+
+// validate rejects settings env can parse but the server can't run with
+func (c config) validate() error {
+	if c.HealthCheckInterval <= 0 {
+		return errors.New("health check interval must be positive")
+	}
+	if c.HealthCheckTimeout <= 0 {
+		return errors.New("health check timeout must be positive")
+	}
+	if c.GrpcTLSReloadInterval <= 0 {
+		return errors.New("grpc tls reload interval must be positive")
+	}
+	return nil
 }
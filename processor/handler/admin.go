@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+
+	"processor/lib"
+	pb "processor/protos"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *InputFileHandler) GetSchedulerStatus(ctx context.Context, req *pb.GetSchedulerStatusRequest) (*pb.SchedulerStatus, error) {
+	snap := h.Admission.Snapshot()
+	res := &pb.SchedulerStatus{
+		MaxRunning:        int32(snap.MaxRunning),
+		MaxQueued:         int32(snap.MaxQueued),
+		RetryAfterSeconds: snap.RetryAfter.Seconds(),
+		Running:           ticketsProto(snap.Running),
+		Queued:            ticketsProto(snap.Queued),
+	}
+	for _, d := range snap.Datasets {
+		res.Datasets = append(res.Datasets, &pb.DatasetAdmission{
+			Dataset: d.Dataset,
+			Limit:   int32(d.Limit),
+			Running: int32(d.Running),
+			Queued:  int32(d.Queued),
+		})
+	}
+	return res, nil
+}
+
+func ticketsProto(tickets []lib.Ticket) []*pb.AdmissionTicket {
+	res := make([]*pb.AdmissionTicket, 0, len(tickets))
+	for _, t := range tickets {
+		res = append(res, &pb.AdmissionTicket{
+			Id:      t.ID,
+			Dataset: t.Dataset,
+			Source:  t.Source,
+			Caller:  t.Caller,
+			Since:   timestamppb.New(t.Since),
+		})
+	}
+	return res
+}
@@ -2,21 +2,47 @@ package handler
 
 import (
 	"context"
+	"strconv"
 
 	"processor/handler/nyc_trip"
 	"processor/lib"
 	"processor/logger"
 	pb "processor/protos"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
+// MethodDatasets is the dataset every TransformService method touches, callers need access to it
+var MethodDatasets = map[string]string{
+	pb.TransformService_ProcessNYCTrip_FullMethodName:   nyc_trip.TableSchema.Name,
+	pb.TransformService_ValidateFile_FullMethodName:     nyc_trip.TableSchema.Name,
+	pb.TransformService_PreviewFile_FullMethodName:      nyc_trip.TableSchema.Name,
+	pb.TransformService_SubmitJob_FullMethodName:        nyc_trip.TableSchema.Name,
+	pb.TransformService_GetJob_FullMethodName:           nyc_trip.TableSchema.Name,
+	pb.TransformService_ListJobs_FullMethodName:         nyc_trip.TableSchema.Name,
+	pb.TransformService_CancelJob_FullMethodName:        nyc_trip.TableSchema.Name,
+	pb.TransformService_WatchJob_FullMethodName:         nyc_trip.TableSchema.Name,
+	pb.TransformService_UploadAndProcess_FullMethodName: nyc_trip.TableSchema.Name,
+	pb.TransformService_ProcessTesting_FullMethodName:   "taxi_zone_lookup",
+}
+
+// AdmittedMethods are the methods reading a whole input into memory, a load or a preview,
+// they wait for a slot of the scheduler
+var AdmittedMethods = map[string]string{
+	pb.TransformService_ProcessNYCTrip_FullMethodName:   nyc_trip.TableSchema.Name,
+	pb.TransformService_ValidateFile_FullMethodName:     nyc_trip.TableSchema.Name,
+	pb.TransformService_PreviewFile_FullMethodName:      nyc_trip.TableSchema.Name,
+	pb.TransformService_UploadAndProcess_FullMethodName: nyc_trip.TableSchema.Name,
+}
+
 type InputFileHandler struct {
 	pb.UnimplementedTransformServiceServer
 	InputFileNYCTrip *nyc_trip.InputFile
 	InputFileTesting *InputFileTesting
+	Jobs             *lib.JobPool
+	Admission        *lib.Scheduler
 }
 
 type InputFileTesting struct {
@@ -33,23 +59,41 @@ func (h *InputFileHandler) ProcessNYCTrip(ctx context.Context, req *pb.InputFile
 	return h.InputFileNYCTrip.ProcessNYCTrip(ctx, req)
 }
 
+func (h *InputFileHandler) ValidateFile(ctx context.Context, req *pb.ValidateFileRequest) (*pb.ProcessFileResponse, error) {
+	return h.InputFileNYCTrip.ValidateFile(ctx, req)
+}
+
+func (h *InputFileHandler) PreviewFile(ctx context.Context, req *pb.PreviewFileRequest) (*pb.PreviewFileResponse, error) {
+	return h.InputFileNYCTrip.PreviewFile(ctx, req)
+}
+
+func (h *InputFileHandler) UploadAndProcess(stream pb.TransformService_UploadAndProcessServer) error {
+	return h.InputFileNYCTrip.UploadAndProcess(stream)
+}
+
 func (h *InputFileHandler) ProcessTesting(ctx context.Context, req *pb.InputFileTestRequest) (*pb.ProcessFileTestResponse, error) {
 	locationID := req.GetLocationId()
 	in := h.InputFileTesting
-	logger.Info(
+	logger.FromContext(ctx).Info(
 		"received testing request", zap.Int64("LocationID", locationID),
 	)
 	mapTaxiZone, err := in.MapId.GetTaxiZoneMap()
 	if err != nil {
-		logger.Error("failed getting mapTaxiZone", zap.Error(err))
-		return nil, status.New(codes.Internal, err.Error()).Err()
+		logger.FromContext(ctx).Error("failed getting mapTaxiZone", zap.Error(err))
+		if errors.Is(err, lib.ErrMapNotReady) {
+			return nil, lib.NewRPCError(codes.Unavailable, pb.ErrorReason_ERROR_REASON_MAP_NOT_READY, err).RetryAfter(lib.DefaultRetryDelay).Err()
+		}
+		return nil, lib.NewRPCError(codes.Internal, pb.ErrorReason_ERROR_REASON_INTERNAL, err).Err()
 	}
 	loc, found := mapTaxiZone.Get(locationID)
 	if !found {
-		return nil, status.Errorf(codes.Internal, "failed getting map value from locationID %v", locationID)
+		err := errors.Errorf("location %d isn't in taxi_zone_lookup", locationID)
+		return nil, lib.NewRPCError(codes.NotFound, pb.ErrorReason_ERROR_REASON_LOCATION_NOT_FOUND, err).
+			With("location_id", strconv.FormatInt(locationID, 10)).
+			Err()
 	}
 
-	logger.Info("testing finished")
+	logger.FromContext(ctx).Info("testing finished")
 	return &pb.ProcessFileTestResponse{
 		Borough:     *loc.Borough,
 		Zone:        *loc.Zone,
@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"processor/handler/nyc_trip"
+	"processor/lib"
+	"processor/logger"
+	pb "processor/protos"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultJobPageSize = 50
+	maxJobPageSize     = 500
+
+	defaultWatchInterval = time.Second
+	minWatchInterval     = 100 * time.Millisecond
+	maxWatchInterval     = time.Minute
+)
+
+var jobStates = map[lib.JobState]pb.JobState{
+	lib.JobQueued:    pb.JobState_JOB_STATE_QUEUED,
+	lib.JobRunning:   pb.JobState_JOB_STATE_RUNNING,
+	lib.JobSucceeded: pb.JobState_JOB_STATE_SUCCEEDED,
+	lib.JobFailed:    pb.JobState_JOB_STATE_FAILED,
+	lib.JobCancelled: pb.JobState_JOB_STATE_CANCELLED,
+}
+
+// jobStatus maps a job store failure to a grpc status
+func jobStatus(err error) error {
+	switch {
+	case errors.Is(err, lib.ErrJobNotFound):
+		return lib.NewRPCError(codes.NotFound, pb.ErrorReason_ERROR_REASON_JOB_NOT_FOUND, err).Err()
+	case errors.Is(err, lib.ErrJobFinished):
+		return lib.NewRPCError(codes.FailedPrecondition, pb.ErrorReason_ERROR_REASON_JOB_FINISHED, err).Err()
+	case errors.Is(err, lib.ErrInvalidPageToken):
+		return lib.InvalidField("page_token", err)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case lib.IsRetryable(err):
+		return lib.NewRPCError(codes.Unavailable, pb.ErrorReason_ERROR_REASON_DATABASE_UNAVAILABLE, err).RetryAfter(lib.DefaultRetryDelay).Err()
+	}
+	return lib.NewRPCError(codes.Internal, pb.ErrorReason_ERROR_REASON_INTERNAL, err).Err()
+}
+
+func (h *InputFileHandler) SubmitJob(ctx context.Context, req *pb.InputFileRequest) (*pb.Job, error) {
+	if req.GetInputFile() == "" {
+		return nil, lib.InvalidField("input_file", errors.New("input file is required"))
+	}
+	if err := h.InputFileNYCTrip.ValidateOptions(req.GetOptions()); err != nil {
+		return nil, lib.NewRPCError(codes.InvalidArgument, pb.ErrorReason_ERROR_REASON_INVALID_OPTIONS, err).Field("options", err.Error()).Err()
+	}
+	var options []byte
+	if req.GetOptions() != nil {
+		var err error
+		options, err = protojson.Marshal(req.GetOptions())
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	job, err := h.Jobs.Submit(ctx, &lib.Job{
+		Dataset:        nyc_trip.TableSchema.Name,
+		InputFile:      req.GetInputFile(),
+		RemoteFilePath: req.GetRemoteFilePath(),
+		Options:        options,
+		SubmittedBy:    lib.IdentityFrom(ctx).Subject,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("failed submitting job", zap.Error(err))
+		return nil, jobStatus(err)
+	}
+	logger.FromContext(ctx).Info("submitted job", zap.String("jobId", job.ID), zap.String("state", string(job.State)))
+	return jobProto(job)
+}
+
+// getJob returns a job of the caller, the jobs of other callers don't exist for it
+func (h *InputFileHandler) getJob(ctx context.Context, id string) (*lib.Job, error) {
+	job, err := h.Jobs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !lib.IdentityFrom(ctx).Owns(job.SubmittedBy) {
+		return nil, errors.Wrap(lib.ErrJobNotFound, id)
+	}
+	return job, nil
+}
+
+func (h *InputFileHandler) GetJob(ctx context.Context, req *pb.GetJobRequest) (*pb.Job, error) {
+	job, err := h.getJob(ctx, req.GetId())
+	if err != nil {
+		return nil, jobStatus(err)
+	}
+	return jobProto(job)
+}
+
+func (h *InputFileHandler) ListJobs(ctx context.Context, req *pb.ListJobsRequest) (*pb.ListJobsResponse, error) {
+	pageSize := int(req.GetPageSize())
+	if pageSize < 0 || pageSize > maxJobPageSize {
+		return nil, lib.InvalidField("page_size", errors.Errorf("page size must be between 0 and %d, got %d", maxJobPageSize, pageSize))
+	}
+	if pageSize == 0 {
+		pageSize = defaultJobPageSize
+	}
+	var state lib.JobState
+	if req.GetState() != pb.JobState_JOB_STATE_UNSPECIFIED {
+		for s, value := range jobStates {
+			if value == req.GetState() {
+				state = s
+			}
+		}
+		if state == "" {
+			return nil, lib.InvalidField("state", errors.Errorf("unknown job state %v", req.GetState()))
+		}
+	}
+	// admins and callers without auth list every job
+	var submittedBy string
+	if caller := lib.IdentityFrom(ctx); !caller.Owns("") {
+		submittedBy = caller.Subject
+	}
+	jobs, next, err := h.Jobs.List(ctx, state, submittedBy, pageSize, req.GetPageToken())
+	if err != nil {
+		return nil, jobStatus(err)
+	}
+	res := &pb.ListJobsResponse{NextPageToken: next}
+	for _, job := range jobs {
+		j, err := jobProto(job)
+		if err != nil {
+			return nil, err
+		}
+		res.Jobs = append(res.Jobs, j)
+	}
+	return res, nil
+}
+
+func (h *InputFileHandler) CancelJob(ctx context.Context, req *pb.CancelJobRequest) (*pb.Job, error) {
+	if _, err := h.getJob(ctx, req.GetId()); err != nil {
+		return nil, jobStatus(err)
+	}
+	job, err := h.Jobs.Cancel(ctx, req.GetId())
+	if err != nil {
+		return nil, jobStatus(err)
+	}
+	logger.FromContext(ctx).Info("cancelled job", zap.String("jobId", job.ID), zap.String("state", string(job.State)))
+	return jobProto(job)
+}
+
+// WatchJob sends the progress of the job every interval, the live progress when the job runs
+// on this processor and the last saved one otherwise. The stream ends with the finished job.
+func (h *InputFileHandler) WatchJob(req *pb.WatchJobRequest, stream pb.TransformService_WatchJobServer) error {
+	ctx := stream.Context()
+	interval := time.Duration(req.GetIntervalMs()) * time.Millisecond
+	if interval == 0 {
+		interval = defaultWatchInterval
+	}
+	if interval < minWatchInterval || interval > maxWatchInterval {
+		return lib.InvalidField("interval_ms", errors.Errorf("interval must be between %v and %v, got %v", minWatchInterval, maxWatchInterval, interval))
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := h.getJob(ctx, req.GetId())
+		if err != nil {
+			return jobStatus(err)
+		}
+		snapshot, live := h.Jobs.Progress(job.ID)
+		if !live {
+			if snapshot, err = storedProgress(job); err != nil {
+				return err
+			}
+		}
+		event := jobProgressProto(job, snapshot)
+		if job.State != lib.JobQueued && job.State != lib.JobRunning {
+			if event.Summary, err = jobProto(job); err != nil {
+				return err
+			}
+			return stream.Send(event)
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-h.Jobs.Stopping():
+			err := errors.New("processor is shutting down, watch the job again")
+			return lib.NewRPCError(codes.Unavailable, pb.ErrorReason_ERROR_REASON_SHUTTING_DOWN, err).RetryAfter(time.Second).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunJob loads a claimed job like ProcessNYCTrip and returns the json of its response
+func (h *InputFileHandler) RunJob(ctx context.Context, job *lib.Job) ([]byte, error) {
+	req := &pb.InputFileRequest{
+		InputFile:      job.InputFile,
+		RemoteFilePath: job.RemoteFilePath,
+	}
+	if len(job.Options) > 0 {
+		req.Options = &pb.PipelineOptions{}
+		if err := protojson.Unmarshal(job.Options, req.Options); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed decoding job options: %v", err)
+		}
+	}
+	// jobs are capped by the job workers already, they wait for a slot instead of failing
+	release, err := h.Admission.Wait(ctx, lib.Ticket{
+		ID:      job.ID,
+		Dataset: job.Dataset,
+		Source:  lib.JobSource,
+		Caller:  job.SubmittedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	res, err := h.InputFileNYCTrip.ProcessNYCTrip(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(res)
+}
+
+var unmarshalStored = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// storedProgress decodes the progress saved by the processor running the job
+func storedProgress(job *lib.Job) (lib.ProgressSnapshot, error) {
+	snapshot := lib.ProgressSnapshot{RemainingSeconds: -1}
+	if len(job.Progress) == 0 {
+		return snapshot, nil
+	}
+	if err := json.Unmarshal(job.Progress, &snapshot); err != nil {
+		return snapshot, status.Errorf(codes.Internal, "failed decoding progress of job %s: %v", job.ID, err)
+	}
+	return snapshot, nil
+}
+
+func jobProgressProto(job *lib.Job, s lib.ProgressSnapshot) *pb.JobProgress {
+	return &pb.JobProgress{
+		JobId:            job.ID,
+		State:            jobStates[job.State],
+		BytesRead:        s.BytesRead,
+		TotalBytes:       s.TotalBytes,
+		RowsParsed:       s.RowsParsed,
+		RowsDropped:      s.RowsDropped,
+		RowsInserted:     s.RowsInserted,
+		Member:           s.Member,
+		ElapsedSeconds:   s.ElapsedSeconds,
+		RemainingSeconds: s.RemainingSeconds,
+	}
+}
+
+func jobProto(job *lib.Job) (*pb.Job, error) {
+	res := &pb.Job{
+		Id:              job.ID,
+		State:           jobStates[job.State],
+		Dataset:         job.Dataset,
+		InputFile:       job.InputFile,
+		RemoteFilePath:  job.RemoteFilePath,
+		SubmittedBy:     job.SubmittedBy,
+		Attempts:        int32(job.Attempts),
+		CreatedAt:       timestamppb.New(job.CreatedAt),
+		StartedAt:       optionalTimestamp(job.StartedAt),
+		FinishedAt:      optionalTimestamp(job.FinishedAt),
+		ErrorCode:       job.ErrorCode,
+		Error:           job.Error,
+		CancelRequested: job.CancelRequested,
+	}
+	if len(job.Options) > 0 {
+		res.Options = &pb.PipelineOptions{}
+		if err := unmarshalStored.Unmarshal(job.Options, res.Options); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed decoding options of job %s: %v", job.ID, err)
+		}
+	}
+	if len(job.Progress) > 0 {
+		snapshot, err := storedProgress(job)
+		if err != nil {
+			return nil, err
+		}
+		res.Progress = jobProgressProto(job, snapshot)
+	}
+	if len(job.Result) > 0 {
+		res.Result = &pb.ProcessFileResponse{}
+		if err := unmarshalStored.Unmarshal(job.Result, res.Result); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed decoding result of job %s: %v", job.ID, err)
+		}
+	}
+	return res, nil
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,145 @@
+package nyc_trip
+
+import (
+	"context"
+	"strings"
+
+	"processor/lib"
+	"processor/logger"
+	pb "processor/protos"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPreviewRows = 20
+	maxPreviewRows     = 1000
+)
+
+// errPreviewDone stops reading once enough rows are previewed
+var errPreviewDone = errors.New("preview done")
+
+// PreviewFile parses and enriches the first rows of an input member the way ProcessNYCTrip would,
+// nothing is written and the redis key is kept
+func (in *InputFile) PreviewFile(ctx context.Context, req *pb.PreviewFileRequest) (*pb.PreviewFileResponse, error) {
+	inputFile := req.GetInputFile()
+	limit := int(req.GetLimit())
+	if limit < 0 || limit > maxPreviewRows {
+		return nil, lib.InvalidField("limit", errors.Errorf("limit must be between 0 and %d, got %d", maxPreviewRows, limit))
+	}
+	if limit == 0 {
+		limit = defaultPreviewRows
+	}
+	logger.FromContext(ctx).Info("received preview request",
+		zap.String("member", req.GetMember()),
+	)
+
+	data, err := in.fetchInput(ctx, inputFile)
+	if err != nil {
+		return nil, err
+	}
+	fileList, err := readMembers(ctx, inputFile, data)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed reading input file", zap.Error(err))
+		return nil, readStatus(err, "input_file")
+	}
+	return in.preview(ctx, fileList, req.GetMember(), limit)
+}
+
+func (in *InputFile) preview(ctx context.Context, fileList []inputMember, member string, limit int) (*pb.PreviewFileResponse, error) {
+	res := &pb.PreviewFileResponse{}
+	selected := -1
+	for i, file := range fileList {
+		res.Members = append(res.Members, file.name)
+		if file.name == member || (member == "" && selected < 0) {
+			selected = i
+		}
+	}
+	if selected < 0 {
+		err := errors.Errorf("member %s isn't in the input, members are %s", member, strings.Join(res.Members, ", "))
+		return nil, lib.NewRPCError(codes.NotFound, pb.ErrorReason_ERROR_REASON_MEMBER_NOT_FOUND, err).With("member", member).Err()
+	}
+	file := fileList[selected]
+	res.Member = file.name
+
+	header, err := firstHeader(fileList)
+	if err != nil {
+		return nil, readStatus(err, "input_file")
+	}
+	res.Header = header.fields
+
+	mapTaxiZone, err := in.MapId.GetTaxiZoneMap()
+	if err != nil {
+		logger.FromContext(ctx).Error("failed getting mapTaxiZone", zap.Error(err))
+		return nil, loadStatus(err)
+	}
+
+	body, firstLine := header.body(file)
+	chunks := []lib.Chunk{{Offset: 0, Length: body.Size()}}
+	err = lib.ForEachLine(ctx, body, chunks, 1, func(lineNumber int64, line string) error {
+		if header.repeated(line) {
+			return nil
+		}
+		row := parserRow{
+			member:     file.name,
+			lineNumber: firstLine + lineNumber,
+			line:       strings.Split(line, "\t"),
+			headerMap:  header.index,
+		}
+		trip, errs := parseTrip(&row, mapTaxiZone, true)
+		previewRow := &pb.PreviewRow{
+			LineNumber: row.lineNumber,
+			RawFields:  row.line,
+		}
+		dropped := false
+		for _, fieldErr := range errs {
+			dropped = dropped || fieldErr.fatal
+			previewRow.Errors = append(previewRow.Errors, &pb.FieldError{
+				Field:    fieldErr.field,
+				Reason:   fieldErr.reason,
+				Message:  fieldErr.message,
+				DropsRow: fieldErr.fatal,
+			})
+		}
+		if !dropped {
+			previewRow.Row = tripRowProto(&trip)
+		}
+		res.Rows = append(res.Rows, previewRow)
+		if len(res.Rows) >= limit {
+			return errPreviewDone
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPreviewDone) {
+		logger.FromContext(ctx).Error("failed previewing file", zap.String("member", file.name), zap.Error(err))
+		return nil, loadStatus(err)
+	}
+	return res, nil
+}
+
+func tripRowProto(r *dbRow) *pb.TripRow {
+	return &pb.TripRow{
+		Vendor:               r.Vendor,
+		PickupTime:           timestamppb.New(r.PickupTime),
+		DropoffTime:          timestamppb.New(r.DropoffTime),
+		PassengerCount:       r.PassengerCount,
+		TripDistance:         r.TripDistance,
+		PuLocationRegion:     r.PuLocationRegion,
+		PuLocationZone:       r.PuLocationZone,
+		DoLocationRegion:     r.DoLocationRegion,
+		DoLocationZone:       r.DoLocationZone,
+		PaymentType:          r.PaymentType,
+		FareAmount:           r.FareAmount,
+		Extra:                r.Extra,
+		MtaTax:               r.MtaTax,
+		TipAmount:            r.TipAmount,
+		TollsAmount:          r.TollsAmount,
+		ImprovementSurcharge: r.ImprovementSurcharge,
+		TotalAmount:          r.TotalAmount,
+		CongestionSurcharge:  r.CongestionSurcharge,
+		AirportFee:           r.AirportFee,
+	}
+}
@@ -0,0 +1,71 @@
+package nyc_trip
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPreviewMember(t *testing.T) {
+	in := newTestInputFile(t, nil)
+	first := testInput(10)[0].data
+	second := []byte("2\t2025-01-02 10:00:00\t2025-01-02 10:20:00\t1\t3.2\t99\t1\t2\tabc\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n" +
+		"1\t2025-01-02 11:00:00\t2025-01-02 11:20:00\t1\t3.2\t2\t1\t2\t\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n" +
+		"1\t2025-01-02 12:00:00\t2025-01-02 12:20:00\t1\t3.2\t2\t1\t2\t9\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n")
+	fileList := []inputMember{{name: "a.csv", data: first}, {name: "b.csv", data: second}}
+
+	res, err := in.preview(context.Background(), fileList, "b.csv", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Member != "b.csv" || len(res.Members) != 2 || res.Header[0] != "VendorID" {
+		t.Fatalf("unexpected preview of %s, members %v, header %v", res.Member, res.Members, res.Header)
+	}
+	if len(res.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(res.Rows))
+	}
+
+	// the member doesn't carry the header, its first line is a row
+	dropped := res.Rows[0]
+	if dropped.LineNumber != 1 || dropped.Row != nil || len(dropped.Errors) != 2 {
+		t.Fatalf("expected line 1 dropped with two errors, got %v", dropped)
+	}
+	if dropped.Errors[0].Field != "PULocationID" || !dropped.Errors[0].DropsRow ||
+		dropped.Errors[1].Field != "fare_amount" || dropped.Errors[1].DropsRow {
+		t.Fatalf("unexpected field errors %v", dropped.Errors)
+	}
+
+	kept := res.Rows[1]
+	if kept.Row == nil || len(kept.Errors) != 0 {
+		t.Fatalf("expected line 2 to be kept, got %v", kept)
+	}
+	if kept.Row.PuLocationRegion != "Queens" || kept.Row.DoLocationZone != "Midtown Center" || kept.Row.FareAmount != nil {
+		t.Fatalf("unexpected enriched row %v", kept.Row)
+	}
+	if kept.RawFields[5] != "2" {
+		t.Fatalf("expected the raw fields, got %v", kept.RawFields)
+	}
+}
+
+func TestPreviewHeaderMember(t *testing.T) {
+	in := newTestInputFile(t, nil)
+
+	res, err := in.preview(context.Background(), testInput(10), "", defaultPreviewRows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 10 || res.Rows[0].LineNumber != 2 || res.Rows[0].Row == nil {
+		t.Fatalf("expected 10 rows starting after the header, got %d starting at %v", len(res.Rows), res.Rows[0])
+	}
+}
+
+func TestPreviewUnknownMember(t *testing.T) {
+	in := newTestInputFile(t, nil)
+
+	_, err := in.preview(context.Background(), testInput(1), "missing.csv", 1)
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -1,11 +1,14 @@
 package nyc_trip
 
 import (
-	"bufio"
 	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"processor/lib"
@@ -13,7 +16,13 @@ import (
 	pb "processor/protos"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -22,138 +31,1051 @@ import (
 type InputFile struct {
 	pb.UnimplementedTransformServiceServer
 
-	RedisHost string
-	RedisDB   string
-	DBConn    *pgxpool.Pool
-	MapId     *lib.MapId
+	Redis           *lib.RedisClient
+	DBConn          *pgxpool.Pool
+	MapId           *lib.MapId
+	RetryPolicy     lib.RetryPolicy
+	PipelineConfig  lib.PipelineConfig
+	PipelineLimits  lib.PipelineLimits
+	AdaptiveConfig  lib.AdaptiveConfig
+	InserterLimiter *lib.WorkerLimiter
+	Hypertable      lib.Hypertable
+	PersistProfile  bool
+	InlineMaxBytes  int
+	LeaseConfig     lib.LeaseConfig
+	UploadConfig    lib.UploadConfig
+
+	insertBatch lib.BatchInsertFunc
+	inFlight    atomic.Int64
+	uploadSlots *semaphore.Weighted
+}
+
+type inputMember struct {
+	name string
+	// index is the position of the member in the input
+	index int
+	data  []byte
+	// stream is read once instead of data for a member that isn't held in memory
+	stream io.Reader
+}
+
+// inputMembers passes the members of an input to fn in order, a streamed member
+// must be read by fn before the next one is reached
+type inputMembers struct {
+	each func(fn func(inputMember) error) error
+	// size is the bytes of the members held in memory
+	size int64
+}
+
+func memberList(fileList []inputMember) inputMembers {
+	members := inputMembers{each: func(fn func(inputMember) error) error {
+		for _, file := range fileList {
+			if err := fn(file); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+	for _, file := range fileList {
+		members.size += int64(len(file.data))
+	}
+	return members
+}
+
+// streamMembers reads the members of an input from r, archive members come in
+// archive order
+func streamMembers(inputFile string, r io.Reader) inputMembers {
+	return inputMembers{each: func(fn func(inputMember) error) error {
+		if !strings.HasSuffix(inputFile, ".tar.gz") {
+			return fn(inputMember{name: inputFile, stream: r})
+		}
+		var fnErr error
+		err := lib.ForEachTarGzMember(r, func(name string, member io.Reader) error {
+			fnErr = fn(inputMember{name: name, stream: archiveMember{r: member}})
+			return fnErr
+		})
+		if err != nil && fnErr == nil {
+			return lib.ArchiveError(err, "failed to extract tar.gz file "+inputFile)
+		}
+		return err
+	}}
+}
+
+// archiveMember fails a read of a member cut short as an invalid archive
+type archiveMember struct {
+	r io.Reader
+}
+
+func (m archiveMember) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	if err != nil && err != io.EOF {
+		return n, lib.ArchiveError(err, "failed reading archive member")
+	}
+	return n, err
 }
 
-func NewInputFile(redisHost, redisDB string, dbConn *pgxpool.Pool, mapId *lib.MapId) *InputFile {
-	return &InputFile{
-		RedisHost: redisHost,
-		RedisDB:   redisDB,
-		DBConn:    dbConn,
-		MapId:     mapId,
+// Init sets up what's derived from the exported fields, it's called once they're set
+// and before the first request
+func (in *InputFile) Init() {
+	in.insertBatch = lib.NewBatchInserter(in.DBConn, in.RetryPolicy)
+	in.uploadSlots = semaphore.NewWeighted(int64(in.UploadConfig.MaxConcurrent))
+}
+
+// pipelineConfig applies the per request overrides on top of the processor config,
+// the runtime tuning doesn't change the overrides
+func (in *InputFile) pipelineConfig(opts *pb.PipelineOptions) (lib.PipelineConfig, error) {
+	cfg := in.PipelineConfig
+	cfg.Adaptive = in.AdaptiveConfig.Pinned(int(opts.GetBatchSize()), int(opts.GetParserWorkers()), int(opts.GetInserterWorkers()))
+	if opts == nil {
+		return cfg, nil
+	}
+	if v := opts.GetParserWorkers(); v != 0 {
+		cfg.ParserWorkers = int(v)
+	}
+	if v := opts.GetInserterWorkers(); v != 0 {
+		cfg.InserterWorkers = int(v)
 	}
+	if v := opts.GetBatchSize(); v != 0 {
+		cfg.BatchSize = int(v)
+	}
+	if v := opts.GetChannelSize(); v != 0 {
+		cfg.ChannelSize = int(v)
+	}
+	if v := opts.GetReaderWorkers(); v != 0 {
+		cfg.ReaderWorkers = int(v)
+	}
+	if v := opts.GetChunkSize(); v != 0 {
+		cfg.ChunkSize = v
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Within(in.PipelineLimits)
+}
+
+// ValidateOptions checks the per request overrides before a job is queued with them
+func (in *InputFile) ValidateOptions(opts *pb.PipelineOptions) error {
+	_, err := in.pipelineConfig(opts)
+	return err
 }
 
 func (in *InputFile) ProcessNYCTrip(ctx context.Context, req *pb.InputFileRequest) (*pb.ProcessFileResponse, error) {
 	inputFile := req.GetInputFile()
-	logger.Info("received request", zap.String("FileInput", inputFile))
+
+	pipelineCfg, err := in.pipelineConfig(req.GetOptions())
+	if err != nil {
+		logger.FromContext(ctx).Error("invalid pipeline options", zap.Error(err))
+		return nil, optionsStatus(err)
+	}
+
+	// the input isn't claimed before the pod can load it
+	if !in.MapId.Ready() {
+		return nil, loadStatus(lib.ErrMapNotReady)
+	}
 
 	perfStart := time.Now()
-	redisDB, err := strconv.Atoi(in.RedisDB)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("input_file", inputFile))
+	claimCtx, claimSpan := lib.StartSpan(ctx, "redis.claim")
+	lease, data, err := in.Redis.ClaimInput(claimCtx, inputFile, in.LeaseConfig)
+	claimSpan.SetAttributes(attribute.Int("bytes", len(data)))
+	lib.EndSpan(claimSpan, err)
+	switch {
+	case errors.Is(err, lib.ErrInputNotFound):
+		logger.FromContext(ctx).Error("input file isn't in redis")
+		return nil, inputStatus(err, inputFile)
+	case errors.Is(err, lib.ErrInputClaimed):
+		logger.FromContext(ctx).Warn("input file is already being processed")
+		return nil, lib.NewRPCError(codes.AlreadyExists, pb.ErrorReason_ERROR_REASON_DUPLICATE_FILE, err).With("input_file", inputFile).Err()
+	case err != nil:
+		logger.FromContext(ctx).Error("failed claiming input file", zap.Error(err))
+		return nil, inputStatus(err, inputFile)
+	}
+
+	// a lost lease means another processor may take the input, stop loading it
+	loadCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stopKeepAlive := lease.KeepAlive(loadCtx, func(err error) {
+		logger.FromContext(ctx).Error("lost lease on input file", zap.Error(err))
+		cancel(err)
+	})
+
+	fileList, err := readMembers(ctx, inputFile, data)
 	if err != nil {
-		logger.Error("failed converting redis db to int", zap.Error(err))
-		return nil, status.New(codes.Internal, err.Error()).Err()
+		stopKeepAlive()
+		logger.FromContext(ctx).Error("failed reading input file", zap.Error(err))
+		in.releaseInput(ctx, lease, err, true)
+		return nil, readStatus(err, "input_file")
 	}
-	redisClient, err := lib.NewRedisClient(ctx, in.RedisHost, redisDB)
+
+	res, err := in.load(loadCtx, inputFile, req.GetRemoteFilePath(), memberList(fileList), pipelineCfg, loadOptions{
+		sampleSize: defaultRejectedSample,
+	})
+	stopKeepAlive()
 	if err != nil {
-		logger.Error("failed creating redis client", zap.Error(err))
-		return nil, status.New(codes.Internal, err.Error()).Err()
+		if cause := context.Cause(loadCtx); errors.Is(cause, lib.ErrLeaseLost) {
+			err = lib.NewRPCError(codes.Aborted, pb.ErrorReason_ERROR_REASON_LEASE_LOST, cause).With("input_file", inputFile).Err()
+		}
+		in.releaseInput(ctx, lease, err, !retryLoad(err))
+		return nil, err
+	}
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), leaseReleaseTimeout)
+	defer releaseCancel()
+	if err := lease.Complete(releaseCtx); err != nil {
+		logger.FromContext(ctx).Error("failed deleting input file from redis", zap.Error(err))
 	}
-	inputFileRedis, err := redisClient.GetInputFile(ctx, inputFile)
+	logger.FromContext(ctx).Info("processed",
+		zap.Duration("duration", time.Since(perfStart)),
+		zap.Int64("batchRetries", res.BatchRetries),
+	)
+	return res, nil
+}
+
+// ValidateFile runs the load without writing to the database, the input comes from redis
+// or inline in the request and is never deleted
+func (in *InputFile) ValidateFile(ctx context.Context, req *pb.ValidateFileRequest) (*pb.ProcessFileResponse, error) {
+	pipelineCfg, err := in.pipelineConfig(req.GetOptions())
 	if err != nil {
-		logger.Error("failed getting input file from redis", zap.Error(err))
-		return nil, status.New(codes.Internal, err.Error()).Err()
+		logger.FromContext(ctx).Error("invalid pipeline options", zap.Error(err))
+		return nil, optionsStatus(err)
 	}
-	defer func() {
-		if err := redisClient.DeleteInputFile(ctx, inputFile); err != nil {
-			logger.Error("failed deleting input file from redis", zap.Error(err))
+	sampleSize := int(req.GetSampleSize())
+	if sampleSize < 0 || sampleSize > maxRejectedSample {
+		return nil, lib.InvalidField("sample_size", errors.Errorf("sample size must be between 0 and %d, got %d", maxRejectedSample, sampleSize))
+	}
+	if sampleSize == 0 {
+		sampleSize = defaultRejectedSample
+	}
+
+	var fileName string
+	var data []byte
+	switch source := req.GetSource().(type) {
+	case *pb.ValidateFileRequest_InputFile:
+		fileName = source.InputFile
+		data, err = in.fetchInput(ctx, fileName)
+		if err != nil {
+			return nil, err
+		}
+	case *pb.ValidateFileRequest_InlineData:
+		fileName = req.GetFileName()
+		if fileName == "" {
+			return nil, lib.InvalidField("file_name", errors.New("file name is required for inline data"))
 		}
+		if len(source.InlineData) > in.InlineMaxBytes {
+			return nil, lib.InvalidField("inline_data", errors.Errorf("inline data is %d bytes, the limit is %d", len(source.InlineData), in.InlineMaxBytes))
+		}
+		data = source.InlineData
+	default:
+		return nil, lib.InvalidField("source", errors.New("either input file or inline data is required"))
+	}
+	logger.FromContext(ctx).Info("received validate request",
+		zap.Int("bytes", len(data)),
+	)
+
+	fileList, err := readMembers(ctx, fileName, data)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed reading input file", zap.Error(err))
+		return nil, readStatus(err, "file_name")
+	}
+	return in.load(ctx, fileName, "", memberList(fileList), pipelineCfg, loadOptions{
+		dryRun:     true,
+		sampleSize: sampleSize,
+	})
+}
+
+// UploadAndProcess loads a file streamed by the caller instead of staged in redis. The
+// chunks are hashed and spooled to disk as they arrive, rows are only loaded once the
+// checksum matched since batches commit on their own and a corrupt upload couldn't be undone.
+func (in *InputFile) UploadAndProcess(stream pb.TransformService_UploadAndProcessServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return lib.InvalidField("metadata", errors.New("upload is empty"))
+	}
+	if err != nil {
+		return err
+	}
+	meta := first.GetMetadata()
+	if meta == nil {
+		return lib.InvalidField("metadata", errors.New("the first upload message must carry the metadata"))
+	}
+	if meta.GetDataset() != TableSchema.Name {
+		return lib.InvalidField("metadata.dataset", errors.Errorf("unsupported dataset %q", meta.GetDataset()))
+	}
+	fileName := meta.GetFileName()
+	if err := checkFormat(fileName); err != nil {
+		return readStatus(err, "metadata.file_name")
+	}
+	if checksum := meta.GetSha256(); checksum != "" && !lib.ValidChecksum(checksum) {
+		return lib.InvalidField("metadata.sha256", errors.Errorf("checksum %q isn't a hex sha256", checksum))
+	}
+	if meta.GetSize() < 0 || meta.GetSize() > in.UploadConfig.MaxBytes {
+		err := errors.Wrapf(lib.ErrUploadTooLarge, "upload is %d bytes, the limit is %d", meta.GetSize(), in.UploadConfig.MaxBytes)
+		return uploadStatus(err)
+	}
+	if c := meta.GetCompression(); c != pb.Compression_COMPRESSION_NONE && c != pb.Compression_COMPRESSION_GZIP {
+		return lib.InvalidField("metadata.compression", errors.Errorf("unsupported compression %v", c))
+	}
+	pipelineCfg, err := in.pipelineConfig(meta.GetOptions())
+	if err != nil {
+		logger.FromContext(ctx).Error("invalid pipeline options", zap.Error(err))
+		return optionsStatus(err)
+	}
+	if !in.MapId.Ready() {
+		return loadStatus(lib.ErrMapNotReady)
+	}
+
+	// chunks aren't read while the upload waits for a slot, grpc flow control then
+	// holds the sender back instead of the bytes piling up here
+	if err := in.uploadSlots.Acquire(ctx, 1); err != nil {
+		return loadStatus(err)
+	}
+	defer in.uploadSlots.Release(1)
+
+	perfStart := time.Now()
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("input_file", fileName))
+	logger.FromContext(ctx).Info("receiving upload",
+		zap.String("file", fileName),
+		zap.Stringer("compression", meta.GetCompression()),
+		zap.Int64("size", meta.GetSize()),
+	)
+	receiver, err := lib.NewUploadReceiver(in.UploadConfig, meta.GetCompression() == pb.Compression_COMPRESSION_GZIP)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed receiving upload", zap.Error(err))
+		return uploadStatus(err)
+	}
+	defer receiver.Close()
+	upload, err := receiveUpload(ctx, stream, meta, receiver)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed receiving upload", zap.Error(err))
+		return err
+	}
+
+	res, err := in.load(ctx, fileName, meta.GetRemoteFilePath(), streamMembers(fileName, upload), pipelineCfg, loadOptions{
+		sampleSize: defaultRejectedSample,
+	})
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("processed",
+		zap.Duration("duration", time.Since(perfStart)),
+		zap.Int64("batchRetries", res.BatchRetries),
+	)
+	return stream.SendAndClose(res)
+}
+
+// receiveUpload spools the chunks following the metadata until the caller closes the stream,
+// it returns the upload decompressed once its checksum matched
+func receiveUpload(ctx context.Context, stream pb.TransformService_UploadAndProcessServer, meta *pb.UploadMetadata, receiver *lib.UploadReceiver) (upload io.Reader, err error) {
+	_, span := lib.StartSpan(ctx, "upload.receive", attribute.String("compression", meta.GetCompression().String()))
+	defer func() {
+		span.SetAttributes(attribute.Int64("bytes", receiver.Received()))
+		lib.EndSpan(span, err)
 	}()
 
-	fileList := [][]byte{}
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunk, ok := msg.GetPayload().(*pb.UploadRequest_Chunk)
+		if !ok {
+			return nil, lib.InvalidField("metadata", errors.New("only the first upload message can carry the metadata"))
+		}
+		if _, err := receiver.Write(chunk.Chunk); err != nil {
+			return nil, uploadStatus(err)
+		}
+	}
+	if size := meta.GetSize(); size != 0 && receiver.Received() != size {
+		err := errors.Errorf("received %d bytes, the metadata announced %d", receiver.Received(), size)
+		return nil, lib.NewRPCError(codes.DataLoss, pb.ErrorReason_ERROR_REASON_UPLOAD_INCOMPLETE, err).Err()
+	}
+	upload, err = receiver.Finish(meta.GetSha256())
+	if err != nil {
+		return nil, uploadStatus(err)
+	}
+	return upload, nil
+}
+
+// uploadStatus maps an upload failure to a grpc status, a too large upload isn't worth retrying
+// and a failure to spool it is the processor's
+func uploadStatus(err error) error {
+	switch {
+	case errors.Is(err, lib.ErrUploadTooLarge):
+		return lib.NewRPCError(codes.ResourceExhausted, pb.ErrorReason_ERROR_REASON_UPLOAD_TOO_LARGE, err).Err()
+	case errors.Is(err, lib.ErrChecksumMismatch):
+		return lib.NewRPCError(codes.DataLoss, pb.ErrorReason_ERROR_REASON_CHECKSUM_MISMATCH, err).Err()
+	case errors.Is(err, lib.ErrInvalidArchive):
+		return lib.NewRPCError(codes.InvalidArgument, pb.ErrorReason_ERROR_REASON_INVALID_ARCHIVE, err).Err()
+	default:
+		return lib.NewRPCError(codes.Internal, pb.ErrorReason_ERROR_REASON_INTERNAL, err).Err()
+	}
+}
 
+func optionsStatus(err error) error {
+	return lib.NewRPCError(codes.InvalidArgument, pb.ErrorReason_ERROR_REASON_INVALID_OPTIONS, err).Field("options", err.Error()).Err()
+}
+
+// readStatus maps a failure to read the members of an input, field names the file name
+func readStatus(err error, field string) error {
+	if errors.Is(err, errUnsupportedFileType) {
+		return lib.NewRPCError(codes.InvalidArgument, pb.ErrorReason_ERROR_REASON_UNSUPPORTED_FILE_TYPE, err).Field(field, err.Error()).Err()
+	}
+	return lib.NewRPCError(codes.InvalidArgument, pb.ErrorReason_ERROR_REASON_INVALID_ARCHIVE, err).Err()
+}
+
+// inputStatus maps a failure to get an input from redis, any failure but a missing key is
+// worth retrying
+func inputStatus(err error, inputFile string) error {
+	if errors.Is(err, lib.ErrInputNotFound) {
+		return lib.NewRPCError(codes.NotFound, pb.ErrorReason_ERROR_REASON_INPUT_NOT_FOUND, err).With("input_file", inputFile).Err()
+	}
+	return lib.NewRPCError(codes.Unavailable, pb.ErrorReason_ERROR_REASON_REDIS_UNAVAILABLE, err).RetryAfter(lib.DefaultRetryDelay).Err()
+}
+
+// InFlight returns the number of loads running
+func (in *InputFile) InFlight() int64 {
+	return in.inFlight.Load()
+}
+
+// leaseReleaseTimeout bounds completing or failing a lease once the request is done
+const leaseReleaseTimeout = 5 * time.Second
+
+// releaseInput gives the input back after a failed load, it stays in redis for a retry
+// until the attempts run out or the failure is permanent, it's then moved to the dead-letter key
+func (in *InputFile) releaseInput(ctx context.Context, lease *lib.InputLease, cause error, permanent bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), leaseReleaseTimeout)
+	defer cancel()
+	deadLettered, err := lease.Fail(ctx, cause, permanent)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed releasing input file", zap.Error(err))
+		return
+	}
+	if deadLettered {
+		logger.FromContext(ctx).Error("moved input file to dead-letter", zap.String("key", lease.DeadLetterKey()))
+		return
+	}
+	logger.FromContext(ctx).Info("kept input file for retry")
+}
+
+// fetchInput reads the input file from redis without claiming it
+func (in *InputFile) fetchInput(ctx context.Context, inputFile string) ([]byte, error) {
+	spanCtx, span := lib.StartSpan(ctx, "redis.get")
+	inputFileRedis, err := in.Redis.GetInputFile(spanCtx, inputFile)
+	lib.EndSpan(span, err)
+	if errors.Is(err, lib.ErrInputNotFound) {
+		logger.FromContext(ctx).Error("input file isn't in redis")
+		return nil, inputStatus(err, inputFile)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed getting input file from redis", zap.Error(err))
+		return nil, inputStatus(err, inputFile)
+	}
+	return inputFileRedis.File, nil
+}
+
+// readMembers returns the files to load from an input, archives are extracted with their
+// members in archive order like an upload streams them
+func readMembers(ctx context.Context, inputFile string, data []byte) (fileList []inputMember, err error) {
+	_, span := lib.StartSpan(ctx, "extract", attribute.Int("bytes", len(data)))
+	defer func() {
+		span.SetAttributes(attribute.Int("members", len(fileList)))
+		lib.EndSpan(span, err)
+	}()
+
+	if err := checkFormat(inputFile); err != nil {
+		return nil, err
+	}
 	if strings.HasSuffix(inputFile, ".tar.gz") {
-		files, err := lib.ExtractTarGz(inputFileRedis.File)
+		err := lib.ForEachTarGzMember(bytes.NewReader(data), func(name string, member io.Reader) error {
+			file, err := io.ReadAll(member)
+			fileList = append(fileList, inputMember{name: name, data: file})
+			return err
+		})
 		if err != nil {
-			logger.Error("failed to extract tar.gz file", zap.Error(err))
-			return nil, status.Errorf(codes.Internal, "failed to extract tar.gz file %v", inputFile)
-		}
-		for _, file := range files {
-			fileList = append(fileList, file)
+			return nil, errors.Wrapf(err, "failed to extract tar.gz file %v", inputFile)
 		}
-	} else if strings.HasSuffix(inputFile, ".txt") || strings.HasSuffix(inputFile, ".csv") {
-		fileList = append(fileList, inputFileRedis.File)
 	} else {
-		logger.Error("file type not supported", zap.String("file", inputFile))
-		return nil, status.Errorf(codes.Internal, "file type not supported %v", inputFile)
+		fileList = append(fileList, inputMember{name: inputFile, data: data})
 	}
+	return fileList, nil
+}
 
-	var lineNumber int64
-	var totalRow int64
+var errUnsupportedFileType = errors.New("file type not supported")
 
-	parser := newNycTripParser(inputFile, req.GetRemoteFilePath(), in.MapId)
+// checkFormat fails unless the file name is a .tar.gz archive or a .txt or .csv file
+func checkFormat(inputFile string) error {
+	for _, ext := range []string{".tar.gz", ".txt", ".csv"} {
+		if strings.HasSuffix(inputFile, ext) {
+			return nil
+		}
+	}
+	return errors.Wrapf(errUnsupportedFileType, "%v", inputFile)
+}
 
-	dbInsert := newNycTripDbInsert(ctx, in.DBConn)
+const (
+	defaultRejectedSample = 10
+	maxRejectedSample     = 1000
+)
 
-	workerCount := 3
-	for range workerCount {
-		parser.wg.Add(1)
-		go parser.processWorker(dbInsert.ch)
-	}
+type loadOptions struct {
+	// dryRun encodes the batches but never sends them to the database
+	dryRun bool
+	// sampleSize is the number of rejected rows returned
+	sampleSize int
+}
 
-	for range workerCount {
-		dbInsert.wg.Add(1)
-		go dbInsert.processWorker()
+// load runs the reader, parser and inserter stages over the input members.
+// The first failing worker or a cancelled ctx stops every stage, load only
+// returns once all of its goroutines have exited.
+func (in *InputFile) load(
+	ctx context.Context,
+	inputFile, remoteFilePath string,
+	members inputMembers,
+	pipelineCfg lib.PipelineConfig,
+	opts loadOptions,
+) (*pb.ProcessFileResponse, error) {
+	in.inFlight.Add(1)
+	defer in.inFlight.Add(-1)
+	start := time.Now()
+	outcome := "error"
+	defer func() {
+		lib.FileDuration.WithLabelValues(TableSchema.Name, strconv.FormatBool(opts.dryRun), outcome).Observe(time.Since(start).Seconds())
+	}()
+
+	// a dry run never writes, it doesn't take the inserter slots of the loads
+	inserterWorkers := pipelineCfg.InserterWorkers
+	if !opts.dryRun {
+		acquired, release, err := in.InserterLimiter.Acquire(ctx, pipelineCfg.InserterWorkers)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed reserving inserter workers", zap.Error(err))
+			return nil, loadStatus(err)
+		}
+		defer release()
+		inserterWorkers = acquired
 	}
+	logger.FromContext(ctx).Info("pipeline sizing",
+		zap.Int("parserWorkers", pipelineCfg.ParserWorkers),
+		zap.Int("inserterWorkers", inserterWorkers),
+		zap.Int("batchSize", pipelineCfg.BatchSize),
+		zap.Int("channelSize", pipelineCfg.ChannelSize),
+		zap.Duration("chunkInterval", in.Hypertable.ChunkInterval),
+	)
 
-	for _, file := range fileList {
-		scanner := bufio.NewScanner(bytes.NewReader(file))
+	group, groupCtx := errgroup.WithContext(ctx)
+	parser := newNycTripParser(inputFile, remoteFilePath, in.MapId, pipelineCfg.ChannelSize, opts.sampleSize)
+	sizer := lib.NewBatchSizer(pipelineCfg.BatchSize, pipelineCfg.Adaptive)
+	insertBatch := lib.GuardWrites(ctx, in.insertBatch)
+	if opts.dryRun {
+		insertBatch = discardBatch
+	}
+	dbInsert := newNycTripDbInsert(insertBatch, sizer, in.Hypertable, pipelineCfg.ChannelSize)
+	progress := lib.ProgressFrom(ctx)
+	if progress == nil {
+		progress = lib.NewProgress()
+	}
+	progress.TotalBytes.Add(members.size)
+	parser.progress = progress
+	dbInsert.progress = progress
+	counters := lib.NewRowCounters(TableSchema.Name, opts.dryRun)
+	parser.counters = counters
 
-		headerMap := make(map[string]int)
+	parserStage := &lib.ScalableStage{
+		Name:  "parser",
+		Min:   1,
+		Max:   max(pipelineCfg.Adaptive.MaxParserWorkers, pipelineCfg.ParserWorkers),
+		Depth: func() (int, int) { return len(parser.ch), cap(parser.ch) },
+		Stop:  parser.stopWorker,
+	}
+	parserStage.Start = func() bool {
+		parser.wg.Add(1)
+		group.Go(lib.Recovered(ctx, func() error {
+			defer parserStage.Stopped()
+			return parser.processWorker(groupCtx, dbInsert.ch)
+		}))
+		return true
+	}
 
-		for scanner.Scan() {
-			line := scanner.Text()
-			row := strings.Split(line, "\t")
-			totalRow++
-			if lineNumber == 0 {
-				for i, col := range row {
-					headerMap[col] = i
-				}
-				lineNumber++
-			} else {
-				parser.ch <- parserRow{
-					line:      row,
-					headerMap: headerMap,
-				}
+	inserterStage := &lib.ScalableStage{
+		Name:  "inserter",
+		Min:   1,
+		Max:   max(pipelineCfg.Adaptive.MaxInserterWorkers, inserterWorkers),
+		Depth: func() (int, int) { return len(dbInsert.ch), cap(dbInsert.ch) },
+		Stop:  dbInsert.stopWorker,
+	}
+	startInserter := func(ownSlot bool) {
+		dbInsert.wg.Add(1)
+		group.Go(lib.Recovered(ctx, func() error {
+			if ownSlot {
+				defer in.InserterLimiter.Release()
 			}
+			defer inserterStage.Stopped()
+			return dbInsert.processWorker(groupCtx)
+		}))
+	}
+	// workers added at runtime hold their own limiter slot until they exit,
+	// so the slots held never drop below the running inserters
+	inserterStage.Start = func() bool {
+		if opts.dryRun {
+			startInserter(false)
+			return true
 		}
+		if !in.InserterLimiter.TryAcquire() {
+			return false
+		}
+		startInserter(true)
+		return true
 	}
 
-	if err = parser.done(); err != nil {
-		logger.Error("failed parsing file", zap.Error(err))
-		return nil, status.Error(codes.Internal, parser.err.Error())
+	for range pipelineCfg.ParserWorkers {
+		parserStage.Started()
+		parserStage.Start()
+	}
+	for range inserterWorkers {
+		inserterStage.Started()
+		startInserter(false)
 	}
-	if err = dbInsert.done(); err != nil {
-		logger.Error("failed inserting to database", zap.Error(err))
-		return nil, status.Error(codes.Internal, dbInsert.err.Error())
+
+	autoscaler := lib.NewAutoscaler(pipelineCfg.Adaptive, sizer, parserStage, inserterStage)
+	go autoscaler.Run(groupCtx)
+
+	depth := lib.NewDepthSampler()
+	depth.Add(TableSchema.Name, "parser", func() int { return len(parser.ch) })
+	depth.Add(TableSchema.Name, "inserter", func() int { return len(dbInsert.ch) })
+	depth.Start(time.Second)
+	defer depth.Stop()
+
+	// the reader closes the stages in order once the input is consumed, the autoscaler
+	// is stopped first so no worker is added to a stage whose input is closed
+	var totalRow atomic.Int64
+	group.Go(lib.Recovered(ctx, func() error {
+		err := readInput(groupCtx, members, pipelineCfg, parser.ch, &totalRow, progress, counters.Read)
+		autoscaler.Stop()
+		// on a failed read the stages aren't closed, the cancelled group stops them
+		// without flushing the rows read so far
+		if err != nil {
+			return err
+		}
+		parser.done()
+		dbInsert.done()
+		return nil
+	}))
+
+	if err := group.Wait(); err != nil {
+		logger.FromContext(ctx).Error("failed loading file", zap.Error(err))
+		// a caller who gave up keeps its cancellation code, the reason still tells the file
+		// must not be sent again
+		committed := dbInsert.insertedRow.Load()
+		if committed > 0 || lib.IsCommitUnknown(err) {
+			code := codes.Aborted
+			switch {
+			case errors.Is(ctx.Err(), context.Canceled):
+				code = codes.Canceled
+			case errors.Is(ctx.Err(), context.DeadlineExceeded):
+				code = codes.DeadlineExceeded
+			}
+			return nil, partialLoadStatus(code, err, committed)
+		}
+		return nil, loadStatus(err)
 	}
 
+	// every worker has exited, the merged stats aren't written anymore
+	stats := &parser.stats
 	var maxTime *timestamppb.Timestamp
 	var minTime *timestamppb.Timestamp
-
-	if parser.maxTime == nil {
-		maxTime = nil
-	} else {
-		maxTime = timestamppb.New(*parser.maxTime)
+	if dropoff := stats.profile.Column("dropoff_time"); dropoff.Count > 0 {
+		maxTime = timestamppb.New(dropoff.MaxTime())
 	}
-	if parser.minTime == nil {
-		minTime = nil
-	} else {
-		minTime = timestamppb.New(*parser.minTime)
+	if pickup := stats.profile.Column("pickup_time"); pickup.Count > 0 {
+		minTime = timestamppb.New(pickup.MinTime())
 	}
 
-	perfEnd := time.Now()
-	logger.Info("processed", zap.Duration("duration", perfEnd.Sub(perfStart)))
+	if in.PersistProfile && !opts.dryRun {
+		ingestion := lib.ProfileIngestion{
+			Table:          TableSchema.Name,
+			FileName:       inputFile,
+			RemoteFilePath: remoteFilePath,
+			IngestedAt:     time.Now().UTC(),
+			IngestedBy:     lib.IdentityFrom(ctx).Subject,
+		}
+		// the rows are already committed, a missing profile doesn't fail the load
+		if err := lib.PersistProfile(ctx, in.DBConn, ingestion, stats.profile); err != nil {
+			logger.FromContext(ctx).Error("failed persisting profile", zap.Error(err))
+		}
+	}
 
+	outcome = "success"
 	return &pb.ProcessFileResponse{
-		TotalRows:     totalRow - 1, // mines the header line
-		ProcessedRows: parser.processedRow.Load(),
-		DroppedRows:   parser.droppedRow.Load(),
+		TotalRows:     totalRow.Load() - 1, // mines the header line
+		ProcessedRows: stats.processedRow,
+		DroppedRows:   stats.droppedRow,
 		InsertedRows:  dbInsert.insertedRow.Load(),
 		MaxTime:       maxTime,
 		MinTime:       minTime,
+		BatchRetries:  dbInsert.retries.Load(),
+		Adaptive:      adaptiveStatsProto(autoscaler.Stats()),
+		Chunks:        chunkStatsProto(dbInsert.chunkStats.Stats()),
+		Profile:       profileProto(stats.profile),
+		DropReasons:   dropReasonsProto(stats.dropReasons),
+		RejectedRows:  rejectedRowsProto(stats.rejected),
 	}, nil
 }
+
+// discardBatch stands in for the database on a dry run, encoding errors still fail the load
+func discardBatch(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+	return 0, 0, tbl.Err()
+}
+
+// inputHeader is the first line of the first non-empty member, its mapping applies to every member.
+// Later members may start without it or repeat it, a repeated header line isn't a row.
+type inputHeader struct {
+	line   string
+	fields []string
+	index  map[string]int
+	// member carries the header line, next is the offset of the line after it
+	member string
+	next   int64
+}
+
+// readHeader reads the header from a member, a streamed member is advanced past it.
+// The header is zero for an empty member.
+func readHeader(file *inputMember) (inputHeader, error) {
+	var header inputHeader
+	var line string
+	var next int64
+	var err error
+	switch {
+	case file.stream != nil:
+		line, file.stream, err = lib.SplitFirstLine(file.stream)
+		if errors.Is(err, io.EOF) {
+			file.stream = bytes.NewReader(nil)
+			return header, nil
+		}
+	case len(file.data) == 0:
+		return header, nil
+	default:
+		line, next, err = lib.ReadFirstLine(bytes.NewReader(file.data), int64(len(file.data)))
+	}
+	if err != nil {
+		return header, errors.Wrapf(err, "failed reading header of %s", file.name)
+	}
+	header.line = line
+	header.fields = strings.Split(line, "\t")
+	header.index = make(map[string]int, len(header.fields))
+	for i, col := range header.fields {
+		header.index[col] = i
+	}
+	header.member = file.name
+	header.next = next
+	return header, nil
+}
+
+// firstHeader reads the header from the first non-empty member
+func firstHeader(fileList []inputMember) (inputHeader, error) {
+	for i := range fileList {
+		header, err := readHeader(&fileList[i])
+		if err != nil || header.member != "" {
+			return header, err
+		}
+	}
+	return inputHeader{}, nil
+}
+
+// missingColumnsError is an input whose header lacks columns every row needs
+type missingColumnsError struct {
+	member  string
+	columns []string
+}
+
+func (e *missingColumnsError) Error() string {
+	return fmt.Sprintf("header of %s lacks the columns %s", e.member, strings.Join(e.columns, ", "))
+}
+
+// checkRequired fails when every row would be dropped for a column missing from the header
+func (h inputHeader) checkRequired() error {
+	var missing []string
+	for _, col := range requiredColumns {
+		if _, found := h.index[col]; !found {
+			missing = append(missing, col)
+		}
+	}
+	if len(missing) > 0 {
+		return &missingColumnsError{member: h.member, columns: missing}
+	}
+	return nil
+}
+
+// body returns the lines of a member after the header and the line number of the first one,
+// line numbers are 1-based and count the header of the member carrying it
+func (h inputHeader) body(file inputMember) (*io.SectionReader, int64) {
+	var offset int64
+	if file.name == h.member {
+		offset = h.next
+	}
+	size := int64(len(file.data))
+	return io.NewSectionReader(bytes.NewReader(file.data), offset, size-offset), h.firstLine(file)
+}
+
+// repeated reports whether line is the header line repeated at the start of a later member
+func (h inputHeader) repeated(line string) bool {
+	return h.member != "" && line == h.line
+}
+
+// firstLine returns the line number of the first line after the header in a member
+func (h inputHeader) firstLine(file inputMember) int64 {
+	if file.name == h.member {
+		return 2
+	}
+	return 1
+}
+
+// readInput sends every line of the input members to the parser stage, the header is
+// read from the first non-empty member as the members come
+func readInput(ctx context.Context, members inputMembers, pipelineCfg lib.PipelineConfig, out chan<- parserRow, totalRow *atomic.Int64, progress *lib.Progress, rowsRead prometheus.Counter) error {
+	var header inputHeader
+	var index int
+	return members.each(func(file inputMember) error {
+		file.index = index
+		index++
+		if header.member == "" {
+			var err error
+			if header, err = readHeader(&file); err != nil {
+				return err
+			}
+			if header.member != "" {
+				if err := header.checkRequired(); err != nil {
+					return err
+				}
+				totalRow.Add(1)
+			}
+		}
+		return readMember(ctx, header, file, pipelineCfg, out, totalRow, progress, rowsRead)
+	})
+}
+
+// readMember splits a member into lines for the parser stage, its span lasts until the
+// parsers have taken every line so a slow parser shows up on it
+func readMember(ctx context.Context, header inputHeader, file inputMember, pipelineCfg lib.PipelineConfig, out chan<- parserRow, totalRow *atomic.Int64, progress *lib.Progress, rowsRead prometheus.Counter) (err error) {
+	ctx, span := lib.StartSpan(ctx, "parse member",
+		attribute.String("member", file.name),
+		attribute.Int("bytes", len(file.data)),
+	)
+	var memberRows atomic.Int64
+	defer func() {
+		// the rows read since the last full interval
+		rowsRead.Add(float64(memberRows.Load() % progressInterval))
+		span.SetAttributes(attribute.Int64("rows", memberRows.Load()))
+		lib.EndSpan(span, err)
+	}()
+
+	progress.SetMember(file.name)
+	// the header and line endings aren't seen line by line, the member counts whole once read
+	memberStart := progress.BytesRead.Load()
+	defer func() {
+		if err == nil && file.stream == nil {
+			progress.BytesRead.Add(int64(len(file.data)) - (progress.BytesRead.Load() - memberStart))
+		}
+	}()
+
+	sendLine := func(lineNumber int64, line string) error {
+		progress.BytesRead.Add(int64(len(line)))
+		if header.repeated(line) {
+			return nil
+		}
+		if memberRows.Add(1)%progressInterval == 0 {
+			rowsRead.Add(progressInterval)
+		}
+		row := parserRow{
+			member:      file.name,
+			memberIndex: file.index,
+			lineNumber:  lineNumber,
+			line:        strings.Split(line, "\t"),
+			headerMap:   header.index,
+		}
+		select {
+		case out <- row:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	var chunks []lib.Chunk
+	if file.stream != nil {
+		firstLine := header.firstLine(file)
+		err = lib.ForEachStreamLine(ctx, file.stream, pipelineCfg.ChunkSize, pipelineCfg.ReaderWorkers, func(lineNumber int64, line string) error {
+			return sendLine(firstLine+lineNumber, line)
+		})
+	} else {
+		body, firstLine := header.body(file)
+		chunks, err = lib.SplitChunks(ctx, body, body.Size(), pipelineCfg.ChunkSize, pipelineCfg.ReaderWorkers)
+		if err != nil {
+			return errors.Wrapf(err, "failed splitting %s", file.name)
+		}
+		span.SetAttributes(attribute.Int("chunks", len(chunks)))
+		err = lib.ForEachLine(ctx, body, chunks, pipelineCfg.ReaderWorkers, func(lineNumber int64, line string) error {
+			return sendLine(firstLine+lineNumber, line)
+		})
+	}
+	totalRow.Add(memberRows.Load())
+	if err != nil {
+		return errors.Wrapf(err, "failed reading %s", file.name)
+	}
+	logger.FromContext(ctx).Debug("read member",
+		zap.String("member", file.name),
+		zap.Int("chunks", len(chunks)),
+		zap.Int64("rows", memberRows.Load()),
+	)
+	return nil
+}
+
+// loadStatus maps a load failure to a grpc status, cancellations keep their own code
+func loadStatus(err error) error {
+	var missing *missingColumnsError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, lib.ErrInvalidArchive), errors.Is(err, lib.ErrUploadTooLarge):
+		return uploadStatus(err)
+	case errors.Is(err, lib.ErrMapNotReady):
+		return lib.NewRPCError(codes.Unavailable, pb.ErrorReason_ERROR_REASON_MAP_NOT_READY, err).RetryAfter(lib.DefaultRetryDelay).Err()
+	case errors.As(err, &missing):
+		rpcErr := lib.NewRPCError(codes.FailedPrecondition, pb.ErrorReason_ERROR_REASON_SCHEMA_MISMATCH, err).With("member", missing.member)
+		for _, col := range missing.columns {
+			rpcErr.Precondition("SCHEMA", col, "column is missing from the input header")
+		}
+		return rpcErr.Err()
+	case lib.IsSchemaError(err):
+		return lib.NewRPCError(codes.FailedPrecondition, pb.ErrorReason_ERROR_REASON_SCHEMA_MISMATCH, err).
+			With("table", TableSchema.Name).
+			Precondition("SCHEMA", TableSchema.Name, "table doesn't match the go mapping").
+			Err()
+	case lib.IsRetryable(err):
+		return lib.NewRPCError(codes.Unavailable, pb.ErrorReason_ERROR_REASON_DATABASE_UNAVAILABLE, err).RetryAfter(lib.DefaultRetryDelay).Err()
+	default:
+		return lib.NewRPCError(codes.Internal, pb.ErrorReason_ERROR_REASON_INTERNAL, err).Err()
+	}
+}
+
+// partialLoadStatus maps a load failure after batches were committed, or with a commit
+// of unknown outcome, it carries no RetryInfo since sending the file again duplicates rows
+func partialLoadStatus(code codes.Code, err error, committed int64) error {
+	return lib.NewRPCError(code, pb.ErrorReason_ERROR_REASON_PARTIALLY_LOADED, err).
+		With("committed_rows", strconv.FormatInt(committed, 10)).
+		With("commit_unknown", strconv.FormatBool(lib.IsCommitUnknown(err))).
+		Err()
+}
+
+// retryLoad reports whether an input can be loaded again after err, an input with rows
+// committed or failing on its content is dead-lettered
+func retryLoad(err error) bool {
+	switch lib.ReasonOf(err) {
+	case pb.ErrorReason_ERROR_REASON_PARTIALLY_LOADED:
+		return false
+	case pb.ErrorReason_ERROR_REASON_LEASE_LOST:
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+func adaptiveStatsProto(stats lib.AdaptiveStats) *pb.AdaptiveStats {
+	res := &pb.AdaptiveStats{
+		BatchSize:        int64(stats.BatchSize),
+		MinBatchSize:     int64(stats.MinBatchSize),
+		MaxBatchSize:     int64(stats.MaxBatchSize),
+		BatchAdjustments: stats.BatchAdjustments,
+		ScaleEvents:      stats.ScaleEvents,
+	}
+	for _, stage := range stats.Stages {
+		res.Stages = append(res.Stages, &pb.StageStats{
+			Name:         stage.Name,
+			FinalWorkers: int64(stage.FinalWorkers),
+			PeakWorkers:  int64(stage.PeakWorkers),
+		})
+	}
+	return res
+}
+
+func chunkStatsProto(stats []lib.ChunkStats) []*pb.ChunkStats {
+	res := make([]*pb.ChunkStats, 0, len(stats))
+	for _, chunk := range stats {
+		res = append(res, &pb.ChunkStats{
+			Start:   timestamppb.New(chunk.Start),
+			End:     timestamppb.New(chunk.End),
+			Rows:    chunk.Rows,
+			Batches: chunk.Batches,
+		})
+	}
+	return res
+}
+
+func profileProto(profile *lib.TableProfile) *pb.Profile {
+	res := &pb.Profile{Rows: profile.Rows}
+	for _, col := range profile.Columns {
+		colRes := &pb.ColumnProfile{
+			Name:             col.Name,
+			Count:            col.Count,
+			Nulls:            col.Nulls,
+			DistinctEstimate: col.Distinct(),
+		}
+		if col.Count > 0 {
+			switch col.Kind {
+			case lib.NumericColumn:
+				colRes.Min = col.Min
+				colRes.Max = col.Max
+				colRes.Mean = col.Mean()
+			case lib.TimeColumn:
+				colRes.MinTime = timestamppb.New(col.MinTime())
+				colRes.MaxTime = timestamppb.New(col.MaxTime())
+			}
+		}
+		if col.Histogram != nil {
+			colRes.Histogram = &pb.Histogram{
+				UpperBounds: col.Histogram.UpperBounds,
+				Counts:      col.Histogram.Counts,
+			}
+		}
+		res.Columns = append(res.Columns, colRes)
+	}
+	return res
+}
+
+func dropReasonsProto(reasons map[dropReason]int64) []*pb.DropReason {
+	res := make([]*pb.DropReason, 0, len(reasons))
+	for reason, count := range reasons {
+		res = append(res, &pb.DropReason{
+			Field:  reason.field,
+			Reason: reason.reason,
+			Count:  count,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Field < res[j].Field
+	})
+	return res
+}
+
+func rejectedRowsProto(rows []rejectedRow) []*pb.RejectedRow {
+	res := make([]*pb.RejectedRow, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, &pb.RejectedRow{
+			Member:     row.member,
+			LineNumber: row.lineNumber,
+			Field:      row.field,
+			Reason:     row.reason,
+			RawFields:  row.raw,
+		})
+	}
+	return res
+}
@@ -0,0 +1,917 @@
+package nyc_trip
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"processor/lib"
+	pb "processor/protos"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const testHeader = "VendorID\ttpep_pickup_datetime\ttpep_dropoff_datetime\tpassenger_count\ttrip_distance\t" +
+	"PULocationID\tDOLocationID\tpayment_type\tfare_amount\textra\tmta_tax\ttip_amount\ttolls_amount\t" +
+	"improvement_surcharge\ttotal_amount\tcongestion_surcharge\tAirport_fee"
+
+// testInput returns a tab separated input of n trips spread over three days
+func testInput(n int) []inputMember {
+	var sb strings.Builder
+	sb.WriteString(testHeader + "\n")
+	pickup := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		start := pickup.Add(time.Duration(i%3)*24*time.Hour + time.Duration(i)*time.Second)
+		fmt.Fprintf(&sb, "%d\t%s\t%s\t%d\t%.2f\t%d\t%d\t%d\t12.5\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n",
+			1+i%2,
+			start.Format(time.DateTime),
+			start.Add(17*time.Minute).Format(time.DateTime),
+			1+i%4,
+			1.6+float64(i%10),
+			1+i%2,
+			2-i%2,
+			1+i%5,
+		)
+	}
+	return []inputMember{{name: "trips.csv", data: []byte(sb.String())}}
+}
+
+func newTestInputFile(t *testing.T, insertBatch lib.BatchInsertFunc) *InputFile {
+	t.Helper()
+	manhattan, queens := "Manhattan", "Queens"
+	midtown, jamaica := "Midtown Center", "Jamaica Bay"
+	yellow, boro := "Yellow Zone", "Boro Zone"
+	mapId, err := lib.NewStaticMap(map[int64]lib.TaxiZone{
+		1: {Borough: &manhattan, Zone: &midtown, Service_zone: &yellow},
+		2: {Borough: &queens, Zone: &jamaica, Service_zone: &boro},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lib.CloseMap(mapId) })
+
+	return &InputFile{
+		MapId: mapId,
+		PipelineConfig: lib.PipelineConfig{
+			ReaderWorkers:   4,
+			ChunkSize:       64 * 1024,
+			ParserWorkers:   3,
+			InserterWorkers: 3,
+			BatchSize:       500,
+			ChannelSize:     10,
+		},
+		PipelineLimits: lib.PipelineLimits{
+			ReaderWorkers:   8,
+			ChunkSize:       1 << 20,
+			ParserWorkers:   8,
+			InserterWorkers: 8,
+			BatchSize:       10000,
+			ChannelSize:     1000,
+		},
+		InserterLimiter: lib.NewWorkerLimiter(3),
+		Hypertable: lib.Hypertable{
+			Name:          TableSchema.Name,
+			TimeColumn:    "pickup_time",
+			ChunkInterval: 24 * time.Hour,
+		},
+		InlineMaxBytes: 1 << 20,
+		UploadConfig:   lib.UploadConfig{MaxBytes: 1 << 20, MaxConcurrent: 1, SpoolDir: t.TempDir()},
+		insertBatch:    insertBatch,
+		uploadSlots:    semaphore.NewWeighted(1),
+	}
+}
+
+// checkNoLeak fails if the goroutines started since baseline are still running
+func checkNoLeak(t *testing.T, baseline int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines leaked\n%s", runtime.NumGoroutine()-baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoadInsertsEveryRow(t *testing.T) {
+	var inserted atomic.Int64
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		inserted.Add(int64(tbl.Rows()))
+		return int64(tbl.Rows()), 0, nil
+	})
+
+	res, err := in.load(context.Background(), "trips.csv", "", memberList(testInput(5000)), in.PipelineConfig, loadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalRows != 5000 || res.ProcessedRows != 5000 || res.InsertedRows != 5000 {
+		t.Fatalf("expected 5000 rows, got total %d processed %d inserted %d",
+			res.TotalRows, res.ProcessedRows, res.InsertedRows)
+	}
+	if inserted.Load() != 5000 {
+		t.Fatalf("expected 5000 rows written, got %d", inserted.Load())
+	}
+	if len(res.Chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(res.Chunks))
+	}
+	for _, chunk := range res.Chunks {
+		if chunk.End.AsTime().Sub(chunk.Start.AsTime()) != 24*time.Hour {
+			t.Fatalf("chunk %v doesn't span the chunk interval", chunk)
+		}
+	}
+}
+
+func TestLoadSkipsRepeatedHeaders(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return int64(tbl.Rows()), 0, nil
+	})
+	in.PipelineConfig.ChunkSize = 512
+	body := func(n int) string {
+		return strings.SplitN(string(testInput(n)[0].data), "\n", 2)[1]
+	}
+	bad := "1\tnot a time\t2025-01-01 00:17:00\t1\t1.6\t1\t2\t1\t12.5\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n"
+	tests := []struct {
+		name    string
+		members func() []inputMember
+		line    int64
+	}{
+		{
+			// the first member carries the header, b repeats it and c doesn't
+			name: "archive members",
+			members: func() []inputMember {
+				return []inputMember{
+					{name: "a.csv", data: testInput(100)[0].data},
+					{name: "b.csv", data: []byte(testHeader + "\n" + body(50) + bad)},
+					{name: "c.csv", data: []byte(body(50))},
+				}
+			},
+			line: 52,
+		},
+		{
+			// concatenated gzip members of a streamed upload each start with the header
+			name: "gzip members",
+			members: func() []inputMember {
+				var buf bytes.Buffer
+				for _, member := range []string{string(testInput(100)[0].data), testHeader + "\n" + body(50) + bad, body(50)} {
+					gz := gzip.NewWriter(&buf)
+					gz.Write([]byte(member))
+					gz.Close()
+				}
+				gz, err := gzip.NewReader(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return []inputMember{{name: "trips.csv", stream: gz}}
+			},
+			line: 153,
+		},
+	}
+	for _, tt := range tests {
+		res, err := in.load(context.Background(), "trips.tar.gz", "", memberList(tt.members()), in.PipelineConfig, loadOptions{sampleSize: 10})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if res.TotalRows != 201 || res.InsertedRows != 200 || res.DroppedRows != 1 {
+			t.Fatalf("%s: expected 201 rows with one dropped, got total %d inserted %d dropped %d",
+				tt.name, res.TotalRows, res.InsertedRows, res.DroppedRows)
+		}
+		if len(res.RejectedRows) != 1 || res.RejectedRows[0].LineNumber != tt.line {
+			t.Fatalf("%s: expected line %d rejected, got %v", tt.name, tt.line, res.RejectedRows)
+		}
+	}
+}
+
+func TestLoadReportsProgress(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return int64(tbl.Rows()), 0, nil
+	})
+	input := testInput(5000)
+	// an unknown zone drops the row
+	input[0].data = append(input[0].data, []byte("1\t2025-01-01 00:00:00\t2025-01-01 00:10:00\t1\t1\t999\t1\t1\t1\t1\t1\t1\t1\t1\t1\t1\t1\n")...)
+	progress := lib.NewProgress()
+	ctx := lib.ContextWithProgress(context.Background(), progress)
+
+	res, err := in.load(ctx, "trips.csv", "", memberList(input), in.PipelineConfig, loadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := progress.Snapshot()
+	if s.TotalBytes != int64(len(input[0].data)) || s.BytesRead != s.TotalBytes {
+		t.Fatalf("expected %d bytes read, got %d of %d", len(input[0].data), s.BytesRead, s.TotalBytes)
+	}
+	if s.RowsParsed != res.ProcessedRows || s.RowsDropped != res.DroppedRows || s.RowsInserted != res.InsertedRows {
+		t.Fatalf("progress %+v doesn't match the response %+v", s, res)
+	}
+	if s.RowsDropped != 1 || s.Member != "trips.csv" || s.RemainingSeconds != 0 {
+		t.Fatalf("unexpected final progress %+v", s)
+	}
+}
+
+func TestLoadStopsOnInsertFailure(t *testing.T) {
+	var calls atomic.Int64
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		if calls.Add(1) == 3 {
+			return 0, 0, errors.New("connection reset by peer")
+		}
+		return int64(tbl.Rows()), 0, nil
+	})
+	baseline := runtime.NumGoroutine()
+
+	_, err := in.load(context.Background(), "trips.csv", "", memberList(testInput(50000)), in.PipelineConfig, loadOptions{})
+	// two batches are already committed, the file can't be sent again
+	if status.Code(err) != codes.Aborted || lib.ReasonOf(err) != pb.ErrorReason_ERROR_REASON_PARTIALLY_LOADED {
+		t.Fatalf("expected a partial load, got %v", err)
+	}
+	for _, detail := range status.Convert(err).Details() {
+		if _, ok := detail.(*errdetails.RetryInfo); ok {
+			t.Fatal("a partial load must not be retried")
+		}
+	}
+	if !strings.Contains(err.Error(), "connection reset by peer") {
+		t.Fatalf("expected the insert failure to be reported first, got %v", err)
+	}
+	checkNoLeak(t, baseline)
+}
+
+func TestLoadRecoversWorkerPanic(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		panic("boom")
+	})
+	baseline := runtime.NumGoroutine()
+
+	_, err := in.load(context.Background(), "trips.csv", "", memberList(testInput(5000)), in.PipelineConfig, loadOptions{})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected internal, got %v", err)
+	}
+	checkNoLeak(t, baseline)
+}
+
+func TestLoadStopsOnCancellation(t *testing.T) {
+	tests := []struct {
+		name string
+		code codes.Code
+		ctx  func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name: "client cancel",
+			code: codes.Canceled,
+			ctx:  func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+		},
+		{
+			name: "deadline",
+			code: codes.DeadlineExceeded,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 100*time.Millisecond)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			started := make(chan struct{}, 1)
+			// every batch hangs like a stuck connection until the load is cancelled
+			in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+				select {
+				case started <- struct{}{}:
+				default:
+				}
+				<-ctx.Done()
+				return 0, 0, ctx.Err()
+			})
+			baseline := runtime.NumGoroutine()
+
+			go func() {
+				select {
+				case <-started:
+					if tt.code == codes.Canceled {
+						cancel()
+					}
+				case <-ctx.Done():
+				}
+			}()
+			start := time.Now()
+			_, err := in.load(ctx, "trips.csv", "", memberList(testInput(50000)), in.PipelineConfig, loadOptions{})
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected %v, got %v", tt.code, err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("load took %v to stop", elapsed)
+			}
+			checkNoLeak(t, baseline)
+		})
+	}
+}
+
+func TestLoadFailsWithoutMap(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return int64(tbl.Rows()), 0, nil
+	})
+	in.MapId = &lib.MapId{}
+	baseline := runtime.NumGoroutine()
+
+	_, err := in.load(context.Background(), "trips.csv", "", memberList(testInput(50000)), in.PipelineConfig, loadOptions{})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected an internal error, got %v", err)
+	}
+	checkNoLeak(t, baseline)
+}
+
+func TestLoadErrorReasons(t *testing.T) {
+	insertFails := func(err error) lib.BatchInsertFunc {
+		return func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) { return 0, 0, err }
+	}
+	noZone := []inputMember{{
+		name: "trips.csv",
+		data: []byte(strings.Replace(testHeader, "PULocationID", "pickup_zone", 1) + "\n"),
+	}}
+	tests := []struct {
+		name   string
+		insert lib.BatchInsertFunc
+		input  []inputMember
+		code   codes.Code
+		reason pb.ErrorReason
+		retry  bool
+	}{
+		{"connection lost", insertFails(&pgconn.PgError{Code: "08006"}), testInput(100),
+			codes.Unavailable, pb.ErrorReason_ERROR_REASON_DATABASE_UNAVAILABLE, true},
+		{"undefined column", insertFails(&pgconn.PgError{Code: "42703"}), testInput(100),
+			codes.FailedPrecondition, pb.ErrorReason_ERROR_REASON_SCHEMA_MISMATCH, false},
+		{"changed column type", insertFails(&pgconn.PgError{Code: "22P03"}), testInput(100),
+			codes.FailedPrecondition, pb.ErrorReason_ERROR_REASON_SCHEMA_MISMATCH, false},
+		{"missing column", insertFails(nil), noZone,
+			codes.FailedPrecondition, pb.ErrorReason_ERROR_REASON_SCHEMA_MISMATCH, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := newTestInputFile(t, tt.insert)
+			_, err := in.load(context.Background(), "trips.csv", "", memberList(tt.input), in.PipelineConfig, loadOptions{})
+			if status.Code(err) != tt.code || lib.ReasonOf(err) != tt.reason {
+				t.Fatalf("expected %v %v, got %v", tt.code, tt.reason, err)
+			}
+			var retry bool
+			for _, detail := range status.Convert(err).Details() {
+				_, ok := detail.(*errdetails.RetryInfo)
+				retry = retry || ok
+			}
+			if retry != tt.retry {
+				t.Fatalf("expected retry info %v, got %v", tt.retry, status.Convert(err).Details())
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return int64(tbl.Rows()), 0, nil
+	})
+
+	res, err := in.load(context.Background(), "trips.csv", "", memberList(testInput(5000)), in.PipelineConfig, loadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := res.Profile
+	if profile.Rows != 5000 {
+		t.Fatalf("expected 5000 profiled rows, got %d", profile.Rows)
+	}
+	columns := make(map[string]*pb.ColumnProfile)
+	for _, col := range profile.Columns {
+		columns[col.Name] = col
+	}
+
+	if vendor := columns["vendor"]; vendor.DistinctEstimate != 2 || vendor.Nulls != 0 {
+		t.Fatalf("expected 2 distinct vendors without nulls, got %v", vendor)
+	}
+	if distance := columns["trip_distance"]; distance.Min != 1.6 || distance.Max != 10.6 || math.Abs(distance.Mean-6.1) > 1e-9 {
+		t.Fatalf("unexpected trip_distance bounds %v", distance)
+	}
+	// every trip lasts 17 minutes, the 900-1200 seconds bucket
+	duration := columns["trip_duration"]
+	if duration.Histogram.Counts[4] != 5000 {
+		t.Fatalf("unexpected trip_duration histogram %v", duration.Histogram)
+	}
+	if !res.MinTime.AsTime().Equal(columns["pickup_time"].MinTime.AsTime()) ||
+		!res.MaxTime.AsTime().Equal(columns["dropoff_time"].MaxTime.AsTime()) {
+		t.Fatalf("min/max time %v %v don't match the profile", res.MinTime, res.MaxTime)
+	}
+}
+
+func TestLoadProfileIndependentOfWorkers(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return int64(tbl.Rows()), 0, nil
+	})
+
+	var profiles []*pb.Profile
+	for _, workers := range []int{1, 4} {
+		cfg := in.PipelineConfig
+		cfg.ParserWorkers = workers
+		res, err := in.load(context.Background(), "trips.csv", "", memberList(testInput(5000)), cfg, loadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		profiles = append(profiles, res.Profile)
+	}
+	// float sums depend on the merge order, compare the means loosely
+	for i, col := range profiles[0].Columns {
+		other := profiles[1].Columns[i]
+		if math.Abs(col.Mean-other.Mean) > 1e-9 {
+			t.Fatalf("column %s mean differs between worker counts: %v vs %v", col.Name, col.Mean, other.Mean)
+		}
+		col.Mean, other.Mean = 0, 0
+	}
+	if !proto.Equal(profiles[0], profiles[1]) {
+		t.Fatalf("profile depends on the parser workers\n%v\n%v", profiles[0], profiles[1])
+	}
+}
+
+func TestValidateFileInline(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		t.Error("validate must not write to the database")
+		return 0, 0, nil
+	})
+	input := testInput(100)[0].data
+	input = append(input, "1\tnot a time\t2025-01-01 00:17:00\t1\t1.6\t1\t2\t1\t12.5\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n"...)
+	input = append(input, "1\t2025-01-01 00:00:00\t2025-01-01 00:17:00\t1\t1.6\t99\t2\t1\t12.5\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n"...)
+	input = append(input, "1\t2025-01-01 00:00:00\n"...)
+
+	res, err := in.ValidateFile(context.Background(), &pb.ValidateFileRequest{
+		Source:     &pb.ValidateFileRequest_InlineData{InlineData: input},
+		FileName:   "sample.csv",
+		SampleSize: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalRows != 103 || res.ProcessedRows != 100 || res.DroppedRows != 3 || res.InsertedRows != 0 {
+		t.Fatalf("unexpected stats total %d processed %d dropped %d inserted %d",
+			res.TotalRows, res.ProcessedRows, res.DroppedRows, res.InsertedRows)
+	}
+	reasons := make(map[string]int64)
+	for _, reason := range res.DropReasons {
+		reasons[reason.Field+"/"+reason.Reason] = reason.Count
+	}
+	if reasons["tpep_pickup_datetime/invalid_value"] != 1 ||
+		reasons["PULocationID/unknown_zone"] != 1 ||
+		reasons["tpep_dropoff_datetime/invalid_value"] != 1 {
+		t.Fatalf("unexpected drop reasons %v", res.DropReasons)
+	}
+	if len(res.RejectedRows) != 2 || res.RejectedRows[0].LineNumber != 102 || res.RejectedRows[1].LineNumber != 103 {
+		t.Fatalf("expected the first two rejected rows, got %v", res.RejectedRows)
+	}
+	if res.RejectedRows[0].RawFields[1] != "not a time" {
+		t.Fatalf("expected the raw fields of the rejected row, got %v", res.RejectedRows[0].RawFields)
+	}
+}
+
+func TestLoadCountsRowMetricsAsRowsFlow(t *testing.T) {
+	read := lib.RowsRead.WithLabelValues(TableSchema.Name)
+	processed := lib.RowsProcessed.WithLabelValues(TableSchema.Name)
+	dropped := lib.RowsDropped.WithLabelValues(TableSchema.Name, "PULocationID", dropUnknownZone)
+	counts := func() [3]float64 {
+		return [3]float64{testutil.ToFloat64(read), testutil.ToFloat64(processed), testutil.ToFloat64(dropped)}
+	}
+	input := testInput(3000)
+	input[0].data = append(input[0].data, "1\t2025-01-01 00:00:00\t2025-01-01 00:17:00\t1\t1.6\t99\t2\t1\t12.5\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n"...)
+
+	// a dry run doesn't count
+	in := newTestInputFile(t, nil)
+	before := counts()
+	if _, err := in.load(context.Background(), "trips.csv", "", memberList(input), in.PipelineConfig, loadOptions{dryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if counts() != before {
+		t.Fatalf("expected a dry run not to count, went from %v to %v", before, counts())
+	}
+
+	// the rows of a failed load are counted as far as they got
+	in = newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return 0, 0, &pgconn.PgError{Code: "23505"}
+	})
+	before = counts()
+	if _, err := in.load(context.Background(), "trips.csv", "", memberList(input), in.PipelineConfig, loadOptions{}); err == nil {
+		t.Fatal("expected the load to fail")
+	}
+	after := counts()
+	if after[0] <= before[0] || after[1] <= before[1] || after[0]-before[0] > 3001 {
+		t.Fatalf("expected the rows read before the failure to be counted, went from %v to %v", before, after)
+	}
+
+	in = newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return int64(tbl.Rows()), 0, nil
+	})
+	before = counts()
+	if _, err := in.load(context.Background(), "trips.csv", "", memberList(input), in.PipelineConfig, loadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if after := counts(); after != [3]float64{before[0] + 3001, before[1] + 3000, before[2] + 1} {
+		t.Fatalf("expected 3001 rows read, 3000 processed and 1 dropped, went from %v to %v", before, after)
+	}
+}
+
+func TestPipelineConfigOverrides(t *testing.T) {
+	in := newTestInputFile(t, nil)
+	cfg, err := in.pipelineConfig(&pb.PipelineOptions{ParserWorkers: 8, ChannelSize: 1000, ChunkSize: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ParserWorkers != 8 || cfg.ChannelSize != 1000 || cfg.ChunkSize != 1<<20 || cfg.BatchSize != in.PipelineConfig.BatchSize {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	for name, opts := range map[string]*pb.PipelineOptions{
+		"channel size":     {ChannelSize: math.MaxInt32},
+		"inserter workers": {InserterWorkers: 9},
+		"reader workers":   {ReaderWorkers: 1000},
+		"chunk size":       {ChunkSize: 1 << 30},
+		"batch size":       {BatchSize: -1},
+	} {
+		if _, err := in.pipelineConfig(opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidateFileTakesNoInserterSlot(t *testing.T) {
+	in := newTestInputFile(t, nil)
+	// the loads hold every inserter slot
+	_, release, err := in.InserterLimiter.Acquire(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := in.ValidateFile(ctx, &pb.ValidateFileRequest{
+		Source:   &pb.ValidateFileRequest_InlineData{InlineData: testInput(1000)[0].data},
+		FileName: "sample.csv",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ProcessedRows != 1000 {
+		t.Fatalf("expected 1000 processed rows, got %d", res.ProcessedRows)
+	}
+}
+
+func TestValidateFileRejectsBadRequests(t *testing.T) {
+	in := newTestInputFile(t, nil)
+	tests := []struct {
+		name string
+		req  *pb.ValidateFileRequest
+	}{
+		{"no source", &pb.ValidateFileRequest{}},
+		{"no file name", &pb.ValidateFileRequest{Source: &pb.ValidateFileRequest_InlineData{InlineData: []byte("a")}}},
+		{"too large", &pb.ValidateFileRequest{
+			Source:   &pb.ValidateFileRequest_InlineData{InlineData: make([]byte, 2<<20)},
+			FileName: "sample.csv",
+		}},
+		{"unsupported type", &pb.ValidateFileRequest{
+			Source:   &pb.ValidateFileRequest_InlineData{InlineData: []byte("a")},
+			FileName: "sample.parquet",
+		}},
+		{"sample too large", &pb.ValidateFileRequest{SampleSize: maxRejectedSample + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := in.ValidateFile(context.Background(), tt.req)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("expected invalid argument, got %v", err)
+			}
+			if tt.name == "unsupported type" && lib.ReasonOf(err) != pb.ErrorReason_ERROR_REASON_UNSUPPORTED_FILE_TYPE {
+				t.Fatalf("expected an unsupported file type, got %v", err)
+			}
+		})
+	}
+}
+
+// uploadStream plays the client side of UploadAndProcess from a list of messages
+type uploadStream struct {
+	grpc.ServerStream
+	msgs []*pb.UploadRequest
+	res  *pb.ProcessFileResponse
+	// beforeEOF runs once every message was received
+	beforeEOF func()
+}
+
+func (s *uploadStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *uploadStream) Recv() (*pb.UploadRequest, error) {
+	if len(s.msgs) == 0 {
+		if s.beforeEOF != nil {
+			s.beforeEOF()
+			s.beforeEOF = nil
+		}
+		return nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func (s *uploadStream) SendAndClose(res *pb.ProcessFileResponse) error {
+	s.res = res
+	return nil
+}
+
+// newUploadStream sends data in chunks of chunkSize after meta
+func newUploadStream(meta *pb.UploadMetadata, data []byte, chunkSize int) *uploadStream {
+	s := &uploadStream{msgs: []*pb.UploadRequest{{Payload: &pb.UploadRequest_Metadata{Metadata: meta}}}}
+	for len(data) > 0 {
+		n := min(chunkSize, len(data))
+		s.msgs = append(s.msgs, &pb.UploadRequest{Payload: &pb.UploadRequest_Chunk{Chunk: data[:n]}})
+		data = data[n:]
+	}
+	return s
+}
+
+func TestUploadAndProcessGzip(t *testing.T) {
+	var inserted atomic.Int64
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		inserted.Add(int64(tbl.Rows()))
+		return int64(tbl.Rows()), 0, nil
+	})
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(testInput(2000)[0].data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	meta := &pb.UploadMetadata{
+		Dataset:     TableSchema.Name,
+		FileName:    "trips.csv",
+		Compression: pb.Compression_COMPRESSION_GZIP,
+		Sha256:      hex.EncodeToString(sum[:]),
+		Size:        int64(buf.Len()),
+	}
+
+	stream := newUploadStream(meta, buf.Bytes(), 1000)
+	if err := in.UploadAndProcess(stream); err != nil {
+		t.Fatal(err)
+	}
+	if stream.res.TotalRows != 2000 || stream.res.InsertedRows != 2000 || inserted.Load() != 2000 {
+		t.Fatalf("expected 2000 rows, got total %d inserted %d written %d",
+			stream.res.TotalRows, stream.res.InsertedRows, inserted.Load())
+	}
+
+	// the checksum is checked before any row is loaded
+	inserted.Store(0)
+	small := testInput(100)[0].data
+	meta = &pb.UploadMetadata{Dataset: TableSchema.Name, FileName: "trips.txt", Sha256: meta.Sha256}
+	stream = newUploadStream(meta, small, 1000)
+	if err := in.UploadAndProcess(stream); status.Code(err) != codes.DataLoss || lib.ReasonOf(err) != pb.ErrorReason_ERROR_REASON_CHECKSUM_MISMATCH {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+	if inserted.Load() != 0 {
+		t.Fatalf("expected no rows written, got %d", inserted.Load())
+	}
+}
+
+func TestUploadAndProcessLoadsAfterChecksum(t *testing.T) {
+	var inserted atomic.Int64
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		inserted.Add(int64(tbl.Rows()))
+		return int64(tbl.Rows()), 0, nil
+	})
+	data := testInput(8000)[0].data
+	meta := &pb.UploadMetadata{Dataset: TableSchema.Name, FileName: "trips.csv", Sha256: strings.Repeat("0", 64)}
+	stream := newUploadStream(meta, data, 1000)
+	// nothing is written while the upload is received
+	var early int64
+	stream.beforeEOF = func() { early = inserted.Load() }
+	err := in.UploadAndProcess(stream)
+	if status.Code(err) != codes.DataLoss || lib.ReasonOf(err) != pb.ErrorReason_ERROR_REASON_CHECKSUM_MISMATCH {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+	if early != 0 || inserted.Load() != 0 {
+		t.Fatalf("expected no rows written, got %d before the end and %d after", early, inserted.Load())
+	}
+
+	stream = newUploadStream(uploadMeta("trips.csv", data), data, 1000)
+	stream.beforeEOF = func() { early = inserted.Load() }
+	if err := in.UploadAndProcess(stream); err != nil {
+		t.Fatal(err)
+	}
+	if early != 0 || inserted.Load() != 8000 {
+		t.Fatalf("expected 8000 rows written after the upload, got %d before the end and %d after", early, inserted.Load())
+	}
+}
+
+// newTarGz archives the members in order
+func newTarGz(t *testing.T, members []inputMember) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, member := range members {
+		if err := tw.WriteHeader(&tar.Header{Name: member.name, Mode: 0o644, Size: int64(len(member.data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(member.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func uploadMeta(fileName string, data []byte) *pb.UploadMetadata {
+	sum := sha256.Sum256(data)
+	return &pb.UploadMetadata{Dataset: TableSchema.Name, FileName: fileName, Sha256: hex.EncodeToString(sum[:])}
+}
+
+func TestUploadAndProcessArchive(t *testing.T) {
+	var inserted atomic.Int64
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		inserted.Add(int64(tbl.Rows()))
+		return int64(tbl.Rows()), 0, nil
+	})
+	data := testInput(1000)[0].data
+	// the second member repeats the header, the members are read in archive order
+	archive := newTarGz(t, []inputMember{{name: "part-2.csv", data: data}, {name: "part-1.csv", data: data}})
+	stream := newUploadStream(uploadMeta("trips.tar.gz", archive), archive, 1000)
+	if err := in.UploadAndProcess(stream); err != nil {
+		t.Fatal(err)
+	}
+	if stream.res.TotalRows != 2000 || inserted.Load() != 2000 {
+		t.Fatalf("expected 2000 rows, got total %d written %d", stream.res.TotalRows, inserted.Load())
+	}
+
+	// a truncated archive still matches its checksum but isn't valid
+	truncated := archive[:len(archive)/2]
+	if err := in.UploadAndProcess(newUploadStream(uploadMeta("trips.tar.gz", truncated), truncated, 1000)); lib.ReasonOf(err) != pb.ErrorReason_ERROR_REASON_INVALID_ARCHIVE {
+		t.Fatalf("expected an invalid archive, got %v", err)
+	}
+}
+
+// withTestRedis serves the inputs of in from a miniredis
+func withTestRedis(t *testing.T, in *InputFile) *miniredis.Miniredis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	in.Redis = &lib.RedisClient{Client: client}
+	in.LeaseConfig = lib.LeaseConfig{TTL: time.Minute, ExtendInterval: 20 * time.Second, MaxAttempts: 3}
+	return server
+}
+
+func TestArchiveLoadsAlikeFromRedisAndUpload(t *testing.T) {
+	in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+		return int64(tbl.Rows()), 0, nil
+	})
+	server := withTestRedis(t, in)
+	bad := "1\tnot a time\t2025-01-01 00:17:00\t1\t1.6\t1\t2\t1\t12.5\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n"
+	body := strings.SplitN(string(testInput(50)[0].data), "\n", 2)[1]
+	// the header member sorts last by name, a.csv has no header and would fail as the first member
+	archive := newTarGz(t, []inputMember{
+		{name: "z.csv", data: testInput(100)[0].data},
+		{name: "a.csv", data: []byte(body + bad)},
+	})
+
+	server.Set("trips.tar.gz", string(archive))
+	fromRedis, err := in.ProcessNYCTrip(context.Background(), &pb.InputFileRequest{InputFile: "trips.tar.gz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := newUploadStream(uploadMeta("trips.tar.gz", archive), archive, 1000)
+	if err := in.UploadAndProcess(stream); err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range []*pb.ProcessFileResponse{fromRedis, stream.res} {
+		if res.TotalRows != 151 || res.DroppedRows != 1 || len(res.RejectedRows) != 1 {
+			t.Fatalf("expected 151 rows with one dropped, got %v", res)
+		}
+	}
+	if fromRedis.InsertedRows != stream.res.InsertedRows ||
+		!proto.Equal(fromRedis.RejectedRows[0], stream.res.RejectedRows[0]) ||
+		!proto.Equal(fromRedis.DropReasons[0], stream.res.DropReasons[0]) ||
+		fromRedis.Profile.Rows != stream.res.Profile.Rows {
+		t.Fatalf("expected the same load from redis and upload\nredis  %v\nupload %v", fromRedis, stream.res)
+	}
+}
+
+func TestProcessNYCTripDeadLettersFailedLoads(t *testing.T) {
+	tests := []struct {
+		name string
+		// every insert call from failAt fails
+		failAt int64
+		fail   error
+		code   codes.Code
+		dead   bool
+	}{
+		// the batches committed before the failure can't be loaded twice
+		{"partial", 3, errors.New("connection reset by peer"), codes.Aborted, true},
+		{"unavailable", 1, &pgconn.PgError{Code: "57P03"}, codes.Unavailable, false},
+		{"permanent", 1, errors.New("boom"), codes.Internal, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			in := newTestInputFile(t, func(ctx context.Context, tbl *lib.TableInsert) (int64, int64, error) {
+				if calls.Add(1) >= tt.failAt {
+					return 0, 0, tt.fail
+				}
+				return int64(tbl.Rows()), 0, nil
+			})
+			server := withTestRedis(t, in)
+			server.Set("trips.csv", string(testInput(50000)[0].data))
+
+			_, err := in.ProcessNYCTrip(context.Background(), &pb.InputFileRequest{InputFile: "trips.csv"})
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected %v, got %v", tt.code, err)
+			}
+			if tt.dead != server.Exists("{trips.csv}:dead") || tt.dead == server.Exists("trips.csv") {
+				t.Fatalf("expected dead-lettered %v, got keys %v", tt.dead, server.Keys())
+			}
+		})
+	}
+}
+
+func TestUploadAndProcessRejectsBadUploads(t *testing.T) {
+	in := newTestInputFile(t, nil)
+	meta := func(fileName string) *pb.UploadMetadata {
+		return &pb.UploadMetadata{Dataset: TableSchema.Name, FileName: fileName}
+	}
+	// every byte is the same so it compresses far below the limit
+	var bomb bytes.Buffer
+	gz := gzip.NewWriter(&bomb)
+	if _, err := gz.Write(make([]byte, 2<<20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		stream *uploadStream
+		code   codes.Code
+	}{
+		{"empty", &uploadStream{}, codes.InvalidArgument},
+		{"chunk first", &uploadStream{msgs: []*pb.UploadRequest{{Payload: &pb.UploadRequest_Chunk{}}}}, codes.InvalidArgument},
+		{"unknown dataset", newUploadStream(&pb.UploadMetadata{Dataset: "other", FileName: "a.csv"}, nil, 1), codes.InvalidArgument},
+		{"unsupported type", newUploadStream(meta("a.parquet"), nil, 1), codes.InvalidArgument},
+		{"bad checksum", newUploadStream(&pb.UploadMetadata{Dataset: TableSchema.Name, FileName: "a.csv", Sha256: "abc"}, nil, 1), codes.InvalidArgument},
+		{"announced too large", newUploadStream(&pb.UploadMetadata{Dataset: TableSchema.Name, FileName: "a.csv", Size: 2 << 20}, nil, 1), codes.ResourceExhausted},
+		{"sent too large", newUploadStream(meta("a.csv"), make([]byte, 2<<20), 64<<10), codes.ResourceExhausted},
+		{"inflates too large", newUploadStream(&pb.UploadMetadata{
+			Dataset:     TableSchema.Name,
+			FileName:    "a.csv",
+			Compression: pb.Compression_COMPRESSION_GZIP,
+		}, bomb.Bytes(), 64<<10), codes.ResourceExhausted},
+		{"not gzip", newUploadStream(&pb.UploadMetadata{
+			Dataset:     TableSchema.Name,
+			FileName:    "a.csv",
+			Compression: pb.Compression_COMPRESSION_GZIP,
+		}, []byte("plain text"), 4), codes.InvalidArgument},
+		{"truncated", newUploadStream(&pb.UploadMetadata{Dataset: TableSchema.Name, FileName: "a.csv", Size: 10}, []byte("short"), 1), codes.DataLoss},
+		{"metadata twice", &uploadStream{msgs: []*pb.UploadRequest{
+			{Payload: &pb.UploadRequest_Metadata{Metadata: meta("a.csv")}},
+			{Payload: &pb.UploadRequest_Metadata{Metadata: meta("a.csv")}},
+		}}, codes.InvalidArgument},
+	}
+	baseline := runtime.NumGoroutine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := in.UploadAndProcess(tt.stream); status.Code(err) != tt.code {
+				t.Fatalf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+	checkNoLeak(t, baseline)
+	// every spool file was removed
+	if spooled, err := os.ReadDir(in.UploadConfig.SpoolDir); err != nil || len(spooled) != 0 {
+		t.Fatalf("expected an empty spool dir, got %v and %v", spooled, err)
+	}
+}
@@ -1,17 +1,20 @@
 package nyc_trip
 
 import (
+	"container/heap"
 	"context"
-	"reflect"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"processor/lib"
+	"processor/logger"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/dgraph-io/ristretto/v2"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 type nycTripParser struct {
@@ -20,49 +23,150 @@ type nycTripParser struct {
 	mapId          *lib.MapId
 	wg             sync.WaitGroup
 	ch             chan parserRow
-	droppedRow     atomic.Int64
-	processedRow   atomic.Int64
-	maxTime        *time.Time
-	minTime        *time.Time
-	err            error
+	quit           chan struct{}
+	sampleSize     int
+	progress       *lib.Progress
+	counters       lib.RowCounters
+	mu             sync.Mutex
+	stats          parserStats
+}
+
+// parserStats are kept per worker and merged into the parser when the worker exits
+type parserStats struct {
+	droppedRow   int64
+	processedRow int64
+	dropReasons  map[dropReason]int64
+	// rejected holds the first sampleSize dropped rows in input order
+	rejected   rejectedSample
+	sampleSize int
+	profile    *lib.TableProfile
+	// rows already added to the progress of the load, the drop reasons since are pending
+	reportedProcessed int64
+	reportedDropped   int64
+	pendingReasons    map[dropReason]int64
+}
+
+const (
+	dropInvalidValue = "invalid_value"
+	dropUnknownZone  = "unknown_zone"
+)
+
+type dropReason struct {
+	field  string
+	reason string
+}
+
+// rejectedBefore orders rejected rows by their position in the input
+func rejectedBefore(a, b rejectedRow) bool {
+	if a.memberIndex != b.memberIndex {
+		return a.memberIndex < b.memberIndex
+	}
+	return a.lineNumber < b.lineNumber
+}
+
+// rejectedSample is a heap with the latest row of the input on top, the row replaced
+// when an earlier one is dropped
+type rejectedSample []rejectedRow
+
+func (s rejectedSample) Len() int           { return len(s) }
+func (s rejectedSample) Less(i, j int) bool { return rejectedBefore(s[j], s[i]) }
+func (s rejectedSample) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s *rejectedSample) Push(x any)        { *s = append(*s, x.(rejectedRow)) }
+func (s *rejectedSample) Pop() any {
+	old := *s
+	row := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return row
+}
+
+type rejectedRow struct {
+	member      string
+	memberIndex int
+	lineNumber  int64
+	field       string
+	reason      string
+	raw         []string
 }
 
 type nycTripDbInsert struct {
-	ctx         context.Context
 	wg          sync.WaitGroup
 	ch          chan dbRow
-	dbConn      *pgxpool.Pool
+	quit        chan struct{}
+	insertBatch lib.BatchInsertFunc
 	insertedRow atomic.Int64
+	retries     atomic.Int64
+	sizer       *lib.BatchSizer
+	hypertable  lib.Hypertable
+	chunkStats  *lib.ChunkStatsRecorder
+	progress    *lib.Progress
 	tableName   string
 	column      []string
-	err         error
 }
 
 type parserRow struct {
-	line      []string
-	headerMap map[string]int
+	member string
+	// memberIndex is the position of the member in the input
+	memberIndex int
+	lineNumber  int64
+	line        []string
+	headerMap   map[string]int
 }
 
+// dbRow field order and db tags define the nyc_trip columns, see TableSchema.
+// encode has to append the fields in the same order.
 type dbRow struct {
-	Vendor               string
-	PickupTime           time.Time
-	DropoffTime          time.Time
-	PassengerCount       int64
-	TripDistance         float64
-	PuLocationRegion     string
-	PuLocationZone       string
-	DoLocationRegion     string
-	DoLocationZone       string
-	PaymentType          string
-	FareAmount           *float64
-	Extra                *float64
-	MtaTax               *float64
-	TipAmount            *float64
-	TollsAmount          *float64
-	ImprovementSurcharge *float64
-	TotalAmount          *float64
-	CongestionSurcharge  *float64
-	AirportFee           *float64
+	Vendor               string    `db:"vendor,character varying"`
+	PickupTime           time.Time `db:"pickup_time,timestamp without time zone"`
+	DropoffTime          time.Time `db:"dropoff_time,timestamp without time zone"`
+	PassengerCount       int64     `db:"passenger_count,integer"`
+	TripDistance         float64   `db:"trip_distance,double precision"`
+	PuLocationRegion     string    `db:"pu_location_region,character varying"`
+	PuLocationZone       string    `db:"pu_location_zone,character varying"`
+	DoLocationRegion     string    `db:"do_location_regin,character varying"`
+	DoLocationZone       string    `db:"do_location_zone,character varying"`
+	PaymentType          string    `db:"payment_type,character varying"`
+	FareAmount           *float64  `db:"fare_amount,double precision"`
+	Extra                *float64  `db:"extra,double precision"`
+	MtaTax               *float64  `db:"mta_tax,double precision"`
+	TipAmount            *float64  `db:"tip_amount,double precision"`
+	TollsAmount          *float64  `db:"tolls_amount,double precision"`
+	ImprovementSurcharge *float64  `db:"improvement_surcharge,double precision"`
+	TotalAmount          *float64  `db:"total_amount,double precision"`
+	CongestionSurcharge  *float64  `db:"congestion_surcharge,double precision"`
+	AirportFee           *float64  `db:"airport_fee,double precision"`
+}
+
+// TableSchema is the Go mapping of the nyc_trip table, validated against the database at startup
+var TableSchema = lib.TableSchemaOf("nyc_trip", dbRow{})
+
+// tripProfileColumns are the nyc_trip columns followed by the trip duration in seconds,
+// distance, fare and duration also get a histogram
+var tripProfileColumns = func() []lib.ProfileColumn {
+	columns := lib.ProfileColumnsOf(TableSchema)
+	for i := range columns {
+		switch columns[i].Name {
+		case "trip_distance":
+			columns[i].Histogram = []float64{0, 1, 2, 3, 5, 10, 20, 50}
+		case "fare_amount":
+			columns[i].Histogram = []float64{0, 5, 10, 15, 20, 30, 50, 100}
+		}
+	}
+	return append(columns, lib.ProfileColumn{
+		Name:      "trip_duration",
+		Kind:      lib.NumericColumn,
+		Histogram: []float64{0, 300, 600, 900, 1200, 1800, 3600, 7200},
+	})
+}()
+
+// maxOpenChunks caps the chunks an inserter buffers rows for, so inputs spanning
+// many chunks don't hold a full batch per chunk in memory
+const maxOpenChunks = 4
+
+// copyBufferPool recycles batch buffers between inserter workers and requests
+var copyBufferPool = sync.Pool{
+	New: func() any {
+		return lib.NewCopyBuffer(TableSchema)
+	},
 }
 
 var vendorOpts = map[int]string{
@@ -82,50 +186,41 @@ var paymentOpts = map[int]string{
 	6: "Voided trip",
 }
 
-func newNycTripParser(fileName string, remoteFilePath string, mapId *lib.MapId) *nycTripParser {
+func newNycTripParser(fileName string, remoteFilePath string, mapId *lib.MapId, channelSize int, sampleSize int) *nycTripParser {
 	return &nycTripParser{
 		fileName:       fileName,
 		remoteFilePath: remoteFilePath,
 		mapId:          mapId,
-		ch:             make(chan parserRow, 100),
-		droppedRow:     atomic.Int64{},
-		processedRow:   atomic.Int64{},
+		ch:             make(chan parserRow, channelSize),
+		quit:           make(chan struct{}),
+		sampleSize:     sampleSize,
+		progress:       lib.NewProgress(),
+		stats:          newParserStats(sampleSize),
 	}
 }
 
-func newNycTripDbInsert(ctx context.Context, dbConn *pgxpool.Pool) *nycTripDbInsert {
+func newNycTripDbInsert(insertBatch lib.BatchInsertFunc, sizer *lib.BatchSizer, hypertable lib.Hypertable, channelSize int) *nycTripDbInsert {
 	return &nycTripDbInsert{
-		ctx:         ctx,
-		ch:          make(chan dbRow, 100),
-		dbConn:      dbConn,
+		ch:          make(chan dbRow, channelSize),
+		quit:        make(chan struct{}),
+		insertBatch: insertBatch,
 		insertedRow: atomic.Int64{},
-		tableName:   "nyc_trip",
-		column: []string{
-			"vendor",
-			"pickup_time",
-			"dropoff_time",
-			"passenger_count",
-			"trip_distance",
-			"pu_location_region",
-			"pu_location_zone",
-			"do_location_regin",
-			"do_location_zone",
-			"payment_type",
-			"fare_amount",
-			"extra",
-			"mta_tax",
-			"tip_amount",
-			"tolls_amount",
-			"improvement_surcharge",
-			"total_amount",
-			"congestion_surcharge",
-			"airport_fee",
-		},
+		sizer:       sizer,
+		hypertable:  hypertable,
+		chunkStats:  lib.NewChunkStatsRecorder(hypertable),
+		progress:    lib.NewProgress(),
+		tableName:   TableSchema.Name,
+		column:      TableSchema.ColumnNames(),
 	}
 }
 
+// get returns the raw value of a column, empty if the header or the line lacks it
 func (row *parserRow) get(headerName string) string {
-	val := row.line[row.headerMap[headerName]]
+	idx, found := row.headerMap[headerName]
+	if !found || idx >= len(row.line) {
+		return ""
+	}
+	val := row.line[idx]
 	switch headerName {
 	case "vendorID":
 		id, err := strconv.Atoi(val)
@@ -143,22 +238,51 @@ func (row *parserRow) get(headerName string) string {
 	return val
 }
 
-func (r *dbRow) toSlice() []any {
-	val := reflect.ValueOf(*r)
+func (r *dbRow) encode(buf *lib.CopyBuffer) {
+	buf.AppendString(r.Vendor)
+	buf.AppendTime(r.PickupTime)
+	buf.AppendTime(r.DropoffTime)
+	buf.AppendInt(r.PassengerCount)
+	buf.AppendFloat(r.TripDistance)
+	buf.AppendString(r.PuLocationRegion)
+	buf.AppendString(r.PuLocationZone)
+	buf.AppendString(r.DoLocationRegion)
+	buf.AppendString(r.DoLocationZone)
+	buf.AppendString(r.PaymentType)
+	buf.AppendFloatPtr(r.FareAmount)
+	buf.AppendFloatPtr(r.Extra)
+	buf.AppendFloatPtr(r.MtaTax)
+	buf.AppendFloatPtr(r.TipAmount)
+	buf.AppendFloatPtr(r.TollsAmount)
+	buf.AppendFloatPtr(r.ImprovementSurcharge)
+	buf.AppendFloatPtr(r.TotalAmount)
+	buf.AppendFloatPtr(r.CongestionSurcharge)
+	buf.AppendFloatPtr(r.AirportFee)
+}
 
-	res := make([]any, val.NumField())
-	for i := range val.NumField() {
-		if val.Field(i).Kind() == reflect.Ptr {
-			if val.Field(i).IsNil() {
-				res[i] = nil
-			} else {
-				res[i] = val.Field(i).Elem().Interface()
-			}
-		} else {
-			res[i] = val.Field(i).Interface()
-		}
-	}
-	return res
+// profile mirrors encode and adds the derived trip duration last, see tripProfileColumns
+func (r *dbRow) profile(p *lib.TableProfile) {
+	p.StartRow()
+	p.AddString(r.Vendor)
+	p.AddTime(r.PickupTime)
+	p.AddTime(r.DropoffTime)
+	p.AddInt(r.PassengerCount)
+	p.AddFloat(r.TripDistance)
+	p.AddString(r.PuLocationRegion)
+	p.AddString(r.PuLocationZone)
+	p.AddString(r.DoLocationRegion)
+	p.AddString(r.DoLocationZone)
+	p.AddString(r.PaymentType)
+	p.AddFloatPtr(r.FareAmount)
+	p.AddFloatPtr(r.Extra)
+	p.AddFloatPtr(r.MtaTax)
+	p.AddFloatPtr(r.TipAmount)
+	p.AddFloatPtr(r.TollsAmount)
+	p.AddFloatPtr(r.ImprovementSurcharge)
+	p.AddFloatPtr(r.TotalAmount)
+	p.AddFloatPtr(r.CongestionSurcharge)
+	p.AddFloatPtr(r.AirportFee)
+	p.AddFloat(r.DropoffTime.Sub(r.PickupTime).Seconds())
 }
 
 func parseToFloat64Ptr(s string) *float64 {
@@ -169,190 +293,304 @@ func parseToFloat64Ptr(s string) *float64 {
 	return &f
 }
 
-// Drain the channel to prevent deadlocks if there was an error on the process
-func (p *nycTripParser) drainCh() {
-	for range p.ch {
-	}
+// done closes the input of the stage and waits for its workers,
+// workers also exit when the load context is cancelled
+func (p *nycTripParser) done() {
+	close(p.ch)
+	p.wg.Wait()
+}
+
+func (d *nycTripDbInsert) done() {
+	close(d.ch)
+	d.wg.Wait()
 }
 
-func (d *nycTripDbInsert) drainCh() {
-	for range d.ch {
+// stopWorker asks an idle worker to exit, the worker flushes its buffer first
+func (p *nycTripParser) stopWorker() bool {
+	select {
+	case p.quit <- struct{}{}:
+		return true
+	default:
+		return false
 	}
 }
 
-func (p *nycTripParser) done() error {
-	close(p.ch)
-	p.wg.Wait()
-	return p.err
+func (d *nycTripDbInsert) stopWorker() bool {
+	select {
+	case d.quit <- struct{}{}:
+		return true
+	default:
+		return false
+	}
 }
 
-func (d *nycTripDbInsert) done() error {
-	close(d.ch)
-	d.wg.Wait()
-	return d.err
+func newParserStats(sampleSize int) parserStats {
+	return parserStats{
+		dropReasons: make(map[dropReason]int64),
+		sampleSize:  sampleSize,
+		profile:     lib.NewTableProfile(tripProfileColumns),
+	}
 }
 
-func (p *nycTripParser) processWorker(dbInsertChan chan<- dbRow) {
-	defer p.wg.Done()
-	const timeLayout string = "2006-01-02 15:04:05"
-	var passengerCount int64
+// progressInterval is the number of rows a parser worker handles between progress updates
+const progressInterval = 1024
 
-	mapTaxiZone, err := p.mapId.GetTaxiZoneMap()
-	if err != nil {
-		p.err = errors.Wrap(err, "failed getting taxi_zone map")
-		p.drainCh()
-		return
+// report adds the rows handled since the last report to progress and the row metrics
+func (s *parserStats) report(progress *lib.Progress, counters lib.RowCounters) {
+	progress.RowsParsed.Add(s.processedRow - s.reportedProcessed)
+	progress.RowsDropped.Add(s.droppedRow - s.reportedDropped)
+	counters.Processed.Add(float64(s.processedRow - s.reportedProcessed))
+	for reason, count := range s.pendingReasons {
+		counters.Dropped.WithLabelValues(reason.field, reason.reason).Add(float64(count))
 	}
-	for row := range p.ch {
-		vendor := row.get("VendorID")
-		pickupTimeStr := row.get("tpep_pickup_datetime")
-		pickupTime, err := time.Parse(timeLayout, pickupTimeStr)
-		if err != nil {
-			p.droppedRow.Add(1)
-			continue
-		}
+	clear(s.pendingReasons)
+	s.reportedProcessed, s.reportedDropped = s.processedRow, s.droppedRow
+}
 
-		dropoffTimeStr := row.get("tpep_dropoff_datetime")
-		dropoffTime, err := time.Parse(timeLayout, dropoffTimeStr)
-		if err != nil {
-			p.droppedRow.Add(1)
-			continue
-		}
+func (p *nycTripParser) mergeStats(stats *parserStats) {
+	stats.report(p.progress, p.counters)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.droppedRow += stats.droppedRow
+	p.stats.processedRow += stats.processedRow
+	for reason, count := range stats.dropReasons {
+		p.stats.dropReasons[reason] += count
+	}
+	p.stats.rejected = append(p.stats.rejected, stats.rejected...)
+	sort.Slice(p.stats.rejected, func(i, j int) bool {
+		return rejectedBefore(p.stats.rejected[i], p.stats.rejected[j])
+	})
+	p.stats.rejected = p.stats.rejected[:min(len(p.stats.rejected), p.sampleSize)]
+	p.stats.profile.Merge(stats.profile)
+}
 
-		passengerCountStr := row.get("passenger_count")
-		passengerCount, err = strconv.ParseInt(passengerCountStr, 10, 64)
-		if err != nil {
-			p.droppedRow.Add(1)
-			continue
-		}
+// dropRow counts a row that can't be loaded, the line number points back into the input
+func (s *parserStats) dropRow(row *parserRow, field, reason string) {
+	s.droppedRow++
+	s.dropReasons[dropReason{field: field, reason: reason}]++
+	if s.pendingReasons == nil {
+		s.pendingReasons = make(map[dropReason]int64)
+	}
+	s.pendingReasons[dropReason{field: field, reason: reason}]++
+	// workers see the lines out of order, each keeps its sampleSize earliest rows
+	// so the merged sample holds the earliest rows of the input
+	rejected := rejectedRow{
+		member:      row.member,
+		memberIndex: row.memberIndex,
+		lineNumber:  row.lineNumber,
+		field:       field,
+		reason:      reason,
+		raw:         row.line,
+	}
+	switch {
+	case len(s.rejected) < s.sampleSize:
+		heap.Push(&s.rejected, rejected)
+	case s.sampleSize > 0 && rejectedBefore(rejected, s.rejected[0]):
+		s.rejected[0] = rejected
+		heap.Fix(&s.rejected, 0)
+	}
+	logger.Debug("dropped row",
+		zap.String("member", row.member),
+		zap.Int64("line", row.lineNumber),
+		zap.String("field", field),
+		zap.String("reason", reason),
+	)
+}
 
-		tripDistanceStr := row.get("trip_distance")
-		tripDistance, err := strconv.ParseFloat(tripDistanceStr, 64)
-		if err != nil {
-			p.droppedRow.Add(1)
-			continue
-		}
+// fieldError is a source value that couldn't be parsed, fatal ones drop the row
+// and the others leave the column null
+type fieldError struct {
+	field   string
+	reason  string
+	message string
+	fatal   bool
+}
 
-		puLocationIdStr := row.get("PULocationID")
-		puLocationId, err := strconv.ParseInt(puLocationIdStr, 10, 64)
-		if err != nil {
-			p.droppedRow.Add(1)
-			continue
-		}
-		puLookup, found := mapTaxiZone.Get(puLocationId)
-		if !found {
-			p.droppedRow.Add(1)
-			continue
-		}
+// requiredColumns are the source columns parseTrip drops a row without
+var requiredColumns = []string{
+	"tpep_pickup_datetime", "tpep_dropoff_datetime", "passenger_count", "trip_distance",
+	"PULocationID", "DOLocationID",
+}
 
-		doLocationIdStr := row.get("DOLocationID")
-		doLocationId, err := strconv.ParseInt(doLocationIdStr, 10, 64)
+// parseTrip parses and enriches a source row. It stops at the first fatal error
+// unless all is set, then every field error is reported, previews use it.
+func parseTrip(row *parserRow, mapTaxiZone *ristretto.Cache[int64, lib.TaxiZone], all bool) (dbRow, []fieldError) {
+	const timeLayout string = "2006-01-02 15:04:05"
+	var trip dbRow
+	var errs []fieldError
+	// fail records a fatal error and tells whether parsing has to stop
+	fail := func(field, reason string, err error) bool {
+		errs = append(errs, fieldError{field: field, reason: reason, message: err.Error(), fatal: true})
+		return !all
+	}
+	lookupZone := func(field string) (lib.TaxiZone, bool) {
+		locationId, err := strconv.ParseInt(row.get(field), 10, 64)
 		if err != nil {
-			p.droppedRow.Add(1)
-			continue
+			return lib.TaxiZone{}, fail(field, dropInvalidValue, err)
 		}
-		doLookup, found := mapTaxiZone.Get(doLocationId)
+		zone, found := mapTaxiZone.Get(locationId)
 		if !found {
-			p.droppedRow.Add(1)
-			continue
+			return lib.TaxiZone{}, fail(field, dropUnknownZone, errors.Errorf("location %d isn't in taxi_zone_lookup", locationId))
 		}
+		return zone, false
+	}
+	nullableFloat := func(field string) *float64 {
+		val := row.get(field)
+		f := parseToFloat64Ptr(val)
+		if f == nil && val != "" && all {
+			errs = append(errs, fieldError{field: field, reason: dropInvalidValue, message: "not a number, loaded as null"})
+		}
+		return f
+	}
 
-		paymentType := row.get("payment_type")
-
-		fareAmountStr := row.get("fare_amount")
-		fareAmount := parseToFloat64Ptr(fareAmountStr)
-
-		extraStr := row.get("extra")
-		extra := parseToFloat64Ptr(extraStr)
-
-		mtaTaxStr := row.get("mta_tax")
-		mtaTax := parseToFloat64Ptr(mtaTaxStr)
-
-		tipAmountStr := row.get("tip_amount")
-		tipAmount := parseToFloat64Ptr(tipAmountStr)
-
-		tollsAmountStr := row.get("tolls_amount")
-		tollsAmount := parseToFloat64Ptr(tollsAmountStr)
-
-		improvementSurchargeStr := row.get("improvement_surcharge")
-		improvementSurcharge := parseToFloat64Ptr(improvementSurchargeStr)
+	var err error
+	trip.Vendor = row.get("VendorID")
+	if trip.PickupTime, err = time.Parse(timeLayout, row.get("tpep_pickup_datetime")); err != nil && fail("tpep_pickup_datetime", dropInvalidValue, err) {
+		return trip, errs
+	}
+	if trip.DropoffTime, err = time.Parse(timeLayout, row.get("tpep_dropoff_datetime")); err != nil && fail("tpep_dropoff_datetime", dropInvalidValue, err) {
+		return trip, errs
+	}
+	if trip.PassengerCount, err = strconv.ParseInt(row.get("passenger_count"), 10, 64); err != nil && fail("passenger_count", dropInvalidValue, err) {
+		return trip, errs
+	}
+	if trip.TripDistance, err = strconv.ParseFloat(row.get("trip_distance"), 64); err != nil && fail("trip_distance", dropInvalidValue, err) {
+		return trip, errs
+	}
+	puLookup, stop := lookupZone("PULocationID")
+	if stop {
+		return trip, errs
+	}
+	if puLookup.Borough != nil {
+		trip.PuLocationRegion, trip.PuLocationZone = *puLookup.Borough, *puLookup.Zone
+	}
+	doLookup, stop := lookupZone("DOLocationID")
+	if stop {
+		return trip, errs
+	}
+	if doLookup.Borough != nil {
+		trip.DoLocationRegion, trip.DoLocationZone = *doLookup.Borough, *doLookup.Zone
+	}
 
-		totalAmountStr := row.get("total_amount")
-		totalAmount := parseToFloat64Ptr(totalAmountStr)
+	trip.PaymentType = row.get("payment_type")
+	trip.FareAmount = nullableFloat("fare_amount")
+	trip.Extra = nullableFloat("extra")
+	trip.MtaTax = nullableFloat("mta_tax")
+	trip.TipAmount = nullableFloat("tip_amount")
+	trip.TollsAmount = nullableFloat("tolls_amount")
+	trip.ImprovementSurcharge = nullableFloat("improvement_surcharge")
+	trip.TotalAmount = nullableFloat("total_amount")
+	trip.CongestionSurcharge = nullableFloat("congestion_surcharge")
+	trip.AirportFee = nullableFloat("Airport_fee")
+	return trip, errs
+}
 
-		congestionSurchargeStr := row.get("congestion_surcharge")
-		congestionSurcharge := parseToFloat64Ptr(congestionSurchargeStr)
+func (p *nycTripParser) processWorker(ctx context.Context, dbInsertChan chan<- dbRow) error {
+	defer p.wg.Done()
 
-		airportFeeStr := row.get("Airport_fee")
-		airportFee := parseToFloat64Ptr(airportFeeStr)
+	mapTaxiZone, err := p.mapId.GetTaxiZoneMap()
+	if err != nil {
+		return errors.Wrap(err, "failed getting taxi_zone map")
+	}
+	stats := newParserStats(p.sampleSize)
+	defer p.mergeStats(&stats)
+	for {
+		var row parserRow
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.quit:
+			return nil
+		case r, ok := <-p.ch:
+			if !ok {
+				return nil
+			}
+			row = r
+		}
 
-		if p.maxTime == nil || dropoffTime.After(*p.maxTime) {
-			p.maxTime = &dropoffTime
+		if (stats.processedRow+stats.droppedRow)%progressInterval == 0 {
+			stats.report(p.progress, p.counters)
 		}
-		if p.minTime == nil || pickupTime.Before(*p.minTime) {
-			p.minTime = &pickupTime
+		insertRow, errs := parseTrip(&row, mapTaxiZone, false)
+		if len(errs) > 0 {
+			stats.dropRow(&row, errs[0].field, errs[0].reason)
+			continue
 		}
-		p.processedRow.Add(1)
-
-		dbInsertChan <- dbRow{
-			Vendor:               vendor,
-			PickupTime:           pickupTime,
-			DropoffTime:          dropoffTime,
-			PassengerCount:       passengerCount,
-			TripDistance:         tripDistance,
-			PuLocationRegion:     *puLookup.Borough,
-			PuLocationZone:       *puLookup.Zone,
-			DoLocationRegion:     *doLookup.Borough,
-			DoLocationZone:       *doLookup.Zone,
-			PaymentType:          paymentType,
-			FareAmount:           fareAmount,
-			Extra:                extra,
-			MtaTax:               mtaTax,
-			TipAmount:            tipAmount,
-			TollsAmount:          tollsAmount,
-			ImprovementSurcharge: improvementSurcharge,
-			TotalAmount:          totalAmount,
-			CongestionSurcharge:  congestionSurcharge,
-			AirportFee:           airportFee,
+		stats.processedRow++
+
+		insertRow.profile(stats.profile)
+		select {
+		case dbInsertChan <- insertRow:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
-func (d *nycTripDbInsert) processWorker() {
+func (d *nycTripDbInsert) processWorker(ctx context.Context) error {
 	defer d.wg.Done()
 
-	batchSize := 1000
-	bufRowInsert := make([][]any, 0, batchSize)
+	bufRowInsert := copyBufferPool.Get().(*lib.CopyBuffer)
+	defer func() {
+		bufRowInsert.Reset()
+		copyBufferPool.Put(bufRowInsert)
+	}()
+	groups := lib.NewChunkGroups(d.hypertable, func(r *dbRow) time.Time { return r.PickupTime })
+
+	// flush writes the rows of one chunk sorted by pickup_time
+	flush := func(chunk time.Time) error {
+		rows := groups.Take(chunk)
+		for i := range rows {
+			rows[i].encode(bufRowInsert)
+		}
+		groups.Release(rows)
+
+		start := time.Now()
+		tbl := lib.NewTableInsert(d.tableName, d.column, bufRowInsert)
+		totalInserted, retries, err := d.insertBatch(ctx, tbl)
+		d.retries.Add(retries)
+		d.sizer.Observe(bufRowInsert.Len(), time.Since(start), err != nil || retries > 0)
+		if err != nil {
+			return errors.Wrap(err, "failed inserting to database")
+		}
+		d.insertedRow.Add(totalInserted)
+		d.progress.RowsInserted.Add(totalInserted)
+		lib.RowsInserted.WithLabelValues(d.tableName).Add(float64(totalInserted))
+		d.chunkStats.Record(chunk, int64(bufRowInsert.Len()))
+		bufRowInsert.Reset()
+		return nil
+	}
+	flushAll := func() error {
+		for _, chunk := range groups.Chunks() {
+			if err := flush(chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	for {
 		select {
 		case rowInsert, ok := <-d.ch:
 			if !ok {
-				if len(bufRowInsert) > 0 {
-					tbl := lib.NewTableInsert(d.tableName, d.column, bufRowInsert)
-					totalInserted, err := lib.InsertUpdateDuplicateBatch(d.ctx, tbl, d.dbConn)
-					if err != nil {
-						d.err = errors.Wrap(err, "failed inserting to database")
-					}
-					d.insertedRow.Add(totalInserted)
-				}
-				return
+				return flushAll()
 			}
 
-			row := rowInsert.toSlice()
-			bufRowInsert = append(bufRowInsert, row)
-			if len(bufRowInsert) == batchSize {
-				tbl := lib.NewTableInsert(d.tableName, d.column, bufRowInsert)
-				totalInserted, err := lib.InsertUpdateDuplicateBatch(d.ctx, tbl, d.dbConn)
-				if err != nil {
-					d.err = errors.Wrap(err, "failed inserting to database")
-					d.drainCh()
-					return
-				}
-				d.insertedRow.Add(totalInserted)
-				bufRowInsert = bufRowInsert[:0]
+			chunk := groups.Add(rowInsert)
+			if groups.Len(chunk) < d.sizer.Size() && groups.Open() <= maxOpenChunks {
+				continue
+			}
+			// a full chunk goes first, otherwise the largest one makes room for the new chunk
+			if groups.Len(chunk) < d.sizer.Size() {
+				chunk = groups.Largest()
+			}
+			if err := flush(chunk); err != nil {
+				return err
 			}
-		case <-d.ctx.Done():
-			return
+		case <-d.quit:
+			return flushAll()
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
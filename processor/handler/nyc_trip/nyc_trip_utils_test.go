@@ -0,0 +1,195 @@
+package nyc_trip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"processor/lib"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var columnOID = map[string]uint32{
+	"character varying":           pgtype.VarcharOID,
+	"timestamp without time zone": pgtype.TimestampOID,
+	"integer":                     pgtype.Int4OID,
+	"double precision":            pgtype.Float8OID,
+}
+
+// reflectToSlice is the reflection based row conversion used before CopyBuffer
+func reflectToSlice(r *dbRow) []any {
+	val := reflect.ValueOf(*r)
+
+	res := make([]any, val.NumField())
+	for i := range val.NumField() {
+		if val.Field(i).Kind() == reflect.Ptr {
+			if val.Field(i).IsNil() {
+				res[i] = nil
+			} else {
+				res[i] = val.Field(i).Elem().Interface()
+			}
+		} else {
+			res[i] = val.Field(i).Interface()
+		}
+	}
+	return res
+}
+
+// pgxCopyStream encodes rows the way pgx.CopyFrom does for a [][]any batch
+func pgxCopyStream(tb testing.TB, m *pgtype.Map, buf []byte, rows [][]any) []byte {
+	buf = append(buf, "PGCOPY\n\377\r\n\000"...)
+	buf = binary.BigEndian.AppendUint32(buf, 0)
+	buf = binary.BigEndian.AppendUint32(buf, 0)
+	for _, row := range rows {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(row)))
+		for i, val := range row {
+			if val == nil {
+				buf = binary.BigEndian.AppendUint32(buf, 0xffffffff)
+				continue
+			}
+			sp := len(buf)
+			buf = append(buf, 0, 0, 0, 0)
+			var err error
+			buf, err = m.Encode(columnOID[TableSchema.Columns[i].DataType], pgtype.BinaryFormatCode, val, buf)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			binary.BigEndian.PutUint32(buf[sp:], uint32(len(buf)-sp-4))
+		}
+	}
+	return binary.BigEndian.AppendUint16(buf, 0xffff)
+}
+
+func sampleRows(n int) []dbRow {
+	fare := 12.5
+	tip := 2.75
+	pickup := time.Date(2025, 1, 1, 0, 18, 38, 0, time.UTC)
+	rows := make([]dbRow, n)
+	for i := range rows {
+		rows[i] = dbRow{
+			Vendor:           vendorOpts[1+i%2],
+			PickupTime:       pickup.Add(time.Duration(i) * time.Second),
+			DropoffTime:      pickup.Add(time.Duration(i)*time.Second + 17*time.Minute),
+			PassengerCount:   int64(i % 4),
+			TripDistance:     1.6 + float64(i),
+			PuLocationRegion: "Manhattan",
+			PuLocationZone:   "Midtown Center",
+			DoLocationRegion: "Queens",
+			DoLocationZone:   "Jamaica Bay",
+			PaymentType:      paymentOpts[i%6],
+			FareAmount:       &fare,
+		}
+		if i%3 == 0 {
+			rows[i].TipAmount = &tip
+		}
+	}
+	return rows
+}
+
+func TestCopyBufferMatchesPgx(t *testing.T) {
+	rows := sampleRows(10)
+
+	batch := lib.NewCopyBuffer(TableSchema)
+	slices := make([][]any, 0, len(rows))
+	for i := range rows {
+		rows[i].encode(batch)
+		slices = append(slices, reflectToSlice(&rows[i]))
+	}
+	if err := batch.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if batch.Len() != len(rows) {
+		t.Fatalf("expected %d rows, got %d", len(rows), batch.Len())
+	}
+
+	want := pgxCopyStream(t, pgtype.NewMap(), nil, slices)
+	if !bytes.Equal(batch.Bytes(), want) {
+		t.Fatalf("copy stream differs from pgx encoding\ngot  %x\nwant %x", batch.Bytes(), want)
+	}
+}
+
+func TestCopyBufferIntegerOutOfRange(t *testing.T) {
+	rows := sampleRows(1)
+	rows[0].PassengerCount = 1 << 40
+
+	batch := lib.NewCopyBuffer(TableSchema)
+	rows[0].encode(batch)
+	if batch.Err() == nil {
+		t.Fatal("expected an out of range error for passenger_count")
+	}
+}
+
+const benchBatchSize = 1000
+
+func BenchmarkBatchEncodeReflect(b *testing.B) {
+	rows := sampleRows(benchBatchSize)
+	m := pgtype.NewMap()
+	var buf []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		batch := make([][]any, 0, benchBatchSize)
+		for i := range rows {
+			batch = append(batch, reflectToSlice(&rows[i]))
+		}
+		buf = pgxCopyStream(b, m, buf[:0], batch)
+	}
+}
+
+func BenchmarkBatchEncodeCopyBuffer(b *testing.B) {
+	rows := sampleRows(benchBatchSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		batch := copyBufferPool.Get().(*lib.CopyBuffer)
+		for i := range rows {
+			rows[i].encode(batch)
+		}
+		_ = batch.Bytes()
+		batch.Reset()
+		copyBufferPool.Put(batch)
+	}
+}
+
+func TestRejectedSampleKeepsEarliestRows(t *testing.T) {
+	parser := &nycTripParser{sampleSize: 3, stats: newParserStats(3), counters: lib.NewRowCounters(TableSchema.Name, true)}
+	// two workers each see part of the input out of order
+	// the first member of the input sorts after the second one by name
+	first, second := parserRow{member: "z.csv"}, parserRow{member: "a.csv", memberIndex: 1}
+	at := func(member parserRow, lineNumber int64) parserRow {
+		member.lineNumber = lineNumber
+		return member
+	}
+	workers := [][]parserRow{
+		{at(second, 2), at(first, 9), at(first, 4), at(first, 7)},
+		{at(first, 8), at(first, 2), at(second, 1)},
+	}
+	for _, rows := range workers {
+		stats := newParserStats(3)
+		for i := range rows {
+			stats.dropRow(&rows[i], "PULocationID", dropUnknownZone)
+		}
+		if len(stats.rejected) != 3 {
+			t.Fatalf("expected a full worker sample, got %d rows", len(stats.rejected))
+		}
+		parser.progress = lib.NewProgress()
+		parser.mergeStats(&stats)
+	}
+
+	var got []int64
+	for _, row := range parser.stats.rejected {
+		if row.member != "z.csv" {
+			t.Fatalf("expected the rows of the first member, got %s", row.member)
+		}
+		got = append(got, row.lineNumber)
+	}
+	if !reflect.DeepEqual(got, []int64{2, 4, 7}) {
+		t.Fatalf("expected lines 2, 4 and 7, got %v", got)
+	}
+	if parser.stats.droppedRow != 7 {
+		t.Fatalf("expected 7 dropped rows, got %d", parser.stats.droppedRow)
+	}
+}
@@ -0,0 +1,294 @@
+package lib
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"processor/logger"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// AdaptiveConfig bounds the runtime tuning of a file load, a zero value disables it
+type AdaptiveConfig struct {
+	Enabled            bool
+	MinBatchSize       int
+	MaxBatchSize       int
+	TargetBatchLatency time.Duration
+	MaxParserWorkers   int
+	MaxInserterWorkers int
+	ScaleInterval      time.Duration
+}
+
+// Validate checks the bounds even when tuning is disabled, they also cap the requests
+func (c AdaptiveConfig) Validate() error {
+	if c.MinBatchSize < 1 || c.MaxBatchSize < c.MinBatchSize {
+		return errors.Errorf("batch size bounds must be positive with the min below the max, got %d and %d", c.MinBatchSize, c.MaxBatchSize)
+	}
+	if c.TargetBatchLatency <= 0 {
+		return errors.Errorf("target batch latency must be positive, got %v", c.TargetBatchLatency)
+	}
+	if c.MaxParserWorkers < 1 || c.MaxInserterWorkers < 1 {
+		return errors.Errorf("max workers must be positive, got %d parsers and %d inserters", c.MaxParserWorkers, c.MaxInserterWorkers)
+	}
+	if c.ScaleInterval <= 0 {
+		return errors.Errorf("autoscale interval must be positive, got %v", c.ScaleInterval)
+	}
+	return nil
+}
+
+// Pinned keeps the tuning off what a request chose, its batch size stays fixed and
+// its worker counts are never exceeded. Zero values aren't overrides.
+func (c AdaptiveConfig) Pinned(batchSize, parserWorkers, inserterWorkers int) AdaptiveConfig {
+	if batchSize > 0 {
+		c.MinBatchSize, c.MaxBatchSize = batchSize, batchSize
+	}
+	if parserWorkers > 0 {
+		c.MaxParserWorkers = parserWorkers
+	}
+	if inserterWorkers > 0 {
+		c.MaxInserterWorkers = inserterWorkers
+	}
+	return c
+}
+
+type AdaptiveStats struct {
+	BatchSize        int
+	MinBatchSize     int
+	MaxBatchSize     int
+	BatchAdjustments int64
+	ScaleEvents      int64
+	Stages           []StageStats
+}
+
+type StageStats struct {
+	Name         string
+	FinalWorkers int
+	PeakWorkers  int
+}
+
+// BatchSizer grows the batch size while CopyFrom stays under the target latency
+// and halves it when a batch is slow or hits a db error
+type BatchSizer struct {
+	cfg         AdaptiveConfig
+	mu          sync.Mutex
+	size        int
+	minSeen     int
+	maxSeen     int
+	adjustments int64
+}
+
+func NewBatchSizer(initial int, cfg AdaptiveConfig) *BatchSizer {
+	size := initial
+	if cfg.Enabled {
+		size = min(max(initial, cfg.MinBatchSize), cfg.MaxBatchSize)
+	}
+	return &BatchSizer{
+		cfg:     cfg,
+		size:    size,
+		minSeen: size,
+		maxSeen: size,
+	}
+}
+
+func (b *BatchSizer) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Observe records one flushed batch, hadError is set when the batch failed or needed a retry
+func (b *BatchSizer) Observe(rows int, latency time.Duration, hadError bool) {
+	if !b.cfg.Enabled {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// a short tail batch says nothing about the current size
+	if rows < b.size && !hadError {
+		return
+	}
+	prev := b.size
+	switch {
+	case hadError || latency > b.cfg.TargetBatchLatency:
+		b.size = max(b.size/2, b.cfg.MinBatchSize)
+	case latency < b.cfg.TargetBatchLatency/2:
+		b.size = min(b.size+b.size/4+1, b.cfg.MaxBatchSize)
+	}
+	if b.size == prev {
+		return
+	}
+	b.adjustments++
+	b.minSeen = min(b.minSeen, b.size)
+	b.maxSeen = max(b.maxSeen, b.size)
+	logger.Info("adjusted batch size",
+		zap.Int("from", prev),
+		zap.Int("to", b.size),
+		zap.Duration("latency", latency),
+		zap.Bool("dbError", hadError),
+	)
+}
+
+func (b *BatchSizer) fillStats(stats *AdaptiveStats) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats.BatchSize = b.size
+	stats.MinBatchSize = b.minSeen
+	stats.MaxBatchSize = b.maxSeen
+	stats.BatchAdjustments = b.adjustments
+}
+
+// ScalableStage is a pool of workers reading from a channel whose fill ratio drives the scaling
+type ScalableStage struct {
+	Name  string
+	Min   int
+	Max   int
+	Depth func() (length int, capacity int)
+	// Start launches one more worker, returning false if it couldn't
+	Start func() bool
+	// Stop asks one worker to exit, returning false if none could be stopped
+	Stop func() bool
+
+	workers atomic.Int64
+	peak    atomic.Int64
+	final   int
+	idle    int
+}
+
+func (s *ScalableStage) Workers() int {
+	return int(s.workers.Load())
+}
+
+// Started must be called for every worker launched outside of the autoscaler
+func (s *ScalableStage) Started() {
+	n := s.workers.Add(1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+// Stopped must be called by every worker on exit
+func (s *ScalableStage) Stopped() {
+	s.workers.Add(-1)
+}
+
+func (s *ScalableStage) fill() float64 {
+	length, capacity := s.Depth()
+	if capacity == 0 {
+		return 0
+	}
+	return float64(length) / float64(capacity)
+}
+
+const (
+	saturatedFill = 0.8
+	drainedFill   = 0.1
+	// ticks a stage has to stay drained before a worker is removed
+	idleTicks = 3
+)
+
+// Autoscaler adds workers to a stage whose input channel is saturated and removes
+// them when it stays drained, a stage is only scaled up if the next one keeps up
+type Autoscaler struct {
+	cfg        AdaptiveConfig
+	stages     []*ScalableStage
+	sizer      *BatchSizer
+	events     atomic.Int64
+	stopCh     chan struct{}
+	finishedCh chan struct{}
+}
+
+func NewAutoscaler(cfg AdaptiveConfig, sizer *BatchSizer, stages ...*ScalableStage) *Autoscaler {
+	return &Autoscaler{
+		cfg:        cfg,
+		stages:     stages,
+		sizer:      sizer,
+		stopCh:     make(chan struct{}),
+		finishedCh: make(chan struct{}),
+	}
+}
+
+func (a *Autoscaler) Run(ctx context.Context) {
+	defer close(a.finishedCh)
+	if !a.cfg.Enabled {
+		return
+	}
+	ticker := time.NewTicker(a.cfg.ScaleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			a.tick()
+		}
+	}
+}
+
+// Stop waits for Run to return, no worker is started or stopped afterwards
+func (a *Autoscaler) Stop() {
+	close(a.stopCh)
+	<-a.finishedCh
+	for _, stage := range a.stages {
+		stage.final = stage.Workers()
+	}
+}
+
+func (a *Autoscaler) tick() {
+	for i, stage := range a.stages {
+		fill := stage.fill()
+		downstreamSaturated := i+1 < len(a.stages) && a.stages[i+1].fill() >= saturatedFill
+		workers := stage.Workers()
+
+		switch {
+		case fill >= saturatedFill && !downstreamSaturated && workers < stage.Max:
+			stage.idle = 0
+			if stage.Start() {
+				stage.Started()
+				a.record(stage, "add", fill)
+			}
+		case fill <= drainedFill && workers > stage.Min:
+			stage.idle++
+			if stage.idle >= idleTicks && stage.Stop() {
+				stage.idle = 0
+				a.record(stage, "remove", fill)
+			}
+		default:
+			stage.idle = 0
+		}
+	}
+}
+
+func (a *Autoscaler) record(stage *ScalableStage, action string, fill float64) {
+	a.events.Add(1)
+	logger.Info("autoscaled stage",
+		zap.String("stage", stage.Name),
+		zap.String("action", action),
+		zap.Int("workers", stage.Workers()),
+		zap.Float64("channelFill", fill),
+	)
+}
+
+// Stats returns the final tuning state, it must be called after Stop
+func (a *Autoscaler) Stats() AdaptiveStats {
+	var stats AdaptiveStats
+	a.sizer.fillStats(&stats)
+	stats.ScaleEvents = a.events.Load()
+	for _, stage := range a.stages {
+		stats.Stages = append(stats.Stages, StageStats{
+			Name:         stage.Name,
+			FinalWorkers: stage.final,
+			PeakWorkers:  int(stage.peak.Load()),
+		})
+	}
+	return stats
+}
@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"testing"
+	"time"
+)
+
+func testAdaptiveConfig() AdaptiveConfig {
+	return AdaptiveConfig{
+		Enabled:            true,
+		MinBatchSize:       100,
+		MaxBatchSize:       1000,
+		TargetBatchLatency: 100 * time.Millisecond,
+		MaxParserWorkers:   4,
+		MaxInserterWorkers: 4,
+		ScaleInterval:      time.Second,
+	}
+}
+
+func TestBatchSizerGrowsShrinksAndClamps(t *testing.T) {
+	sizer := NewBatchSizer(5000, testAdaptiveConfig())
+	if sizer.Size() != 1000 {
+		t.Fatalf("expected the initial size clamped to 1000, got %d", sizer.Size())
+	}
+	steps := []struct {
+		name     string
+		rows     int
+		latency  time.Duration
+		hadError bool
+		size     int
+	}{
+		{"slow batch halves", 1000, time.Second, false, 500},
+		{"db error halves", 500, 10 * time.Millisecond, true, 250},
+		{"short tail batch is ignored", 10, time.Millisecond, false, 250},
+		{"fast batch grows by a quarter", 250, 10 * time.Millisecond, false, 313},
+		{"latency near the target keeps the size", 313, 80 * time.Millisecond, false, 313},
+		{"errors stop at the min", 313, time.Second, true, 156},
+		{"min", 156, time.Second, true, 100},
+		{"min holds", 100, time.Second, true, 100},
+	}
+	for _, step := range steps {
+		sizer.Observe(step.rows, step.latency, step.hadError)
+		if sizer.Size() != step.size {
+			t.Fatalf("%s: expected %d, got %d", step.name, step.size, sizer.Size())
+		}
+	}
+	for range 20 {
+		sizer.Observe(sizer.Size(), time.Millisecond, false)
+	}
+	if sizer.Size() != 1000 {
+		t.Fatalf("expected growth to stop at the max, got %d", sizer.Size())
+	}
+	var stats AdaptiveStats
+	sizer.fillStats(&stats)
+	if stats.MinBatchSize != 100 || stats.MaxBatchSize != 1000 || stats.BatchAdjustments == 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestBatchSizerKeepsOverrides(t *testing.T) {
+	disabled := testAdaptiveConfig()
+	disabled.Enabled = false
+	pinned := testAdaptiveConfig().Pinned(5000, 2, 0)
+	if pinned.MaxParserWorkers != 2 || pinned.MaxInserterWorkers != 4 {
+		t.Fatalf("unexpected pinned workers %+v", pinned)
+	}
+	for name, cfg := range map[string]AdaptiveConfig{"disabled": disabled, "pinned": pinned} {
+		sizer := NewBatchSizer(5000, cfg)
+		sizer.Observe(5000, time.Second, true)
+		sizer.Observe(5000, time.Millisecond, false)
+		if sizer.Size() != 5000 {
+			t.Errorf("%s: expected the batch size to stay 5000, got %d", name, sizer.Size())
+		}
+	}
+}
+
+func TestAdaptiveConfigValidate(t *testing.T) {
+	if err := testAdaptiveConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]func(*AdaptiveConfig){
+		"zero min":        func(c *AdaptiveConfig) { c.MinBatchSize = 0 },
+		"min above max":   func(c *AdaptiveConfig) { c.MinBatchSize = 2000 },
+		"zero latency":    func(c *AdaptiveConfig) { c.TargetBatchLatency = 0 },
+		"zero parsers":    func(c *AdaptiveConfig) { c.MaxParserWorkers = 0 },
+		"zero interval":   func(c *AdaptiveConfig) { c.ScaleInterval = 0 },
+		"disabled checks": func(c *AdaptiveConfig) { c.Enabled, c.ScaleInterval = false, -time.Second },
+	}
+	for name, mutate := range tests {
+		cfg := testAdaptiveConfig()
+		mutate(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// testStage is a stage whose channel fill is set by the test
+type testStage struct {
+	*ScalableStage
+	length int
+}
+
+func newTestStage(name string, min, max, workers int) *testStage {
+	s := &testStage{}
+	s.ScalableStage = &ScalableStage{
+		Name:  name,
+		Min:   min,
+		Max:   max,
+		Depth: func() (int, int) { return s.length, 10 },
+		Start: func() bool { return true },
+		Stop: func() bool {
+			s.Stopped()
+			return true
+		},
+	}
+	for range workers {
+		s.Started()
+	}
+	return s
+}
+
+func TestAutoscalerScalesStages(t *testing.T) {
+	parser, inserter := newTestStage("parser", 1, 3, 1), newTestStage("inserter", 1, 2, 2)
+	a := NewAutoscaler(testAdaptiveConfig(), NewBatchSizer(100, testAdaptiveConfig()), parser.ScalableStage, inserter.ScalableStage)
+
+	// a saturated parser isn't scaled while the inserters can't keep up
+	parser.length, inserter.length = 9, 9
+	a.tick()
+	if parser.Workers() != 1 || inserter.Workers() != 2 {
+		t.Fatalf("expected no change, got %d parsers and %d inserters", parser.Workers(), inserter.Workers())
+	}
+	inserter.length = 5
+	for range 5 {
+		a.tick()
+	}
+	if parser.Workers() != 3 {
+		t.Fatalf("expected the parsers to grow to their max, got %d", parser.Workers())
+	}
+
+	// a drained stage loses a worker every idleTicks ticks down to its min
+	inserter.length = 0
+	for range idleTicks - 1 {
+		a.tick()
+	}
+	if inserter.Workers() != 2 {
+		t.Fatalf("expected a worker to be removed only after %d drained ticks", idleTicks)
+	}
+	for range 3 * idleTicks {
+		a.tick()
+	}
+	if inserter.Workers() != 1 {
+		t.Fatalf("expected the inserters to shrink to their min, got %d", inserter.Workers())
+	}
+	stats := a.Stats()
+	if stats.ScaleEvents != 3 || stats.Stages[0].PeakWorkers != 3 || stats.Stages[1].PeakWorkers != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,392 @@
+package lib
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"processor/logger"
+	pb "processor/protos"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrQueueFull        = errors.New("admission queue is full")
+	ErrAdmissionTimeout = errors.New("timed out waiting for a load slot")
+)
+
+// RetryAfterHeader tells a rejected caller how many seconds to wait before trying again
+const RetryAfterHeader = "retry-after"
+
+// JobSource is the ticket source of the loads run by the job pool
+const JobSource = "job"
+
+// AdmissionConfig bounds the loads running at once, every load holds pool connections
+// and buffers its input so running too many starves the database and the memory
+type AdmissionConfig struct {
+	MaxRunning int
+	// DatasetLimits caps the loads of one dataset, the others are only bound by MaxRunning
+	DatasetLimits map[string]int
+	// MaxQueued is the number of calls waiting for a slot before new ones are rejected
+	MaxQueued int
+	MaxWait   time.Duration
+	// RetryAfter is the shortest wait suggested to a rejected caller
+	RetryAfter time.Duration
+}
+
+func (c AdmissionConfig) Validate() error {
+	if c.MaxRunning < 1 {
+		return errors.Errorf("admission max running must be positive, got %d", c.MaxRunning)
+	}
+	for dataset, limit := range c.DatasetLimits {
+		if limit < 1 {
+			return errors.Errorf("admission limit of %s must be positive, got %d", dataset, limit)
+		}
+	}
+	if c.MaxQueued < 0 {
+		return errors.Errorf("admission max queued can't be negative, got %d", c.MaxQueued)
+	}
+	if c.MaxWait <= 0 {
+		return errors.Errorf("admission max wait must be positive, got %v", c.MaxWait)
+	}
+	if c.RetryAfter <= 0 {
+		return errors.Errorf("admission retry after must be positive, got %v", c.RetryAfter)
+	}
+	return nil
+}
+
+// ParseDatasetLimits reads "dataset=limit" entries
+func ParseDatasetLimits(entries []string) (map[string]int, error) {
+	limits := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		dataset, value, ok := strings.Cut(entry, "=")
+		if !ok || dataset == "" {
+			return nil, errors.Errorf("dataset limit %q must be dataset=limit", entry)
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid limit of dataset %s", dataset)
+		}
+		limits[dataset] = limit
+	}
+	return limits, nil
+}
+
+// AdmissionError rejects a call, RetryAfter is how long the caller should back off
+type AdmissionError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *AdmissionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AdmissionError) Unwrap() error {
+	return e.Err
+}
+
+// Ticket is a load waiting for or holding a slot
+type Ticket struct {
+	ID      string
+	Dataset string
+	// Source is the rpc method or JobSource
+	Source string
+	Caller string
+	// Since is when the load was queued or started
+	Since time.Time
+}
+
+type waiter struct {
+	ticket  Ticket
+	granted chan struct{}
+	release func()
+}
+
+// Scheduler admits loads first come first served, a queued load of a dataset at its
+// limit doesn't hold back the loads of other datasets
+type Scheduler struct {
+	cfg AdmissionConfig
+
+	mu             sync.Mutex
+	seq            uint64
+	running        map[uint64]Ticket
+	datasetRunning map[string]int
+	queue          []*waiter
+	// avgRun is a moving average of the load durations, it sizes the retry after hint
+	avgRun time.Duration
+}
+
+func NewScheduler(cfg AdmissionConfig) *Scheduler {
+	return &Scheduler{
+		cfg:            cfg,
+		running:        make(map[uint64]Ticket),
+		datasetRunning: make(map[string]int),
+	}
+}
+
+// Admit waits for a slot, the call is rejected with an AdmissionError when the queue is
+// full or no slot frees up within MaxWait. The returned function gives the slot back.
+func (s *Scheduler) Admit(ctx context.Context, t Ticket) (func(), error) {
+	return s.admit(ctx, t, true)
+}
+
+// Wait is Admit without the queue bounds, for background loads already capped by their
+// own workers which would rather wait than fail
+func (s *Scheduler) Wait(ctx context.Context, t Ticket) (func(), error) {
+	return s.admit(ctx, t, false)
+}
+
+func (s *Scheduler) admit(ctx context.Context, t Ticket, bounded bool) (func(), error) {
+	t.Since = time.Now()
+	s.mu.Lock()
+	// queued loads are all blocked by a full dataset or a full processor, a load that
+	// fits now doesn't overtake any of them
+	if s.fits(t.Dataset) {
+		release := s.start(t)
+		s.mu.Unlock()
+		return release, nil
+	}
+	if bounded && len(s.queue) >= s.cfg.MaxQueued {
+		retryAfter := s.retryAfter()
+		s.mu.Unlock()
+		AdmissionRejected.WithLabelValues(t.Dataset, "queue_full").Inc()
+		return nil, &AdmissionError{Err: ErrQueueFull, RetryAfter: retryAfter}
+	}
+	w := &waiter{ticket: t, granted: make(chan struct{})}
+	s.queue = append(s.queue, w)
+	position := len(s.queue)
+	s.mu.Unlock()
+	AdmissionQueued.WithLabelValues(t.Dataset).Inc()
+	defer AdmissionQueued.WithLabelValues(t.Dataset).Dec()
+	logger.FromContext(ctx).Info("waiting for a load slot", zap.Int("position", position))
+
+	var timeout <-chan time.Time
+	if bounded {
+		timer := time.NewTimer(s.cfg.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case <-w.granted:
+		AdmissionWait.WithLabelValues(t.Dataset).Observe(time.Since(t.Since).Seconds())
+		return w.release, nil
+	case <-timeout:
+		err = &AdmissionError{Err: ErrAdmissionTimeout, RetryAfter: s.RetryAfter()}
+		AdmissionRejected.WithLabelValues(t.Dataset, "timeout").Inc()
+	case <-ctx.Done():
+		err = errors.Wrap(ctx.Err(), "stopped waiting for a load slot")
+	}
+
+	s.mu.Lock()
+	if w.release != nil {
+		// the slot was granted while giving up
+		s.mu.Unlock()
+		w.release()
+		return nil, err
+	}
+	s.queue = slices.DeleteFunc(s.queue, func(q *waiter) bool { return q == w })
+	s.mu.Unlock()
+	return nil, err
+}
+
+// fits reports whether a load of dataset can start now, s.mu must be held
+func (s *Scheduler) fits(dataset string) bool {
+	if len(s.running) >= s.cfg.MaxRunning {
+		return false
+	}
+	limit, ok := s.cfg.DatasetLimits[dataset]
+	return !ok || s.datasetRunning[dataset] < limit
+}
+
+// start registers a running load, s.mu must be held
+func (s *Scheduler) start(t Ticket) func() {
+	s.seq++
+	id := s.seq
+	t.Since = time.Now()
+	s.running[id] = t
+	s.datasetRunning[t.Dataset]++
+	AdmissionRunning.WithLabelValues(t.Dataset).Inc()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.running, id)
+			s.datasetRunning[t.Dataset]--
+			AdmissionRunning.WithLabelValues(t.Dataset).Dec()
+			elapsed := time.Since(t.Since)
+			if s.avgRun == 0 {
+				s.avgRun = elapsed
+			} else {
+				s.avgRun = (4*s.avgRun + elapsed) / 5
+			}
+			s.dispatch()
+		})
+	}
+}
+
+// dispatch starts the queued loads that fit in queue order, s.mu must be held
+func (s *Scheduler) dispatch() {
+	for i := 0; i < len(s.queue) && len(s.running) < s.cfg.MaxRunning; {
+		w := s.queue[i]
+		if !s.fits(w.ticket.Dataset) {
+			i++
+			continue
+		}
+		s.queue = slices.Delete(s.queue, i, i+1)
+		w.release = s.start(w.ticket)
+		close(w.granted)
+	}
+}
+
+// retryAfter estimates when the queue ahead of a new caller is drained, s.mu must be held
+func (s *Scheduler) retryAfter() time.Duration {
+	estimate := s.avgRun * time.Duration(len(s.queue)+1) / time.Duration(s.cfg.MaxRunning)
+	return max(s.cfg.RetryAfter, estimate).Round(time.Second)
+}
+
+func (s *Scheduler) RetryAfter() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retryAfter()
+}
+
+// DatasetAdmission is the load count of one dataset, Limit is zero without a dataset limit
+type DatasetAdmission struct {
+	Dataset string
+	Limit   int
+	Running int
+	Queued  int
+}
+
+type AdmissionSnapshot struct {
+	MaxRunning int
+	MaxQueued  int
+	RetryAfter time.Duration
+	Datasets   []DatasetAdmission
+	// Running is oldest first, Queued in admission order
+	Running []Ticket
+	Queued  []Ticket
+}
+
+func (s *Scheduler) Snapshot() AdmissionSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := AdmissionSnapshot{
+		MaxRunning: s.cfg.MaxRunning,
+		MaxQueued:  s.cfg.MaxQueued,
+		RetryAfter: s.retryAfter(),
+	}
+	datasets := make(map[string]*DatasetAdmission)
+	dataset := func(name string) *DatasetAdmission {
+		if d, ok := datasets[name]; ok {
+			return d
+		}
+		d := &DatasetAdmission{Dataset: name, Limit: s.cfg.DatasetLimits[name]}
+		datasets[name] = d
+		return d
+	}
+	for name := range s.cfg.DatasetLimits {
+		dataset(name)
+	}
+	for _, t := range s.running {
+		snap.Running = append(snap.Running, t)
+		dataset(t.Dataset).Running++
+	}
+	sort.Slice(snap.Running, func(i, j int) bool { return snap.Running[i].Since.Before(snap.Running[j].Since) })
+	for _, w := range s.queue {
+		snap.Queued = append(snap.Queued, w.ticket)
+		dataset(w.ticket.Dataset).Queued++
+	}
+	for _, d := range datasets {
+		snap.Datasets = append(snap.Datasets, *d)
+	}
+	sort.Slice(snap.Datasets, func(i, j int) bool { return snap.Datasets[i].Dataset < snap.Datasets[j].Dataset })
+	return snap
+}
+
+// rpcTicket describes the rpc in ctx
+func rpcTicket(ctx context.Context, fullMethod, dataset string) Ticket {
+	return Ticket{
+		ID:      RequestIDFrom(ctx),
+		Dataset: dataset,
+		Source:  fullMethod,
+		Caller:  IdentityFrom(ctx).Subject,
+	}
+}
+
+// admissionStatus maps an admission failure to a grpc status and the retry after trailer
+func admissionStatus(err error) (metadata.MD, error) {
+	var rejected *AdmissionError
+	switch {
+	case errors.As(err, &rejected):
+		reason := pb.ErrorReason_ERROR_REASON_QUEUE_FULL
+		if errors.Is(err, ErrAdmissionTimeout) {
+			reason = pb.ErrorReason_ERROR_REASON_ADMISSION_TIMEOUT
+		}
+		seconds := int64(math.Ceil(rejected.RetryAfter.Seconds()))
+		st := NewRPCError(codes.ResourceExhausted, reason, errors.Errorf("%v, retry after %ds", err, seconds)).
+			RetryAfter(time.Duration(seconds) * time.Second).
+			Err()
+		return metadata.Pairs(RetryAfterHeader, strconv.FormatInt(seconds, 10)), st
+	case errors.Is(err, context.DeadlineExceeded):
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return nil, status.Error(codes.Canceled, err.Error())
+	}
+}
+
+// UnaryAdmissionInterceptor makes the calls of methods wait for a load slot, methods maps a
+// full method name to its dataset and the other methods aren't limited. It runs after the
+// auth interceptor so rejected callers never take a place in the queue.
+func (s *Scheduler) UnaryAdmissionInterceptor(methods map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		dataset, ok := methods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+		release, err := s.Admit(ctx, rpcTicket(ctx, info.FullMethod, dataset))
+		if err != nil {
+			trailer, err := admissionStatus(err)
+			if trailer != nil {
+				_ = grpc.SetTrailer(ctx, trailer)
+			}
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+func (s *Scheduler) StreamAdmissionInterceptor(methods map[string]string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		dataset, ok := methods[info.FullMethod]
+		if !ok {
+			return handler(srv, ss)
+		}
+		release, err := s.Admit(ss.Context(), rpcTicket(ss.Context(), info.FullMethod, dataset))
+		if err != nil {
+			trailer, err := admissionStatus(err)
+			if trailer != nil {
+				ss.SetTrailer(trailer)
+			}
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,147 @@
+package lib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testScheduler() *Scheduler {
+	return NewScheduler(AdmissionConfig{
+		MaxRunning:    3,
+		DatasetLimits: map[string]int{"trips": 2},
+		MaxQueued:     2,
+		MaxWait:       time.Minute,
+		RetryAfter:    time.Second,
+	})
+}
+
+func admitAsync(s *Scheduler, ctx context.Context, dataset string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Admit(ctx, Ticket{Dataset: dataset})
+		done <- err
+	}()
+	return done
+}
+
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.Snapshot().Queued) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued loads, got %d", n, len(s.Snapshot().Queued))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerDatasetLimitDoesNotBlockOthers(t *testing.T) {
+	s := testScheduler()
+	ctx := context.Background()
+	var releases []func()
+	for range 2 {
+		release, err := s.Admit(ctx, Ticket{Dataset: "trips"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+
+	queued := admitAsync(s, ctx, "trips")
+	waitQueued(t, s, 1)
+	// the trips limit is reached but the processor has a slot left
+	release, err := s.Admit(ctx, Ticket{Dataset: "zones"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	releases[0]()
+	if err := <-queued; err != nil {
+		t.Fatal(err)
+	}
+	snap := s.Snapshot()
+	if len(snap.Running) != 3 || len(snap.Queued) != 0 {
+		t.Fatalf("expected 3 running and none queued, got %d and %d", len(snap.Running), len(snap.Queued))
+	}
+	// releasing twice doesn't free a second slot
+	releases[0]()
+	if len(s.Snapshot().Running) != 3 {
+		t.Fatal("a release freed more than one slot")
+	}
+}
+
+func TestSchedulerRejectsWhenQueueIsFull(t *testing.T) {
+	s := testScheduler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for range 3 {
+		if _, err := s.Admit(ctx, Ticket{Dataset: "zones"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second := admitAsync(s, ctx, "zones"), admitAsync(s, ctx, "zones")
+	waitQueued(t, s, 2)
+
+	_, err := s.Admit(ctx, Ticket{Dataset: "zones"})
+	var rejected *AdmissionError
+	if !errors.As(err, &rejected) || !errors.Is(err, ErrQueueFull) || rejected.RetryAfter < time.Second {
+		t.Fatalf("expected a full queue with a retry after, got %v", err)
+	}
+	// background loads wait past the queue bound
+	waited := make(chan error, 1)
+	go func() {
+		_, err := s.Wait(ctx, Ticket{Dataset: "zones", Source: JobSource})
+		waited <- err
+	}()
+	waitQueued(t, s, 3)
+
+	cancel()
+	for _, done := range []<-chan error{first, second, waited} {
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected a cancelled wait, got %v", err)
+		}
+	}
+	if len(s.Snapshot().Queued) != 0 {
+		t.Fatal("cancelled loads stayed queued")
+	}
+}
+
+func TestAdmissionInterceptorTimesOut(t *testing.T) {
+	s := testScheduler()
+	s.cfg.MaxWait = 10 * time.Millisecond
+	for range 2 {
+		if _, err := s.Admit(context.Background(), Ticket{Dataset: "trips"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	interceptor := s.UnaryAdmissionInterceptor(map[string]string{"/svc/Load": "trips"})
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Load"}, handler)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected resource exhausted, got %v", err)
+	}
+	// methods without a dataset aren't limited
+	if _, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Get"}, handler); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseDatasetLimits(t *testing.T) {
+	limits, err := ParseDatasetLimits([]string{"trips=3", "zones=1"})
+	if err != nil || limits["trips"] != 3 || limits["zones"] != 1 {
+		t.Fatalf("unexpected limits %v %v", limits, err)
+	}
+	for _, entry := range []string{"trips", "=3", "trips=many"} {
+		if _, err := ParseDatasetLimits([]string{entry}); err == nil {
+			t.Errorf("%s: expected an error", entry)
+		}
+	}
+}
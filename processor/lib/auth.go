@@ -0,0 +1,239 @@
+package lib
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"slices"
+	"strings"
+	"time"
+
+	"processor/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const allowAll = "*"
+
+var (
+	ErrUnauthenticated  = errors.New("missing or invalid bearer token")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Identity is the authenticated caller of an RPC
+type Identity struct {
+	Subject string
+	Role    string
+	// Datasets the caller may touch, * allows every dataset
+	Datasets []string
+	// Admin is set when the role may call every method
+	Admin bool
+}
+
+func (id Identity) AllowsDataset(dataset string) bool {
+	return slices.Contains(id.Datasets, allowAll) || slices.Contains(id.Datasets, dataset)
+}
+
+// Owns reports whether the caller may see a job submitted by submittedBy, admins see every
+// job and every caller is the same one when auth is disabled
+func (id Identity) Owns(submittedBy string) bool {
+	return id.Subject == "" || id.Admin || id.Subject == submittedBy
+}
+
+type identityKey struct{}
+
+func ContextWithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFrom returns the caller of the RPC, the subject is empty when auth is disabled
+func IdentityFrom(ctx context.Context) Identity {
+	id, _ := ctx.Value(identityKey{}).(Identity)
+	return id
+}
+
+type apiKey struct {
+	hash     [sha256.Size]byte
+	identity Identity
+}
+
+// AuthConfig declares the accepted tokens and what every role may call.
+// Roles are "role=Method|Method", API keys are "subject:role:dataset|dataset:key"
+// and JWTs carry the sub, role and datasets claims.
+type AuthConfig struct {
+	Roles       []string
+	APIKeys     []string
+	JWTSecret   string
+	JWTIssuer   string
+	JWTAudience string
+}
+
+// Authenticator checks bearer tokens and the methods and datasets their identity may use
+type Authenticator struct {
+	roles     map[string][]string
+	apiKeys   []apiKey
+	jwtSecret []byte
+	parser    *jwt.Parser
+}
+
+func NewAuthenticator(cfg AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{roles: make(map[string][]string)}
+	for _, role := range cfg.Roles {
+		name, methods, found := strings.Cut(role, "=")
+		if !found || name == "" || methods == "" {
+			return nil, errors.Errorf("role %q must be role=Method|Method", role)
+		}
+		a.roles[name] = strings.Split(methods, "|")
+	}
+	for i, entry := range cfg.APIKeys {
+		parts := strings.SplitN(entry, ":", 4)
+		if len(parts) != 4 || parts[0] == "" || parts[3] == "" {
+			return nil, errors.Errorf("api key %d must be subject:role:datasets:key", i)
+		}
+		if _, found := a.roles[parts[1]]; !found {
+			return nil, errors.Errorf("api key of %s has unknown role %s", parts[0], parts[1])
+		}
+		a.apiKeys = append(a.apiKeys, apiKey{
+			hash: sha256.Sum256([]byte(parts[3])),
+			identity: Identity{
+				Subject:  parts[0],
+				Role:     parts[1],
+				Datasets: strings.Split(parts[2], "|"),
+			},
+		})
+	}
+	if cfg.JWTSecret != "" {
+		a.jwtSecret = []byte(cfg.JWTSecret)
+		opts := []jwt.ParserOption{
+			jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(30 * time.Second),
+		}
+		if cfg.JWTIssuer != "" {
+			opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+		}
+		if cfg.JWTAudience != "" {
+			opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+		}
+		a.parser = jwt.NewParser(opts...)
+	}
+	if len(a.apiKeys) == 0 && a.parser == nil {
+		return nil, errors.New("auth needs api keys or a jwt secret")
+	}
+	return a, nil
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Role     string   `json:"role"`
+	Datasets []string `json:"datasets"`
+}
+
+// Authenticate returns the identity of a bearer token
+func (a *Authenticator) Authenticate(token string) (Identity, error) {
+	hash := sha256.Sum256([]byte(token))
+	for _, key := range a.apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], key.hash[:]) == 1 {
+			return key.identity, nil
+		}
+	}
+	if a.parser == nil || strings.Count(token, ".") != 2 {
+		return Identity{}, ErrUnauthenticated
+	}
+
+	var claims tokenClaims
+	_, err := a.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return a.jwtSecret, nil
+	})
+	if err != nil {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, err.Error())
+	}
+	if claims.Subject == "" {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, "token has no subject")
+	}
+	if _, found := a.roles[claims.Role]; !found {
+		return Identity{}, errors.Wrapf(ErrUnauthenticated, "token has unknown role %q", claims.Role)
+	}
+	return Identity{Subject: claims.Subject, Role: claims.Role, Datasets: claims.Datasets}, nil
+}
+
+// Authorize checks the role of id may call fullMethod on dataset, an empty dataset isn't checked
+func (a *Authenticator) Authorize(id Identity, fullMethod, dataset string) error {
+	method := fullMethod[strings.LastIndexByte(fullMethod, '/')+1:]
+	methods := a.roles[id.Role]
+	if !slices.Contains(methods, allowAll) && !slices.Contains(methods, method) {
+		return errors.Wrapf(ErrPermissionDenied, "role %s may not call %s", id.Role, method)
+	}
+	if dataset != "" && !id.AllowsDataset(dataset) {
+		return errors.Wrapf(ErrPermissionDenied, "%s may not access dataset %s", id.Subject, dataset)
+	}
+	return nil
+}
+
+// publicMethod is reachable without a token so probes and tooling keep working
+func publicMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.") ||
+		strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, fullMethod string, datasets map[string]string) (context.Context, error) {
+	if publicMethod(fullMethod) {
+		return ctx, nil
+	}
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			scheme, value, found := strings.Cut(values[0], " ")
+			if found && strings.EqualFold(scheme, "bearer") {
+				token = strings.TrimSpace(value)
+			}
+		}
+	}
+	if token == "" {
+		logger.FromContext(ctx).Warn("rejected unauthenticated request")
+		return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+	}
+	id, err := a.Authenticate(token)
+	if err != nil {
+		logger.FromContext(ctx).Warn("rejected unauthenticated request", zap.Error(err))
+		return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+	}
+	if err := a.Authorize(id, fullMethod, datasets[fullMethod]); err != nil {
+		logger.FromContext(ctx).Warn("rejected unauthorized request",
+			zap.String("caller", id.Subject),
+			zap.String("role", id.Role),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	id.Admin = slices.Contains(a.roles[id.Role], allowAll)
+	ctx = logger.WithFields(ctx, zap.String("caller", id.Subject))
+	return ContextWithIdentity(ctx, id), nil
+}
+
+// UnaryAuthInterceptor authenticates every call, datasets maps a full method name to the dataset it loads
+func (a *Authenticator) UnaryAuthInterceptor(datasets map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod, datasets)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Authenticator) StreamAuthInterceptor(datasets map[string]string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod, datasets)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(AuthConfig{
+		Roles:     []string{"admin=*", "readonly=ProcessTesting"},
+		APIKeys:   []string{"airflow:admin:nyc_trip:s3cr3t:with:colons"},
+		JWTSecret: "jwt-secret",
+		JWTIssuer: "pipeline",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func signToken(t *testing.T, secret string, claims tokenClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a := newTestAuthenticator(t)
+	id, err := a.Authenticate("s3cr3t:with:colons")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != "airflow" || id.Role != "admin" {
+		t.Fatalf("unexpected identity %+v", id)
+	}
+	if err := a.Authorize(id, "/TransformService/ProcessNYCTrip", "nyc_trip"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Authorize(id, "/TransformService/ProcessTesting", "taxi_zone_lookup"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected dataset to be denied, got %v", err)
+	}
+	if _, err := a.Authenticate("wrong"); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected unauthenticated, got %v", err)
+	}
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	a := newTestAuthenticator(t)
+	claims := tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "analyst",
+			Issuer:    "pipeline",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Role:     "readonly",
+		Datasets: []string{"*"},
+	}
+	id, err := a.Authenticate(signToken(t, "jwt-secret", claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Authorize(id, "/TransformService/ProcessTesting", "taxi_zone_lookup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Authorize(id, "/TransformService/ProcessNYCTrip", "nyc_trip"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected method to be denied, got %v", err)
+	}
+
+	for name, token := range map[string]string{
+		"wrong secret": signToken(t, "other", claims),
+		"expired": signToken(t, "jwt-secret", tokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "analyst",
+				Issuer:    "pipeline",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+			},
+			Role: "readonly",
+		}),
+		"unknown role": signToken(t, "jwt-secret", tokenClaims{
+			RegisteredClaims: claims.RegisteredClaims,
+			Role:             "root",
+		}),
+	} {
+		if _, err := a.Authenticate(token); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("%s: expected unauthenticated, got %v", name, err)
+		}
+	}
+}
+
+func TestIdentityOwns(t *testing.T) {
+	tests := []struct {
+		name string
+		id   Identity
+		owns bool
+	}{
+		{"submitter", Identity{Subject: "airflow"}, true},
+		{"other caller", Identity{Subject: "etl"}, false},
+		{"admin", Identity{Subject: "ops", Admin: true}, true},
+		{"auth disabled", Identity{}, true},
+	}
+	for _, tt := range tests {
+		if owns := tt.id.Owns("airflow"); owns != tt.owns {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.owns, owns)
+		}
+	}
+}
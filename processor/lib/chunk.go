@@ -0,0 +1,224 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// chunkProbeSize is how much is read around a chunk boundary to find the next newline
+const chunkProbeSize = 64 * 1024
+
+// Chunk is a byte range of an input starting right after a newline
+type Chunk struct {
+	Offset int64
+	Length int64
+	// FirstLine is the line number of the first line in the chunk, relative to the input
+	FirstLine int64
+}
+
+// ReadFirstLine returns the first line of r without its line terminator and the offset of the next line
+func ReadFirstLine(r io.ReaderAt, size int64) (string, int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, errors.Wrap(err, "failed reading first line")
+	}
+	next := int64(len(line))
+	return trimLineEnd(line), next, nil
+}
+
+// SplitFirstLine reads the first line of r without its line terminator and returns a
+// reader of the lines after it, it returns io.EOF if r is empty
+func SplitFirstLine(r io.Reader) (string, io.Reader, error) {
+	reader := bufio.NewReaderSize(r, chunkProbeSize)
+	line, err := reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", nil, io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return "", nil, errors.Wrap(err, "failed reading first line")
+	}
+	return trimLineEnd(line), reader, nil
+}
+
+// SplitChunks splits r into ranges of about chunkSize bytes aligned on newlines
+// and counts the lines of every range in parallel so the line numbers are known upfront
+func SplitChunks(ctx context.Context, r io.ReaderAt, size, chunkSize int64, workers int) ([]Chunk, error) {
+	var chunks []Chunk
+	var start int64
+	for start < size {
+		end := start + chunkSize
+		if end >= size {
+			chunks = append(chunks, Chunk{Offset: start, Length: size - start})
+			break
+		}
+		next, err := nextLineStart(r, size, end)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, Chunk{Offset: start, Length: next - start})
+		start = next
+	}
+
+	lineCounts := make([]int64, len(chunks))
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+	for i, chunk := range chunks {
+		group.Go(Recovered(ctx, func() error {
+			count, err := countLines(ctx, io.NewSectionReader(r, chunk.Offset, chunk.Length))
+			lineCounts[i] = count
+			return err
+		}))
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	var line int64
+	for i := range chunks {
+		chunks[i].FirstLine = line
+		line += lineCounts[i]
+	}
+	return chunks, nil
+}
+
+// ForEachLine calls fn for every line of the chunks from up to workers goroutines,
+// lines are passed without their terminator together with their line number
+func ForEachLine(ctx context.Context, r io.ReaderAt, chunks []Chunk, workers int, fn func(lineNumber int64, line string) error) error {
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+	for _, chunk := range chunks {
+		group.Go(Recovered(ctx, func() error {
+			return eachLine(ctx, io.NewSectionReader(r, chunk.Offset, chunk.Length), chunk.FirstLine, fn)
+		}))
+	}
+	return group.Wait()
+}
+
+// ForEachStreamLine is ForEachLine for an input that can only be read once, such as a
+// decompressed upload. Blocks of about chunkSize bytes ending on a newline are read in
+// turn, their lines are counted before the block is handed to one of the workers so the
+// line numbers match a serial read. At most workers+1 blocks are held in memory.
+func ForEachStreamLine(ctx context.Context, r io.Reader, chunkSize int64, workers int, fn func(lineNumber int64, line string) error) error {
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+	reader := bufio.NewReaderSize(r, chunkProbeSize)
+	var line int64
+	var readErr error
+	for groupCtx.Err() == nil {
+		var block bytes.Buffer
+		_, err := io.CopyN(&block, reader, chunkSize)
+		if err == nil {
+			// the block ends with the line it cut
+			var rest []byte
+			rest, err = reader.ReadBytes('\n')
+			block.Write(rest)
+		}
+		// a block cut short by a read error isn't passed, its last line may be partial
+		if block.Len() > 0 && (err == nil || err == io.EOF) {
+			data, first := block.Bytes(), line
+			count, _ := countLines(groupCtx, bytes.NewReader(data))
+			line += count
+			group.Go(Recovered(groupCtx, func() error {
+				return eachLine(groupCtx, bytes.NewReader(data), first, fn)
+			}))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = errors.Wrapf(err, "failed reading line %d", line)
+			break
+		}
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return ctx.Err()
+}
+
+// eachLine calls fn for every line of r numbering them from lineNumber
+func eachLine(ctx context.Context, r io.Reader, lineNumber int64, fn func(lineNumber int64, line string) error) error {
+	reader := bufio.NewReaderSize(r, chunkProbeSize)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if fnErr := fn(lineNumber, trimLineEnd(line)); fnErr != nil {
+				return fnErr
+			}
+			lineNumber++
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed reading line %d", lineNumber)
+		}
+		if lineNumber%4096 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// nextLineStart returns the offset right after the first newline at or after pos
+func nextLineStart(r io.ReaderAt, size, pos int64) (int64, error) {
+	buf := make([]byte, chunkProbeSize)
+	for pos < size {
+		n, err := r.ReadAt(buf, pos)
+		if idx := bytes.IndexByte(buf[:n], '\n'); idx >= 0 {
+			return pos + int64(idx) + 1, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, errors.Wrapf(err, "failed reading at offset %d", pos)
+		}
+		pos += int64(n)
+		if n == 0 {
+			break
+		}
+	}
+	return size, nil
+}
+
+func countLines(ctx context.Context, r io.Reader) (int64, error) {
+	buf := make([]byte, chunkProbeSize)
+	var count int64
+	var last byte
+	var read bool
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			count += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			last = buf[n-1]
+			read = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "failed counting lines")
+		}
+	}
+	// a last line without terminator still counts
+	if read && last != '\n' {
+		count++
+	}
+	return count, nil
+}
+
+// trimLineEnd drops the terminator the same way bufio.ScanLines does
+func trimLineEnd(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r")
+}
@@ -0,0 +1,220 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serialLines numbers the lines of data the way a single scanner would
+func serialLines(t testing.TB, data []byte) map[int64]string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	lines := make(map[int64]string)
+	var n int64
+	for scanner.Scan() {
+		lines[n] = scanner.Text()
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+// lineCollector records the lines passed by the workers
+type lineCollector struct {
+	mu    sync.Mutex
+	lines map[int64]string
+}
+
+func (c *lineCollector) add(lineNumber int64, line string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, found := c.lines[lineNumber]; found {
+		return fmt.Errorf("line %d passed twice, %q and %q", lineNumber, prev, line)
+	}
+	c.lines[lineNumber] = line
+	return nil
+}
+
+func gzipMembers(t testing.TB, members ...string) []byte {
+	var buf bytes.Buffer
+	for _, member := range members {
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write([]byte(member)); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestChunkedLineNumbersMatchSerial(t *testing.T) {
+	long := strings.Repeat("x", 3*chunkProbeSize)
+	var many strings.Builder
+	for i := range 500 {
+		fmt.Fprintf(&many, "row %d\t%s\n", i, strings.Repeat("v", i%37))
+	}
+	inputs := map[string]string{
+		"lf":                  "a\nb\nc\n",
+		"crlf":                "a\r\nb\r\nc\r\n",
+		"no final newline":    "a\nb\nc",
+		"empty lines":         "\n\na\n\nb\n\n",
+		"single line":         "only",
+		"lines over a probe":  "a\n" + long + "\nb\n" + long,
+		"many lines":          many.String(),
+		"empty":               "",
+		"only newlines":       "\n\n\n",
+		"crlf without ending": "a\r\nb\r",
+	}
+	for name, input := range inputs {
+		data := []byte(input)
+		want := serialLines(t, data)
+		for _, chunkSize := range []int64{1, 7, 100, 4096, 1 << 20} {
+			for _, workers := range []int{1, 4} {
+				chunks, err := SplitChunks(context.Background(), bytes.NewReader(data), int64(len(data)), chunkSize, workers)
+				if err != nil {
+					t.Fatal(err)
+				}
+				chunked := &lineCollector{lines: make(map[int64]string)}
+				if err := ForEachLine(context.Background(), bytes.NewReader(data), chunks, workers, chunked.add); err != nil {
+					t.Fatal(err)
+				}
+				compareLines(t, fmt.Sprintf("%s chunked %d/%d", name, chunkSize, workers), want, chunked.lines)
+
+				streamed := &lineCollector{lines: make(map[int64]string)}
+				if err := ForEachStreamLine(context.Background(), bytes.NewReader(data), chunkSize, workers, streamed.add); err != nil {
+					t.Fatal(err)
+				}
+				compareLines(t, fmt.Sprintf("%s streamed %d/%d", name, chunkSize, workers), want, streamed.lines)
+			}
+		}
+	}
+}
+
+func TestStreamLineNumbersAcrossGzipMembers(t *testing.T) {
+	// a member may end without a newline, its last line then continues in the next one
+	members := []string{"h\na\nb\n", "c\nd", "e\nf\n"}
+	want := serialLines(t, []byte(strings.Join(members, "")))
+	for _, chunkSize := range []int64{1, 3, 1 << 20} {
+		gz, err := gzip.NewReader(bytes.NewReader(gzipMembers(t, members...)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &lineCollector{lines: make(map[int64]string)}
+		if err := ForEachStreamLine(context.Background(), gz, chunkSize, 3, got.add); err != nil {
+			t.Fatal(err)
+		}
+		compareLines(t, fmt.Sprintf("gzip members %d", chunkSize), want, got.lines)
+	}
+}
+
+func compareLines(t *testing.T, name string, want, got map[int64]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: expected %d lines, got %d", name, len(want), len(got))
+		return
+	}
+	for n, line := range want {
+		if got[n] != line {
+			t.Errorf("%s: line %d is %q, expected %q", name, n, truncate(got[n]), truncate(line))
+			return
+		}
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > 20 {
+		return s[:20] + "..."
+	}
+	return s
+}
+
+func TestSplitFirstLine(t *testing.T) {
+	line, rest, err := SplitFirstLine(strings.NewReader("h1\th2\r\na\nb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(rest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "h1\th2" || string(body) != "a\nb" {
+		t.Fatalf("unexpected split %q / %q", line, body)
+	}
+	if _, _, err := SplitFirstLine(strings.NewReader("")); err != io.EOF {
+		t.Fatalf("expected EOF on an empty input, got %v", err)
+	}
+}
+
+func TestForEachStreamLineStopsOnError(t *testing.T) {
+	data := strings.Repeat("line\n", 10000)
+	fail := fmt.Errorf("stop")
+	err := ForEachStreamLine(context.Background(), strings.NewReader(data), 100, 4, func(lineNumber int64, line string) error {
+		if lineNumber == 500 {
+			return fail
+		}
+		return nil
+	})
+	if err != fail {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+}
+
+// benchmarkInput is a tab separated input of about 32 MiB
+func benchmarkInput() []byte {
+	var sb strings.Builder
+	for i := 0; sb.Len() < 32<<20; i++ {
+		fmt.Fprintf(&sb, "%d\t2025-01-01 00:00:%02d\t2025-01-01 00:17:%02d\t1\t1.60\t1\t2\t1\t12.5\t1\t0.5\t2\t0\t1\t17\t2.5\t0\n", i%2, i%60, i%60)
+	}
+	return []byte(sb.String())
+}
+
+// splitFields stands in for the parser so the benchmark scales with cpu work per line
+func splitFields(lineNumber int64, line string) error {
+	if len(strings.Split(line, "\t")) != 17 {
+		return fmt.Errorf("line %d has the wrong field count", lineNumber)
+	}
+	return nil
+}
+
+func BenchmarkForEachLine(b *testing.B) {
+	data := benchmarkInput()
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for range b.N {
+				chunks, err := SplitChunks(context.Background(), bytes.NewReader(data), int64(len(data)), 1<<20, workers)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := ForEachLine(context.Background(), bytes.NewReader(data), chunks, workers, splitFields); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkForEachStreamLine(b *testing.B) {
+	data := benchmarkInput()
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for range b.N {
+				if err := ForEachStreamLine(context.Background(), bytes.NewReader(data), 1<<20, workers, splitFields); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package lib
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var copySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// microseconds between the unix epoch and the postgres epoch 2000-01-01
+const microsecFromUnixEpochToY2K = 946684800 * 1000000
+
+// CopyBuffer encodes rows straight into the postgres binary COPY format.
+// Values must be appended in the column order of the schema, the column type
+// picks the wire encoding so no reflection or boxing happens per row.
+type CopyBuffer struct {
+	columns []Column
+	buf     []byte
+	col     int
+	rows    int
+	sealed  bool
+	err     error
+}
+
+func NewCopyBuffer(schema TableSchema) *CopyBuffer {
+	b := &CopyBuffer{columns: schema.Columns}
+	b.Reset()
+	return b
+}
+
+// Reset empties the buffer and keeps its memory for the next batch
+func (b *CopyBuffer) Reset() {
+	b.buf = append(b.buf[:0], copySignature...)
+	b.buf = binary.BigEndian.AppendUint32(b.buf, 0) // flags
+	b.buf = binary.BigEndian.AppendUint32(b.buf, 0) // header extension length
+	b.col = 0
+	b.rows = 0
+	b.sealed = false
+	b.err = nil
+}
+
+func (b *CopyBuffer) Len() int {
+	return b.rows
+}
+
+func (b *CopyBuffer) Err() error {
+	return b.err
+}
+
+// Bytes returns the whole COPY stream including the trailer, no row can be appended afterwards
+func (b *CopyBuffer) Bytes() []byte {
+	if !b.sealed {
+		b.buf = binary.BigEndian.AppendUint16(b.buf, 0xffff)
+		b.sealed = true
+	}
+	return b.buf
+}
+
+func (b *CopyBuffer) next() Column {
+	if b.col == 0 {
+		b.buf = binary.BigEndian.AppendUint16(b.buf, uint16(len(b.columns)))
+	}
+	col := b.columns[b.col]
+	b.col++
+	if b.col == len(b.columns) {
+		b.col = 0
+		b.rows++
+	}
+	return col
+}
+
+func (b *CopyBuffer) fail(col Column, err error) {
+	if b.err == nil {
+		b.err = errors.Wrapf(err, "failed encoding column %s", col.Name)
+	}
+}
+
+func (b *CopyBuffer) AppendNull() {
+	b.next()
+	b.buf = binary.BigEndian.AppendUint32(b.buf, math.MaxUint32) // -1 length
+}
+
+func (b *CopyBuffer) AppendString(v string) {
+	b.next()
+	b.buf = binary.BigEndian.AppendUint32(b.buf, uint32(len(v)))
+	b.buf = append(b.buf, v...)
+}
+
+func (b *CopyBuffer) AppendInt(v int64) {
+	col := b.next()
+	switch col.DataType {
+	case "smallint":
+		if v < math.MinInt16 || v > math.MaxInt16 {
+			b.fail(col, errors.Errorf("%d is out of range for smallint", v))
+		}
+		b.buf = binary.BigEndian.AppendUint32(b.buf, 2)
+		b.buf = binary.BigEndian.AppendUint16(b.buf, uint16(v))
+	case "integer":
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			b.fail(col, errors.Errorf("%d is out of range for integer", v))
+		}
+		b.buf = binary.BigEndian.AppendUint32(b.buf, 4)
+		b.buf = binary.BigEndian.AppendUint32(b.buf, uint32(v))
+	default:
+		b.buf = binary.BigEndian.AppendUint32(b.buf, 8)
+		b.buf = binary.BigEndian.AppendUint64(b.buf, uint64(v))
+	}
+}
+
+func (b *CopyBuffer) AppendFloat(v float64) {
+	col := b.next()
+	if col.DataType == "real" {
+		b.buf = binary.BigEndian.AppendUint32(b.buf, 4)
+		b.buf = binary.BigEndian.AppendUint32(b.buf, math.Float32bits(float32(v)))
+		return
+	}
+	b.buf = binary.BigEndian.AppendUint32(b.buf, 8)
+	b.buf = binary.BigEndian.AppendUint64(b.buf, math.Float64bits(v))
+}
+
+func (b *CopyBuffer) AppendFloatPtr(v *float64) {
+	if v == nil {
+		b.AppendNull()
+		return
+	}
+	b.AppendFloat(*v)
+}
+
+func (b *CopyBuffer) AppendTime(v time.Time) {
+	col := b.next()
+	if col.DataType == "timestamp without time zone" {
+		// keep the wall clock, same as pgx does for timestamp
+		v = time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), time.UTC)
+	}
+	micro := v.Unix()*1000000 + int64(v.Nanosecond())/1000 - microsecFromUnixEpochToY2K
+	b.buf = binary.BigEndian.AppendUint32(b.buf, 8)
+	b.buf = binary.BigEndian.AppendUint64(b.buf, uint64(micro))
+}
@@ -1,9 +1,11 @@
 package lib
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"processor/logger"
 
@@ -44,12 +46,12 @@ SELECT COUNT(*) FROM ins;
 type TableInsert struct {
 	name     string
 	column   []string
-	rowBatch [][]any
+	rowBatch *CopyBuffer
 	// colIndex []string
 	// colSet   []string
 }
 
-func NewTableInsert(tableName string, column []string, rowBatch [][]any) *TableInsert {
+func NewTableInsert(tableName string, column []string, rowBatch *CopyBuffer) *TableInsert {
 	return &TableInsert{
 		name:     tableName,
 		column:   column,
@@ -59,12 +61,41 @@ func NewTableInsert(tableName string, column []string, rowBatch [][]any) *TableI
 	}
 }
 
-func NewDBConn(ctx context.Context, url string) (*pgxpool.Pool, error) {
+// Rows returns the number of rows in the batch
+func (t *TableInsert) Rows() int {
+	return t.rowBatch.Len()
+}
+
+// Err returns the first error met while encoding the batch
+func (t *TableInsert) Err() error {
+	return t.rowBatch.Err()
+}
+
+type DBPoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+}
+
+func NewDBConn(ctx context.Context, url string, poolCfg DBPoolConfig) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(strings.TrimSpace(url))
 	if err != nil {
 		return nil, errors.Wrap(err, "pgx failed parsed url")
 	}
 	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	if poolCfg.MaxConns > 0 {
+		config.MaxConns = poolCfg.MaxConns
+	}
+	if poolCfg.MinConns > 0 {
+		config.MinConns = poolCfg.MinConns
+	}
+	if poolCfg.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = poolCfg.MaxConnLifetime
+	}
+	if poolCfg.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = poolCfg.MaxConnIdleTime
+	}
 
 	conn, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -74,21 +105,22 @@ func NewDBConn(ctx context.Context, url string) (*pgxpool.Pool, error) {
 		conn.Close()
 		return nil, err
 	}
-	logger.Info("Database connection established")
+	logger.Info("Database connection established", zap.Int32("maxConns", config.MaxConns))
 	return conn, nil
 }
 
-func InsertUpdateDuplicateBatch(ctx context.Context, tbl *TableInsert, conn *pgxpool.Pool) (int64, error) {
+func InsertUpdateDuplicateBatch(ctx context.Context, tbl *TableInsert, conn *pgxpool.Pool) (totalInserted int64, err error) {
 	var (
-		totalInserted int64
 		tempTableName string
 		// uniqueColumns string
 		// setString     string
 		// setColumns    string
-		err error
 	)
 
 	tempTableName = fmt.Sprintf("%s_temp", tbl.name)
+	if err = tbl.rowBatch.Err(); err != nil {
+		return 0, errors.Wrap(err, "failed encoding batch")
+	}
 
 	// uniqueColumns = strings.Join(tbl.colIndex, ",")
 	// for _, col := range tbl.colSet {
@@ -102,14 +134,14 @@ func InsertUpdateDuplicateBatch(ctx context.Context, tbl *TableInsert, conn *pgx
 	}
 	defer func() {
 		if err != nil {
-			err = tx.Rollback(ctx)
-			if err != nil {
-				logger.Error("failed rolling back transaction", zap.Error(err))
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				logger.Error("failed rolling back transaction", zap.Error(rbErr))
 			}
 		} else {
-			err = tx.Commit(ctx)
-			if err != nil {
-				logger.Error("failed committing transaction", zap.Error(err))
+			if cmErr := tx.Commit(ctx); cmErr != nil {
+				logger.Error("failed committing transaction", zap.Error(cmErr))
+				totalInserted = 0
+				err = &commitError{err: errors.Wrap(cmErr, "failed committing transaction")}
 			}
 		}
 	}()
@@ -119,7 +151,12 @@ func InsertUpdateDuplicateBatch(ctx context.Context, tbl *TableInsert, conn *pgx
 	if err != nil {
 		return 0, errors.Wrap(err, "failed creating temporary table")
 	}
-	_, err = tx.CopyFrom(ctx, pgx.Identifier{tempTableName}, tbl.column, pgx.CopyFromRows(tbl.rowBatch))
+	quotedColumns := make([]string, len(tbl.column))
+	for i, col := range tbl.column {
+		quotedColumns[i] = pgx.Identifier{col}.Sanitize()
+	}
+	query = fmt.Sprintf("COPY %s ( %s ) FROM STDIN BINARY", pgx.Identifier{tempTableName}.Sanitize(), strings.Join(quotedColumns, ", "))
+	_, err = tx.Conn().PgConn().CopyFrom(ctx, bytes.NewReader(tbl.rowBatch.Bytes()), query)
 	if err != nil {
 		return 0, errors.Wrap(err, "failed inserting to temporary table")
 	}
@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"context"
+	"time"
+
+	"processor/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthCheck reports the state of one dependency under its own health service name
+type HealthCheck struct {
+	Service string
+	Check   func(ctx context.Context) error
+}
+
+// HealthMonitor runs the checks periodically and publishes them on a grpc health server.
+// The services are SERVING only while every check passes.
+type HealthMonitor struct {
+	server   *health.Server
+	services []string
+	checks   []HealthCheck
+	interval time.Duration
+	timeout  time.Duration
+	update   chan struct{}
+	failing  map[string]bool
+}
+
+func NewHealthMonitor(server *health.Server, services []string, interval, timeout time.Duration, checks ...HealthCheck) *HealthMonitor {
+	m := &HealthMonitor{
+		server:   server,
+		services: services,
+		checks:   checks,
+		interval: interval,
+		timeout:  timeout,
+		update:   make(chan struct{}, 1),
+		failing:  make(map[string]bool),
+	}
+	for _, service := range services {
+		server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	for _, check := range checks {
+		server.SetServingStatus(check.Service, healthpb.HealthCheckResponse_NOT_SERVING)
+		m.failing[check.Service] = true
+	}
+	return m
+}
+
+// Update runs the checks now instead of waiting for the next interval
+func (m *HealthMonitor) Update() {
+	select {
+	case m.update <- struct{}{}:
+	default:
+	}
+}
+
+// Run checks until ctx is done
+func (m *HealthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.update:
+		}
+	}
+}
+
+func (m *HealthMonitor) check(ctx context.Context) {
+	serving := true
+	for _, check := range m.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, m.timeout)
+		err := check.Check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			serving = false
+		}
+		if failing := err != nil; failing != m.failing[check.Service] {
+			m.failing[check.Service] = failing
+			if failing {
+				logger.Error("health check failed", zap.String("service", check.Service), zap.Error(err))
+			} else {
+				logger.Info("health check passed", zap.String("service", check.Service))
+			}
+		}
+		m.server.SetServingStatus(check.Service, status)
+	}
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	for _, service := range m.services {
+		m.server.SetServingStatus(service, status)
+	}
+}
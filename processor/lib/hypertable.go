@@ -0,0 +1,178 @@
+package lib
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// Hypertable is the time partitioning of a timescale hypertable
+type Hypertable struct {
+	Name          string
+	TimeColumn    string
+	ChunkInterval time.Duration
+}
+
+// DiscoverHypertable reads the time dimension of table from timescaledb_information.dimensions
+func DiscoverHypertable(ctx context.Context, conn *pgxpool.Pool, table string) (Hypertable, error) {
+	h := Hypertable{Name: table}
+	var intervalSeconds *float64
+	err := conn.QueryRow(ctx, `
+		SELECT column_name, EXTRACT(EPOCH FROM time_interval)::float8
+		FROM timescaledb_information.dimensions
+		WHERE hypertable_schema = current_schema()
+		  AND hypertable_name = $1
+		  AND dimension_type = 'Time'
+		ORDER BY dimension_number
+		LIMIT 1
+	`, table).Scan(&h.TimeColumn, &intervalSeconds)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return h, errors.Errorf("table %s isn't a hypertable with a time dimension", table)
+	}
+	if err != nil {
+		return h, errors.Wrapf(err, "failed querying dimensions of %s", table)
+	}
+	if intervalSeconds == nil || *intervalSeconds <= 0 {
+		return h, errors.Errorf("time dimension %s of %s has no time interval", h.TimeColumn, table)
+	}
+	h.ChunkInterval = time.Duration(*intervalSeconds * float64(time.Second))
+	// chunks are computed in microseconds like timescale does
+	if h.ChunkInterval < time.Microsecond {
+		return h, errors.Errorf("time dimension %s of %s has an interval under a microsecond", h.TimeColumn, table)
+	}
+	return h, nil
+}
+
+// ChunkStart returns the start of the chunk t falls in, timescale aligns chunks on the unix epoch
+func (h Hypertable) ChunkStart(t time.Time) time.Time {
+	interval := h.ChunkInterval.Microseconds()
+	us := t.UnixMicro()
+	start := us / interval * interval
+	if us < 0 && us%interval != 0 {
+		start -= interval
+	}
+	return time.UnixMicro(start).UTC()
+}
+
+// ChunkGroups buffers rows per hypertable chunk so every batch only touches one chunk,
+// it isn't safe for concurrent use
+type ChunkGroups[T any] struct {
+	hypertable Hypertable
+	timeOf     func(*T) time.Time
+	groups     map[time.Time][]T
+	free       [][]T
+}
+
+func NewChunkGroups[T any](hypertable Hypertable, timeOf func(*T) time.Time) *ChunkGroups[T] {
+	return &ChunkGroups[T]{
+		hypertable: hypertable,
+		timeOf:     timeOf,
+		groups:     make(map[time.Time][]T),
+	}
+}
+
+// Add buffers row and returns the chunk it belongs to
+func (g *ChunkGroups[T]) Add(row T) time.Time {
+	chunk := g.hypertable.ChunkStart(g.timeOf(&row))
+	rows, found := g.groups[chunk]
+	if !found && len(g.free) > 0 {
+		rows = g.free[len(g.free)-1]
+		g.free = g.free[:len(g.free)-1]
+	}
+	g.groups[chunk] = append(rows, row)
+	return chunk
+}
+
+func (g *ChunkGroups[T]) Len(chunk time.Time) int {
+	return len(g.groups[chunk])
+}
+
+// Open returns the number of chunks with buffered rows
+func (g *ChunkGroups[T]) Open() int {
+	return len(g.groups)
+}
+
+// Largest returns the chunk with the most buffered rows
+func (g *ChunkGroups[T]) Largest() time.Time {
+	var largest time.Time
+	size := -1
+	for chunk, rows := range g.groups {
+		if len(rows) > size || (len(rows) == size && chunk.Before(largest)) {
+			largest, size = chunk, len(rows)
+		}
+	}
+	return largest
+}
+
+// Chunks returns the chunks with buffered rows, oldest first
+func (g *ChunkGroups[T]) Chunks() []time.Time {
+	chunks := make([]time.Time, 0, len(g.groups))
+	for chunk := range g.groups {
+		chunks = append(chunks, chunk)
+	}
+	slices.SortFunc(chunks, func(a, b time.Time) int { return a.Compare(b) })
+	return chunks
+}
+
+// Take removes the rows of chunk sorted by time, they must be handed back with Release once encoded
+func (g *ChunkGroups[T]) Take(chunk time.Time) []T {
+	rows := g.groups[chunk]
+	delete(g.groups, chunk)
+	slices.SortStableFunc(rows, func(a, b T) int { return g.timeOf(&a).Compare(g.timeOf(&b)) })
+	return rows
+}
+
+func (g *ChunkGroups[T]) Release(rows []T) {
+	clear(rows)
+	g.free = append(g.free, rows[:0])
+}
+
+type ChunkStats struct {
+	Start   time.Time
+	End     time.Time
+	Rows    int64
+	Batches int64
+}
+
+// ChunkStatsRecorder collects the rows and batches written to every chunk across workers
+type ChunkStatsRecorder struct {
+	interval time.Duration
+	mu       sync.Mutex
+	chunks   map[time.Time]*ChunkStats
+}
+
+func NewChunkStatsRecorder(hypertable Hypertable) *ChunkStatsRecorder {
+	return &ChunkStatsRecorder{
+		interval: hypertable.ChunkInterval,
+		chunks:   make(map[time.Time]*ChunkStats),
+	}
+}
+
+func (r *ChunkStatsRecorder) Record(chunk time.Time, rows int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, found := r.chunks[chunk]
+	if !found {
+		stats = &ChunkStats{Start: chunk, End: chunk.Add(r.interval)}
+		r.chunks[chunk] = stats
+	}
+	stats.Rows += rows
+	stats.Batches++
+}
+
+// Stats returns the recorded chunks, oldest first
+func (r *ChunkStatsRecorder) Stats() []ChunkStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]ChunkStats, 0, len(r.chunks))
+	for _, stats := range r.chunks {
+		res = append(res, *stats)
+	}
+	slices.SortFunc(res, func(a, b ChunkStats) int { return a.Start.Compare(b.Start) })
+	return res
+}
@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestChunkStart(t *testing.T) {
+	day := Hypertable{ChunkInterval: 24 * time.Hour}
+	week := Hypertable{ChunkInterval: 7 * 24 * time.Hour}
+	epoch := time.Unix(0, 0).UTC()
+	tests := []struct {
+		name       string
+		hypertable Hypertable
+		t          time.Time
+		want       time.Time
+	}{
+		{"epoch", day, epoch, epoch},
+		{"aligned", day, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"mid chunk", day, time.Date(2025, 1, 2, 17, 3, 0, 0, time.UTC), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"last nanosecond", day, time.Date(2025, 1, 2, 23, 59, 59, 999999999, time.UTC), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"other zone", day, time.Date(2025, 1, 2, 1, 0, 0, 0, time.FixedZone("CET", 3600)), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"week from a thursday", week, time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"just after epoch", day, epoch.Add(time.Nanosecond), epoch},
+		{"pre-epoch aligned", day, epoch.Add(-24 * time.Hour), epoch.Add(-24 * time.Hour)},
+		{"pre-epoch mid chunk", day, epoch.Add(-time.Hour), epoch.Add(-24 * time.Hour)},
+		{"one microsecond before epoch", day, epoch.Add(-time.Microsecond), epoch.Add(-24 * time.Hour)},
+		{"one nanosecond before epoch", day, epoch.Add(-time.Nanosecond), epoch.Add(-24 * time.Hour)},
+		{"pre-epoch sub-microsecond", day, epoch.Add(-48*time.Hour - 500*time.Nanosecond), epoch.Add(-72 * time.Hour)},
+		{"1900", day, time.Date(1900, 3, 1, 12, 0, 0, 0, time.UTC), time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"pre-epoch week", week, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(1969, 12, 25, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tt.hypertable.ChunkStart(tt.t); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+type timedRow struct {
+	id int
+	at time.Time
+}
+
+func TestChunkGroups(t *testing.T) {
+	hypertable := Hypertable{ChunkInterval: 24 * time.Hour}
+	groups := NewChunkGroups(hypertable, func(r *timedRow) time.Time { return r.at })
+	epoch := time.Unix(0, 0).UTC()
+	rows := []timedRow{
+		{1, epoch.Add(30 * time.Hour)},
+		{2, epoch.Add(-time.Nanosecond)},
+		{3, epoch.Add(25 * time.Hour)},
+		{4, epoch.Add(-23 * time.Hour)},
+		{5, epoch.Add(time.Hour)},
+	}
+	for _, row := range rows {
+		groups.Add(row)
+	}
+	dayBefore, day1 := epoch.Add(-24*time.Hour), epoch.Add(24*time.Hour)
+	if groups.Open() != 3 || groups.Len(dayBefore) != 2 || groups.Len(day1) != 2 || groups.Len(epoch) != 1 {
+		t.Fatalf("unexpected groups, %d open with %d/%d/%d rows",
+			groups.Open(), groups.Len(dayBefore), groups.Len(epoch), groups.Len(day1))
+	}
+	if !slices.Equal(groups.Chunks(), []time.Time{dayBefore, epoch, day1}) {
+		t.Fatalf("expected the chunks oldest first, got %v", groups.Chunks())
+	}
+	// the oldest of two equally full chunks is the largest
+	if !groups.Largest().Equal(dayBefore) {
+		t.Fatalf("expected the pre-epoch chunk as largest, got %v", groups.Largest())
+	}
+
+	taken := groups.Take(dayBefore)
+	if len(taken) != 2 || taken[0].id != 4 || taken[1].id != 2 {
+		t.Fatalf("expected the rows of the chunk sorted by time, got %v", taken)
+	}
+	if groups.Open() != 2 || groups.Len(dayBefore) != 0 {
+		t.Fatal("expected the taken chunk to be removed")
+	}
+	groups.Release(taken)
+	// a new chunk reuses the released buffer
+	groups.Add(timedRow{6, epoch.Add(-50 * time.Hour)})
+	if reused := groups.Take(epoch.Add(-72 * time.Hour)); len(reused) != 1 || cap(reused) != cap(taken) || reused[0].id != 6 {
+		t.Fatalf("expected the released buffer to be reused, got %v with cap %d", reused, cap(reused))
+	}
+}
+
+func TestChunkStatsRecorder(t *testing.T) {
+	hypertable := Hypertable{ChunkInterval: time.Hour}
+	recorder := NewChunkStatsRecorder(hypertable)
+	late, early := time.Unix(3600, 0).UTC(), time.Unix(-3600, 0).UTC()
+	recorder.Record(late, 10)
+	recorder.Record(early, 5)
+	recorder.Record(late, 7)
+	stats := recorder.Stats()
+	want := []ChunkStats{
+		{Start: early, End: early.Add(time.Hour), Rows: 5, Batches: 1},
+		{Start: late, End: late.Add(time.Hour), Rows: 17, Batches: 2},
+	}
+	if !slices.Equal(stats, want) {
+		t.Fatalf("expected %v, got %v", want, stats)
+	}
+}
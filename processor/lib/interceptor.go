@@ -0,0 +1,188 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"runtime/debug"
+	"time"
+
+	"processor/logger"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader carries the request id in both directions, a caller may send its job id instead
+const (
+	RequestIDHeader = "x-request-id"
+	JobIDHeader     = "x-job-id"
+)
+
+type requestIDKey struct{}
+
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// maxRequestIDLength bounds the client supplied ids copied into every log line and span
+const maxRequestIDLength = 128
+
+// validRequestID accepts the ids of common tracing and job systems, anything else could
+// forge log fields or bloat every line of the request
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// incomingRequestID keeps the id sent by the caller when it's valid and generates one otherwise
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range []string{RequestIDHeader, JobIDHeader} {
+			if values := md.Get(header); len(values) > 0 && validRequestID(values[0]) {
+				return values[0]
+			}
+		}
+	}
+	return newRequestID()
+}
+
+// startRPC scopes the logger of ctx to the request
+func startRPC(ctx context.Context, fullMethod string, datasets map[string]string, req any) context.Context {
+	id := incomingRequestID(ctx)
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	fields := []zap.Field{zap.String("requestId", id), zap.String("method", fullMethod)}
+	if dataset := datasets[fullMethod]; dataset != "" {
+		fields = append(fields, zap.String("dataset", dataset))
+	}
+	if file := requestFile(req); file != "" {
+		fields = append(fields, zap.String("file", file))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		fields = append(fields, zap.String("traceId", span.TraceID().String()))
+	}
+	ctx = logger.WithFields(ctx, fields...)
+	// probes would drown the request logs
+	if publicMethod(fullMethod) {
+		logger.FromContext(ctx).Debug("rpc started")
+	} else {
+		logger.FromContext(ctx).Info("rpc started")
+	}
+	return ctx
+}
+
+func requestFile(req any) string {
+	if r, ok := req.(interface{ GetInputFile() string }); ok && r.GetInputFile() != "" {
+		return r.GetInputFile()
+	}
+	if r, ok := req.(interface{ GetFileName() string }); ok {
+		return r.GetFileName()
+	}
+	return ""
+}
+
+// recoverRPC turns a handler panic into codes.Internal instead of crashing the server
+func recoverRPC(ctx context.Context, recovered any) error {
+	logger.FromContext(ctx).Error("recovered panic in rpc",
+		zap.Any("panic", recovered),
+		zap.ByteString("stack", debug.Stack()),
+	)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// Recovered wraps a goroutine of an rpc, such as an errgroup worker, so its panic fails the rpc
+// with an error instead of crashing the server, recoverRPC only covers the handler goroutine
+func Recovered(ctx context.Context, fn func() error) func() error {
+	return func() (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.FromContext(ctx).Error("recovered panic in rpc worker",
+					zap.Any("panic", recovered),
+					zap.ByteString("stack", debug.Stack()),
+				)
+				err = errors.New("recovered panic in rpc worker")
+			}
+		}()
+		return fn()
+	}
+}
+
+func endRPC(ctx context.Context, fullMethod string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []zap.Field{zap.Duration("duration", time.Since(start)), zap.String("code", code.String())}
+	log := logger.FromContext(ctx)
+	switch {
+	case code == codes.OK && publicMethod(fullMethod):
+		log.Debug("rpc finished", fields...)
+	case code == codes.OK:
+		log.Info("rpc finished", fields...)
+	case code == codes.Internal, code == codes.Unknown, code == codes.DataLoss, code == codes.Unavailable:
+		log.Error("rpc finished", append(fields, zap.Error(err))...)
+	default:
+		log.Warn("rpc finished", append(fields, zap.Error(err))...)
+	}
+}
+
+// UnaryRequestInterceptor assigns the request id, logs the rpc and recovers its panics,
+// it must be the first interceptor so the others log with the request id
+func UnaryRequestInterceptor(datasets map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		ctx = startRPC(ctx, info.FullMethod, datasets, req)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, RequestIDFrom(ctx)))
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				resp, err = nil, recoverRPC(ctx, recovered)
+			}
+			endRPC(ctx, info.FullMethod, start, err)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func StreamRequestInterceptor(datasets map[string]string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		ctx := startRPC(ss.Context(), info.FullMethod, datasets, nil)
+		_ = ss.SetHeader(metadata.Pairs(RequestIDHeader, RequestIDFrom(ctx)))
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoverRPC(ctx, recovered)
+			}
+			endRPC(ctx, info.FullMethod, start, err)
+		}()
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextStream replaces the context of a stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,90 @@
+package lib
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryRequestInterceptorPropagatesRequestID(t *testing.T) {
+	interceptor := UnaryRequestInterceptor(nil)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(JobIDHeader, "job-42"))
+	var got string
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/TransformService/ProcessTesting"},
+		func(ctx context.Context, req any) (any, error) {
+			got = RequestIDFrom(ctx)
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "job-42" {
+		t.Fatalf("expected the job id as request id, got %q", got)
+	}
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/TransformService/ProcessTesting"},
+		func(ctx context.Context, req any) (any, error) {
+			got = RequestIDFrom(ctx)
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 32 {
+		t.Fatalf("expected a generated request id, got %q", got)
+	}
+}
+
+func TestIncomingRequestIDRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string
+	}{
+		{"request id", metadata.Pairs(RequestIDHeader, "req-1.a:B_2"), "req-1.a:B_2"},
+		{"job id fallback", metadata.Pairs(RequestIDHeader, "bad id", JobIDHeader, "job-42"), "job-42"},
+		{"newline", metadata.Pairs(RequestIDHeader, "a\nlevel=error"), ""},
+		{"quote", metadata.Pairs(RequestIDHeader, `a"b`), ""},
+		{"too long", metadata.Pairs(RequestIDHeader, strings.Repeat("a", maxRequestIDLength+1)), ""},
+		{"max length", metadata.Pairs(RequestIDHeader, strings.Repeat("a", maxRequestIDLength)), strings.Repeat("a", maxRequestIDLength)},
+	}
+	for _, tt := range tests {
+		got := incomingRequestID(metadata.NewIncomingContext(context.Background(), tt.md))
+		if tt.want != "" && got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+		// an invalid id is replaced by a generated one
+		if tt.want == "" && len(got) != 32 {
+			t.Errorf("%s: expected a generated id, got %q", tt.name, got)
+		}
+	}
+}
+
+func TestRecoveredReturnsError(t *testing.T) {
+	err := Recovered(context.Background(), func() error { panic("boom") })()
+	if err == nil {
+		t.Fatal("expected the panic as an error")
+	}
+	if err := Recovered(context.Background(), func() error { return nil })(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnaryRequestInterceptorRecoversPanic(t *testing.T) {
+	interceptor := UnaryRequestInterceptor(nil)
+	resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/TransformService/ProcessNYCTrip"},
+		func(ctx context.Context, req any) (any, error) {
+			panic("boom")
+		})
+	if resp != nil {
+		t.Fatalf("expected no response, got %v", resp)
+	}
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected internal, got %v", err)
+	}
+}
@@ -0,0 +1,826 @@
+package lib
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"processor/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrJobNotFound      = errors.New("job doesn't exist")
+	ErrJobFinished      = errors.New("job already finished")
+	ErrJobCancelled     = errors.New("job was cancelled")
+	ErrInvalidPageToken = errors.New("invalid page token")
+
+	// errJobLost means another processor requeued the job after missed heartbeats
+	errJobLost     = errors.New("job was requeued by another processor")
+	errPoolStopped = errors.New("processor is shutting down")
+	// errJobWritten fails a stopped job instead of running it again, rows aren't upserted
+	errJobWritten = errors.New("job stopped after writing batches, submitting it again would insert them twice")
+)
+
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// Job is a load persisted in processor_job, Options and Result are json documents of the dataset
+type Job struct {
+	ID              string
+	Dataset         string
+	InputFile       string
+	RemoteFilePath  string
+	Options         []byte
+	State           JobState
+	SubmittedBy     string
+	Attempts        int
+	CancelRequested bool
+	Result          []byte
+	// WritesStarted is set before the first batch is written
+	WritesStarted bool
+	// Progress is the last ProgressSnapshot saved by the processor running the job
+	Progress    []byte
+	ErrorCode   string
+	Error       string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	HeartbeatAt *time.Time
+	FinishedAt  *time.Time
+}
+
+type JobConfig struct {
+	Workers int
+	// PollInterval bounds how long a queued job waits when it was submitted to another processor
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	// StaleAfter requeues running jobs whose processor stopped heartbeating
+	StaleAfter time.Duration
+	// MaxAttempts fails a stale job instead of requeueing it, a job crashing its processor
+	// would otherwise crash every processor in turn
+	MaxAttempts int
+}
+
+func (c JobConfig) Validate() error {
+	if c.Workers < 1 {
+		return errors.Errorf("job workers must be positive, got %d", c.Workers)
+	}
+	if c.PollInterval <= 0 {
+		return errors.Errorf("job poll interval must be positive, got %v", c.PollInterval)
+	}
+	if c.HeartbeatInterval <= 0 || c.HeartbeatInterval >= c.StaleAfter {
+		return errors.Errorf("job heartbeat interval must be positive and below the stale timeout %v, got %v", c.StaleAfter, c.HeartbeatInterval)
+	}
+	if c.MaxAttempts < 1 {
+		return errors.Errorf("job max attempts must be positive, got %d", c.MaxAttempts)
+	}
+	return nil
+}
+
+const jobColumns = `
+	id::text, dataset, input_file, coalesce(remote_file_path, ''), options, state, coalesce(submitted_by, ''),
+	attempts, cancel_requested, writes_started, result, progress, coalesce(error_code, ''), coalesce(error, ''),
+	created_at, started_at, heartbeat_at, finished_at
+`
+
+func scanJob(row pgx.Row) (*Job, error) {
+	job := &Job{}
+	err := row.Scan(
+		&job.ID, &job.Dataset, &job.InputFile, &job.RemoteFilePath, &job.Options, &job.State, &job.SubmittedBy,
+		&job.Attempts, &job.CancelRequested, &job.WritesStarted, &job.Result, &job.Progress, &job.ErrorCode, &job.Error,
+		&job.CreatedAt, &job.StartedAt, &job.HeartbeatAt, &job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// JobStore keeps the jobs in processor_job, every processor sharing the database sees the same queue
+type JobStore struct {
+	conn *pgxpool.Pool
+}
+
+func NewJobStore(conn *pgxpool.Pool) *JobStore {
+	return &JobStore{conn: conn}
+}
+
+// Insert queues job, or returns the queued or running job of the same input
+func (s *JobStore) Insert(ctx context.Context, job *Job) (*Job, error) {
+	// the active job may finish between the insert and the lookup, the insert then succeeds on a retry
+	for range 3 {
+		inserted, err := scanJob(s.conn.QueryRow(ctx, `
+			INSERT INTO processor_job (
+				id, dataset, input_file, remote_file_path, options, state, submitted_by,
+				attempts, cancel_requested, created_at
+			) VALUES ($1, $2, $3, NULLIF($4, ''), $5, 'queued', NULLIF($6, ''), 0, false, $7)
+			ON CONFLICT (input_file) WHERE state IN ('queued', 'running') DO NOTHING
+			RETURNING `+jobColumns,
+			uuid.NewString(), job.Dataset, job.InputFile, job.RemoteFilePath, job.Options, job.SubmittedBy, time.Now().UTC(),
+		))
+		if err == nil {
+			return inserted, nil
+		}
+		if err != pgx.ErrNoRows {
+			return nil, errors.Wrap(err, "failed inserting processor_job")
+		}
+		active, err := scanJob(s.conn.QueryRow(ctx, `
+			SELECT `+jobColumns+` FROM processor_job
+			WHERE input_file = $1 AND state IN ('queued', 'running')
+		`, job.InputFile))
+		if err == nil {
+			return active, nil
+		}
+		if err != pgx.ErrNoRows {
+			return nil, errors.Wrap(err, "failed querying processor_job")
+		}
+	}
+	return nil, errors.Errorf("failed queueing %s, its active job keeps changing", job.InputFile)
+}
+
+func (s *JobStore) Get(ctx context.Context, id string) (*Job, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.Wrap(ErrJobNotFound, id)
+	}
+	job, err := scanJob(s.conn.QueryRow(ctx, `SELECT `+jobColumns+` FROM processor_job WHERE id = $1`, id))
+	if err == pgx.ErrNoRows {
+		return nil, errors.Wrap(ErrJobNotFound, id)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed querying job %s", id)
+	}
+	return job, nil
+}
+
+// pageToken encodes the position of the last job of a page
+func pageToken(job *Job) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(job.CreatedAt.Format(time.RFC3339Nano) + "," + job.ID))
+}
+
+func parsePageToken(token string) (*time.Time, *string, error) {
+	if token == "" {
+		return nil, nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, nil, ErrInvalidPageToken
+	}
+	createdAt, id, found := strings.Cut(string(raw), ",")
+	if !found {
+		return nil, nil, ErrInvalidPageToken
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, nil, ErrInvalidPageToken
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil, ErrInvalidPageToken
+	}
+	return &t, &id, nil
+}
+
+// List returns the most recent jobs first, an empty state or submittedBy matches every job
+func (s *JobStore) List(ctx context.Context, state JobState, submittedBy string, pageSize int, token string) ([]*Job, string, error) {
+	afterTime, afterID, err := parsePageToken(token)
+	if err != nil {
+		return nil, "", err
+	}
+	// one more row tells whether there is a next page
+	rows, err := s.conn.Query(ctx, `
+		SELECT `+jobColumns+` FROM processor_job
+		WHERE ($1 = '' OR state = $1)
+		AND ($5 = '' OR submitted_by = $5)
+		AND ($2::timestamp IS NULL OR (created_at, id) < ($2, $3::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`, string(state), afterTime, afterID, pageSize+1, submittedBy)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed querying processor_job")
+	}
+	defer rows.Close()
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed scanning processor_job")
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", errors.Wrap(err, "failed querying processor_job")
+	}
+	if len(jobs) <= pageSize {
+		return jobs, "", nil
+	}
+	jobs = jobs[:pageSize]
+	return jobs, pageToken(jobs[pageSize-1]), nil
+}
+
+// Claim starts the oldest queued job, it returns nil when the queue is empty
+func (s *JobStore) Claim(ctx context.Context) (*Job, error) {
+	now := time.Now().UTC()
+	job, err := scanJob(s.conn.QueryRow(ctx, `
+		UPDATE processor_job
+		SET state = 'running', attempts = attempts + 1, started_at = $1, heartbeat_at = $1
+		WHERE id = (
+			SELECT id FROM processor_job
+			WHERE state = 'queued'
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		now,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed claiming job")
+	}
+	return job, nil
+}
+
+// Heartbeat marks a running job alive, saves its progress and reports whether a cancel was requested.
+// The updates of a job are conditioned on its attempt so a requeued run can't overwrite the next one.
+func (s *JobStore) Heartbeat(ctx context.Context, job *Job, progress []byte) (bool, error) {
+	var cancelRequested bool
+	err := s.conn.QueryRow(ctx, `
+		UPDATE processor_job SET heartbeat_at = $3, progress = $4
+		WHERE id = $1 AND attempts = $2 AND state = 'running'
+		RETURNING cancel_requested
+	`, job.ID, job.Attempts, time.Now().UTC(), progress).Scan(&cancelRequested)
+	if err == pgx.ErrNoRows {
+		return false, errJobLost
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed heartbeating job %s", job.ID)
+	}
+	return cancelRequested, nil
+}
+
+// MarkWriting records that job is about to write its first batch
+func (s *JobStore) MarkWriting(ctx context.Context, job *Job) error {
+	tag, err := s.conn.Exec(ctx, `
+		UPDATE processor_job SET writes_started = true
+		WHERE id = $1 AND attempts = $2 AND state = 'running'
+	`, job.ID, job.Attempts)
+	if err != nil {
+		return errors.Wrapf(err, "failed marking job %s as writing", job.ID)
+	}
+	if tag.RowsAffected() == 0 {
+		return errJobLost
+	}
+	return nil
+}
+
+// Finish records the outcome of a running job
+func (s *JobStore) Finish(ctx context.Context, job *Job, state JobState, result, progress []byte, errorCode, message string) error {
+	tag, err := s.conn.Exec(ctx, `
+		UPDATE processor_job
+		SET state = $3, result = $4, progress = $5, error_code = NULLIF($6, ''), error = NULLIF($7, ''), finished_at = $8
+		WHERE id = $1 AND attempts = $2 AND state = 'running'
+	`, job.ID, job.Attempts, string(state), result, progress, errorCode, message, time.Now().UTC())
+	if err != nil {
+		return errors.Wrapf(err, "failed finishing job %s", job.ID)
+	}
+	if tag.RowsAffected() == 0 {
+		return errJobLost
+	}
+	return nil
+}
+
+// Requeue gives a running job back to the queue and returns its new state, a job that
+// started writing is failed with its progress instead
+func (s *JobStore) Requeue(ctx context.Context, job *Job, progress []byte) (JobState, error) {
+	var state JobState
+	err := s.conn.QueryRow(ctx, `
+		UPDATE processor_job
+		SET state = CASE WHEN writes_started THEN 'failed' ELSE 'queued' END,
+			finished_at = CASE WHEN writes_started THEN $3::timestamp END,
+			started_at = CASE WHEN writes_started THEN started_at END,
+			progress = CASE WHEN writes_started THEN $4::jsonb END,
+			error_code = CASE WHEN writes_started THEN $5 END,
+			error = CASE WHEN writes_started THEN $6 END,
+			heartbeat_at = NULL
+		WHERE id = $1 AND attempts = $2 AND state = 'running'
+		RETURNING state
+	`, job.ID, job.Attempts, time.Now().UTC(), progress, codes.Aborted.String(), errJobWritten.Error()).Scan(&state)
+	if err == pgx.ErrNoRows {
+		return "", errJobLost
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed requeueing job %s", job.ID)
+	}
+	return state, nil
+}
+
+// RequeueStale requeues the running jobs not heartbeated since before and returns how many
+// were requeued and failed. The ones with a pending cancel are cancelled, the ones that
+// started writing or reached maxAttempts are failed instead.
+func (s *JobStore) RequeueStale(ctx context.Context, before time.Time, maxAttempts int) (requeued, failed int64, err error) {
+	rows, err := s.conn.Query(ctx, `
+		UPDATE processor_job AS j
+		SET state = stale.state,
+			finished_at = CASE WHEN stale.state <> 'queued' THEN $2::timestamp END,
+			started_at = CASE WHEN stale.state <> 'queued' THEN j.started_at END,
+			progress = CASE WHEN stale.state <> 'queued' THEN j.progress END,
+			error_code = CASE WHEN stale.state = 'failed' THEN $4 ELSE j.error_code END,
+			error = CASE
+				WHEN stale.state = 'failed' AND j.writes_started THEN $5
+				WHEN stale.state = 'failed' THEN $6
+				ELSE j.error
+			END,
+			heartbeat_at = NULL
+		FROM (
+			SELECT id, CASE
+				WHEN cancel_requested THEN 'cancelled'
+				WHEN writes_started OR attempts >= $3 THEN 'failed'
+				ELSE 'queued'
+			END AS state
+			FROM processor_job
+			WHERE state = 'running' AND heartbeat_at < $1
+			FOR UPDATE SKIP LOCKED
+		) AS stale
+		WHERE j.id = stale.id
+		RETURNING stale.state
+	`, before, time.Now().UTC(), maxAttempts, codes.Aborted.String(), errJobWritten.Error(),
+		fmt.Sprintf("job stopped its processor %d times", maxAttempts))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed requeueing stale jobs")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var state JobState
+		if err := rows.Scan(&state); err != nil {
+			return 0, 0, errors.Wrap(err, "failed requeueing stale jobs")
+		}
+		switch state {
+		case JobQueued:
+			requeued++
+		case JobFailed:
+			failed++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, errors.Wrap(err, "failed requeueing stale jobs")
+	}
+	return requeued, failed, nil
+}
+
+// Cancel cancels a queued job at once and flags a running one for its processor
+func (s *JobStore) Cancel(ctx context.Context, id string) (*Job, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.Wrap(ErrJobNotFound, id)
+	}
+	job, err := scanJob(s.conn.QueryRow(ctx, `
+		UPDATE processor_job
+		SET state = CASE WHEN state = 'queued' THEN 'cancelled' ELSE state END,
+			finished_at = CASE WHEN state = 'queued' THEN $2 ELSE finished_at END,
+			error_code = CASE WHEN state = 'queued' THEN $3 ELSE error_code END,
+			error = CASE WHEN state = 'queued' THEN $4 ELSE error END,
+			cancel_requested = true
+		WHERE id = $1 AND state IN ('queued', 'running')
+		RETURNING `+jobColumns,
+		id, time.Now().UTC(), codes.Canceled.String(), ErrJobCancelled.Error(),
+	))
+	if err == pgx.ErrNoRows {
+		job, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return job, errors.Wrapf(ErrJobFinished, "job %s is %s", id, job.State)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed cancelling job %s", id)
+	}
+	return job, nil
+}
+
+// jobQueue is the queue of the pool, JobStore shares it through processor_job
+type jobQueue interface {
+	Insert(ctx context.Context, job *Job) (*Job, error)
+	Get(ctx context.Context, id string) (*Job, error)
+	List(ctx context.Context, state JobState, submittedBy string, pageSize int, token string) ([]*Job, string, error)
+	Claim(ctx context.Context) (*Job, error)
+	Heartbeat(ctx context.Context, job *Job, progress []byte) (bool, error)
+	MarkWriting(ctx context.Context, job *Job) error
+	Finish(ctx context.Context, job *Job, state JobState, result, progress []byte, errorCode, message string) error
+	Requeue(ctx context.Context, job *Job, progress []byte) (JobState, error)
+	RequeueStale(ctx context.Context, before time.Time, maxAttempts int) (int64, int64, error)
+	Cancel(ctx context.Context, id string) (*Job, error)
+}
+
+type writeGuardKey struct{}
+
+// writeGuard marks a job as writing before its first batch
+type writeGuard struct {
+	mu     sync.Mutex
+	marked bool
+	mark   func(ctx context.Context) error
+}
+
+func (g *writeGuard) before(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.marked {
+		return nil
+	}
+	if err := g.mark(ctx); err != nil {
+		return err
+	}
+	g.marked = true
+	return nil
+}
+
+// GuardWrites wraps insert so the job running in ctx is marked as writing before its first
+// batch, a job that may have committed rows is failed rather than run again from scratch.
+// insert is returned as is outside of a job.
+func GuardWrites(ctx context.Context, insert BatchInsertFunc) BatchInsertFunc {
+	guard, ok := ctx.Value(writeGuardKey{}).(*writeGuard)
+	if !ok {
+		return insert
+	}
+	return func(ctx context.Context, tbl *TableInsert) (int64, int64, error) {
+		if err := guard.before(ctx); err != nil {
+			return 0, 0, err
+		}
+		return insert(ctx, tbl)
+	}
+}
+
+// JobFunc runs a claimed job and returns its json result
+type JobFunc func(ctx context.Context, job *Job) ([]byte, error)
+
+// jobWriteTimeout bounds recording the outcome of a job once it stopped
+const jobWriteTimeout = 5 * time.Second
+
+// JobPool runs the queued jobs on a bounded number of workers
+type JobPool struct {
+	store jobQueue
+	cfg   JobConfig
+	run   JobFunc
+	ready func() bool
+
+	wake chan struct{}
+	stop chan struct{}
+	// ctx is cancelled once the shutdown grace period expired
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]*runningJob
+}
+
+type runningJob struct {
+	cancel   context.CancelCauseFunc
+	progress *Progress
+}
+
+// NewJobPool runs jobs with run, they are only claimed while ready reports true
+func NewJobPool(store *JobStore, cfg JobConfig, run JobFunc, ready func() bool) *JobPool {
+	return newJobPool(store, cfg, run, ready)
+}
+
+func newJobPool(store jobQueue, cfg JobConfig, run JobFunc, ready func() bool) *JobPool {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	return &JobPool{
+		store:   store,
+		cfg:     cfg,
+		run:     run,
+		ready:   ready,
+		wake:    make(chan struct{}, cfg.Workers),
+		stop:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]*runningJob),
+	}
+}
+
+// Start runs the workers and the requeueing of stale jobs until Stop
+func (p *JobPool) Start() {
+	p.wg.Add(p.cfg.Workers + 1)
+	for range p.cfg.Workers {
+		go func() {
+			defer p.wg.Done()
+			p.work()
+		}()
+	}
+	go func() {
+		defer p.wg.Done()
+		p.requeueStale()
+	}()
+	logger.Info("started job workers", zap.Int("workers", p.cfg.Workers))
+}
+
+func (p *JobPool) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// wait returns false once the pool is stopping
+func (p *JobPool) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-p.stop:
+		return false
+	case <-p.wake:
+		return true
+	case <-timer.C:
+		return true
+	}
+}
+
+func (p *JobPool) work() {
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+		if !p.ready() {
+			if !p.wait(p.cfg.PollInterval) {
+				return
+			}
+			continue
+		}
+		job, err := p.store.Claim(p.ctx)
+		if err != nil {
+			logger.Error("failed claiming job", zap.Error(err))
+		}
+		if job == nil {
+			if !p.wait(p.cfg.PollInterval) {
+				return
+			}
+			continue
+		}
+		p.execute(job)
+	}
+}
+
+func (p *JobPool) requeueStale() {
+	for {
+		requeued, failed, err := p.store.RequeueStale(p.ctx, time.Now().UTC().Add(-p.cfg.StaleAfter), p.cfg.MaxAttempts)
+		if err != nil {
+			logger.Error("failed requeueing stale jobs", zap.Error(err))
+		}
+		if failed > 0 {
+			logger.Warn("failed jobs of a stopped processor instead of running them again", zap.Int64("jobs", failed))
+			JobsFinished.WithLabelValues("", string(JobFailed)).Add(float64(failed))
+		}
+		if requeued > 0 {
+			logger.Warn("requeued jobs of a stopped processor", zap.Int64("jobs", requeued))
+			for range requeued {
+				p.notify()
+			}
+		}
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(p.cfg.StaleAfter / 2):
+		}
+	}
+}
+
+func (p *JobPool) execute(job *Job) {
+	ctx, cancel := context.WithCancelCause(p.ctx)
+	defer cancel(nil)
+	progress := NewProgress()
+	ctx = ContextWithProgress(ctx, progress)
+	p.mu.Lock()
+	p.running[job.ID] = &runningJob{cancel: cancel, progress: progress}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.running, job.ID)
+		p.mu.Unlock()
+	}()
+
+	ctx = context.WithValue(ctx, writeGuardKey{}, &writeGuard{mark: func(ctx context.Context) error {
+		err := p.store.MarkWriting(ctx, job)
+		if errors.Is(err, errJobLost) {
+			cancel(errJobLost)
+		}
+		return err
+	}})
+	ctx = logger.WithFields(ctx,
+		zap.String("jobId", job.ID),
+		zap.String("dataset", job.Dataset),
+		zap.String("file", job.InputFile),
+	)
+	if job.SubmittedBy != "" {
+		ctx = logger.WithFields(ctx, zap.String("caller", job.SubmittedBy))
+		ctx = ContextWithIdentity(ctx, Identity{Subject: job.SubmittedBy})
+	}
+	ctx, span := StartSpan(ctx, "job",
+		attribute.String("job.id", job.ID),
+		attribute.String("input_file", job.InputFile),
+		attribute.Int("job.attempt", job.Attempts),
+	)
+	logger.FromContext(ctx).Info("job started", zap.Int("attempt", job.Attempts))
+	start := time.Now()
+
+	stopHeartbeat := p.heartbeat(ctx, job, progress, cancel)
+	result, err := p.runJob(ctx, job)
+	stopHeartbeat()
+	EndSpan(span, err)
+
+	writeCtx, writeCancel := context.WithTimeout(context.WithoutCancel(ctx), jobWriteTimeout)
+	defer writeCancel()
+	cause := context.Cause(ctx)
+	final := progressJSON(progress)
+	state := JobSucceeded
+	switch {
+	case err == nil:
+		err = p.store.Finish(writeCtx, job, JobSucceeded, result, final, "", "")
+	case errors.Is(cause, errJobLost):
+		logger.FromContext(ctx).Warn("stopped job requeued by another processor")
+		return
+	case errors.Is(cause, errPoolStopped):
+		state, err := p.store.Requeue(writeCtx, job, final)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed requeueing job interrupted by shutdown", zap.Error(err))
+			return
+		}
+		if state == JobFailed {
+			JobsFinished.WithLabelValues(job.Dataset, string(state)).Inc()
+			logger.FromContext(ctx).Warn("failed job interrupted by shutdown after writing batches")
+			return
+		}
+		logger.FromContext(ctx).Warn("requeued job interrupted by shutdown")
+		return
+	case errors.Is(cause, ErrJobCancelled):
+		state = JobCancelled
+		err = p.store.Finish(writeCtx, job, state, nil, final, codes.Canceled.String(), ErrJobCancelled.Error())
+	default:
+		state = JobFailed
+		s := status.Convert(err)
+		err = p.store.Finish(writeCtx, job, state, nil, final, s.Code().String(), s.Message())
+	}
+	JobsFinished.WithLabelValues(job.Dataset, string(state)).Inc()
+	if err != nil {
+		logger.FromContext(ctx).Error("failed recording job outcome", zap.String("state", string(state)), zap.Error(err))
+		return
+	}
+	logger.FromContext(ctx).Info("job finished",
+		zap.String("state", string(state)),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+// runJob runs job, a panic fails the job instead of crashing the processor
+func (p *JobPool) runJob(ctx context.Context, job *Job) (result []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.FromContext(ctx).Error("recovered panic in job",
+				zap.Any("panic", recovered),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			result, err = nil, status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return p.run(ctx, job)
+}
+
+// heartbeat keeps job alive with its progress until stop is called and cancels it when a cancel is requested
+func (p *JobPool) heartbeat(ctx context.Context, job *Job, progress *Progress, cancel context.CancelCauseFunc) (stop func()) {
+	ctx, stopCtx := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.cfg.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cancelRequested, err := p.store.Heartbeat(ctx, job, progressJSON(progress))
+			switch {
+			case errors.Is(err, errJobLost):
+				cancel(errJobLost)
+				return
+			case err != nil:
+				if ctx.Err() == nil {
+					logger.FromContext(ctx).Warn("failed heartbeating job", zap.Error(err))
+				}
+			case cancelRequested:
+				cancel(ErrJobCancelled)
+				return
+			}
+		}
+	}()
+	return func() {
+		stopCtx()
+		<-done
+	}
+}
+
+// Submit queues job and wakes a worker
+func (p *JobPool) Submit(ctx context.Context, job *Job) (*Job, error) {
+	job, err := p.store.Insert(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	p.notify()
+	return job, nil
+}
+
+func (p *JobPool) Get(ctx context.Context, id string) (*Job, error) {
+	return p.store.Get(ctx, id)
+}
+
+func (p *JobPool) List(ctx context.Context, state JobState, submittedBy string, pageSize int, token string) ([]*Job, string, error) {
+	return p.store.List(ctx, state, submittedBy, pageSize, token)
+}
+
+// Cancel cancels a queued job, a running job stops at once on this processor
+// or on the next heartbeat of the processor running it
+func (p *JobPool) Cancel(ctx context.Context, id string) (*Job, error) {
+	job, err := p.store.Cancel(ctx, id)
+	if err != nil {
+		return job, err
+	}
+	p.mu.Lock()
+	running, found := p.running[job.ID]
+	p.mu.Unlock()
+	if found {
+		running.cancel(ErrJobCancelled)
+	}
+	return job, nil
+}
+
+// Progress returns the live progress of a job running on this processor
+func (p *JobPool) Progress(id string) (ProgressSnapshot, bool) {
+	p.mu.Lock()
+	running, found := p.running[id]
+	p.mu.Unlock()
+	if !found {
+		return ProgressSnapshot{}, false
+	}
+	return running.progress.Snapshot(), true
+}
+
+func progressJSON(progress *Progress) []byte {
+	// a snapshot only holds finite numbers and a string, it always encodes
+	data, _ := json.Marshal(progress.Snapshot())
+	return data
+}
+
+// Stopping is closed once the pool stops, watchers then move to another processor
+func (p *JobPool) Stopping() <-chan struct{} {
+	return p.stop
+}
+
+// Running returns the number of jobs running on this processor
+func (p *JobPool) Running() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.running)
+}
+
+// Stop stops claiming jobs and waits grace for the running ones, the jobs still
+// running are then stopped and queued again
+func (p *JobPool) Stop(grace time.Duration) {
+	close(p.stop)
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		logger.Info("stopped job workers")
+		return
+	case <-time.After(grace):
+	}
+	logger.Warn("grace period expired, requeueing running jobs", zap.Int("jobs", p.Running()))
+	p.cancel(errPoolStopped)
+	<-done
+	logger.Info("stopped job workers")
+}
@@ -0,0 +1,349 @@
+package lib
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+)
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	job := &Job{
+		ID:        "7d5c1a0e-2b7f-4c8e-9a51-3f0d6e2b9c41",
+		CreatedAt: time.Date(2025, 3, 1, 12, 30, 0, 123456000, time.UTC),
+	}
+	createdAt, id, err := parsePageToken(pageToken(job))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !createdAt.Equal(job.CreatedAt) || *id != job.ID {
+		t.Fatalf("expected %v %s, got %v %s", job.CreatedAt, job.ID, createdAt, *id)
+	}
+
+	if createdAt, id, err := parsePageToken(""); err != nil || createdAt != nil || id != nil {
+		t.Fatalf("expected the first page for an empty token, got %v %v %v", createdAt, id, err)
+	}
+	for _, token := range []string{"%%%", "bm8tY29tbWE", "eCxub3QtYS11dWlk"} {
+		if _, _, err := parsePageToken(token); !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("%s: expected an invalid token, got %v", token, err)
+		}
+	}
+}
+
+func TestJobConfigValidate(t *testing.T) {
+	valid := JobConfig{Workers: 2, PollInterval: time.Second, HeartbeatInterval: 10 * time.Second, StaleAfter: time.Minute, MaxAttempts: 3}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	slowHeartbeat := valid
+	slowHeartbeat.HeartbeatInterval = valid.StaleAfter
+	noWorkers := valid
+	noWorkers.Workers = 0
+	noAttempts := valid
+	noAttempts.MaxAttempts = 0
+	for name, cfg := range map[string]JobConfig{"heartbeat": slowHeartbeat, "workers": noWorkers, "attempts": noAttempts} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// memQueue is an in memory jobQueue following the transitions of processor_job,
+// the updates of a run are conditioned on its attempt like the queries
+type memQueue struct {
+	mu   sync.Mutex
+	jobs []*Job
+}
+
+func (q *memQueue) find(id string) *Job {
+	for _, job := range q.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// current returns the stored job while run is still its running attempt
+func (q *memQueue) current(run *Job) (*Job, error) {
+	job := q.find(run.ID)
+	if job == nil || job.Attempts != run.Attempts || job.State != JobRunning {
+		return nil, errJobLost
+	}
+	return job, nil
+}
+
+func (q *memQueue) Insert(ctx context.Context, job *Job) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	inserted := *job
+	inserted.ID = uuid.NewString()
+	inserted.State = JobQueued
+	inserted.CreatedAt = time.Now().UTC()
+	q.jobs = append(q.jobs, &inserted)
+	copied := inserted
+	return &copied, nil
+}
+
+func (q *memQueue) Get(ctx context.Context, id string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.find(id)
+	if job == nil {
+		return nil, errors.Wrap(ErrJobNotFound, id)
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (q *memQueue) List(ctx context.Context, state JobState, submittedBy string, pageSize int, token string) ([]*Job, string, error) {
+	return nil, "", nil
+}
+
+func (q *memQueue) Claim(ctx context.Context) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.State == JobQueued {
+			now := time.Now().UTC()
+			job.State, job.Attempts, job.StartedAt, job.HeartbeatAt = JobRunning, job.Attempts+1, &now, &now
+			copied := *job
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (q *memQueue) Heartbeat(ctx context.Context, run *Job, progress []byte) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.current(run)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+	job.HeartbeatAt, job.Progress = &now, progress
+	return job.CancelRequested, nil
+}
+
+func (q *memQueue) MarkWriting(ctx context.Context, run *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.current(run)
+	if err != nil {
+		return err
+	}
+	job.WritesStarted = true
+	return nil
+}
+
+func (q *memQueue) Finish(ctx context.Context, run *Job, state JobState, result, progress []byte, errorCode, message string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.current(run)
+	if err != nil {
+		return err
+	}
+	job.State, job.Result, job.Progress, job.ErrorCode, job.Error = state, result, progress, errorCode, message
+	return nil
+}
+
+func (q *memQueue) Requeue(ctx context.Context, run *Job, progress []byte) (JobState, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.current(run)
+	if err != nil {
+		return "", err
+	}
+	job.HeartbeatAt = nil
+	if job.WritesStarted {
+		job.State, job.Progress, job.ErrorCode, job.Error = JobFailed, progress, codes.Aborted.String(), errJobWritten.Error()
+	} else {
+		job.State, job.StartedAt, job.Progress = JobQueued, nil, nil
+	}
+	return job.State, nil
+}
+
+func (q *memQueue) RequeueStale(ctx context.Context, before time.Time, maxAttempts int) (requeued, failed int64, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.State != JobRunning || !job.HeartbeatAt.Before(before) {
+			continue
+		}
+		job.HeartbeatAt = nil
+		switch {
+		case job.CancelRequested:
+			job.State = JobCancelled
+		case job.WritesStarted || job.Attempts >= maxAttempts:
+			job.State, job.ErrorCode = JobFailed, codes.Aborted.String()
+			failed++
+		default:
+			job.State, job.StartedAt, job.Progress = JobQueued, nil, nil
+			requeued++
+		}
+	}
+	return requeued, failed, nil
+}
+
+func (q *memQueue) Cancel(ctx context.Context, id string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.find(id)
+	if job == nil {
+		return nil, errors.Wrap(ErrJobNotFound, id)
+	}
+	switch job.State {
+	case JobQueued:
+		job.State, job.ErrorCode, job.Error = JobCancelled, codes.Canceled.String(), ErrJobCancelled.Error()
+	case JobRunning:
+	default:
+		copied := *job
+		return &copied, errors.Wrapf(ErrJobFinished, "job %s is %s", id, job.State)
+	}
+	job.CancelRequested = true
+	copied := *job
+	return &copied, nil
+}
+
+func testJobPool(q *memQueue, run JobFunc) *JobPool {
+	return newJobPool(q, JobConfig{
+		Workers:           2,
+		PollInterval:      10 * time.Millisecond,
+		HeartbeatInterval: 10 * time.Millisecond,
+		StaleAfter:        time.Second,
+		MaxAttempts:       2,
+	}, run, func() bool { return true })
+}
+
+func waitJob(t *testing.T, q *memQueue, id string, state JobState) *Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := q.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected job %s to be %s, it is %s", id, state, job.State)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobPoolFailsPanickingJob(t *testing.T) {
+	q := &memQueue{}
+	pool := testJobPool(q, func(ctx context.Context, job *Job) ([]byte, error) {
+		if job.InputFile == "poison.csv" {
+			panic("corrupted input")
+		}
+		return []byte(`{}`), nil
+	})
+	pool.Start()
+	defer pool.Stop(time.Second)
+
+	poison, _ := pool.Submit(context.Background(), &Job{InputFile: "poison.csv"})
+	failed := waitJob(t, q, poison.ID, JobFailed)
+	if failed.ErrorCode != codes.Internal.String() || failed.Attempts != 1 {
+		t.Fatalf("expected an internal failure on the first attempt, got %s after %d", failed.ErrorCode, failed.Attempts)
+	}
+	// the workers outlive the panic
+	next, _ := pool.Submit(context.Background(), &Job{InputFile: "trips.csv"})
+	waitJob(t, q, next.ID, JobSucceeded)
+}
+
+func TestJobPoolShutdownRequeuesUnwrittenJobs(t *testing.T) {
+	q := &memQueue{}
+	started := make(chan struct{}, 2)
+	pool := testJobPool(q, func(ctx context.Context, job *Job) ([]byte, error) {
+		if job.InputFile == "written.csv" {
+			insert := GuardWrites(ctx, func(ctx context.Context, tbl *TableInsert) (int64, int64, error) { return 0, 0, nil })
+			if _, _, err := insert(ctx, nil); err != nil {
+				return nil, err
+			}
+		}
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	pool.Start()
+	written, _ := pool.Submit(context.Background(), &Job{InputFile: "written.csv"})
+	unwritten, _ := pool.Submit(context.Background(), &Job{InputFile: "unwritten.csv"})
+	<-started
+	<-started
+	pool.Stop(10 * time.Millisecond)
+
+	if job := waitJob(t, q, written.ID, JobFailed); !job.WritesStarted || job.ErrorCode != codes.Aborted.String() {
+		t.Fatalf("expected the written job to be aborted, got %+v", job)
+	}
+	if job := waitJob(t, q, unwritten.ID, JobQueued); job.WritesStarted || job.Attempts != 1 {
+		t.Fatalf("expected the unwritten job to be queued again, got %+v", job)
+	}
+}
+
+func TestJobPoolCancelsRunningJob(t *testing.T) {
+	q := &memQueue{}
+	started := make(chan struct{})
+	pool := testJobPool(q, func(ctx context.Context, job *Job) ([]byte, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	pool.Start()
+	defer pool.Stop(time.Second)
+
+	job, _ := pool.Submit(context.Background(), &Job{InputFile: "trips.csv"})
+	<-started
+	// the store only flags the job, the run stops on its next heartbeat
+	if _, err := q.Cancel(context.Background(), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	cancelled := waitJob(t, q, job.ID, JobCancelled)
+	if cancelled.ErrorCode != codes.Canceled.String() {
+		t.Fatalf("expected a cancelled error code, got %s", cancelled.ErrorCode)
+	}
+	if _, err := pool.Cancel(context.Background(), job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("expected a finished job, got %v", err)
+	}
+}
+
+func TestJobPoolLostJobDoesNotOverwriteNextAttempt(t *testing.T) {
+	q := &memQueue{}
+	firstStarted, firstDone := make(chan struct{}), make(chan error, 1)
+	pool := testJobPool(q, func(ctx context.Context, job *Job) ([]byte, error) {
+		if job.Attempts > 1 {
+			return []byte(`{"attempt":2}`), nil
+		}
+		close(firstStarted)
+		<-ctx.Done()
+		// a lost run can't mark the job it no longer owns
+		insert := GuardWrites(ctx, func(ctx context.Context, tbl *TableInsert) (int64, int64, error) { return 0, 0, nil })
+		_, _, err := insert(context.WithoutCancel(ctx), nil)
+		firstDone <- err
+		return nil, ctx.Err()
+	})
+	pool.Start()
+	defer pool.Stop(time.Second)
+
+	job, _ := pool.Submit(context.Background(), &Job{InputFile: "trips.csv"})
+	<-firstStarted
+	// another processor saw no heartbeat in time and requeued the job
+	if requeued, _, _ := q.RequeueStale(context.Background(), time.Now().Add(time.Hour), 3); requeued != 1 {
+		t.Fatalf("expected the job to be requeued, got %d", requeued)
+	}
+	pool.notify()
+	if err := <-firstDone; !errors.Is(err, errJobLost) {
+		t.Fatalf("expected the first run to be lost, got %v", err)
+	}
+	finished := waitJob(t, q, job.ID, JobSucceeded)
+	if finished.Attempts != 2 || string(finished.Result) != `{"attempt":2}` || finished.WritesStarted {
+		t.Fatalf("expected the second attempt to own the job, got %+v", finished)
+	}
+}
@@ -0,0 +1,278 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"processor/logger"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInputNotFound = errors.New("input doesn't exist in redis")
+	ErrInputClaimed  = errors.New("input is claimed by another processor")
+	ErrLeaseLost     = errors.New("lease on input was lost")
+)
+
+// LeaseConfig bounds the claim a processor holds on a redis input while loading it
+type LeaseConfig struct {
+	TTL            time.Duration
+	ExtendInterval time.Duration
+	// MaxAttempts is the number of failed loads before the input is dead-lettered
+	MaxAttempts int
+}
+
+func (c LeaseConfig) Validate() error {
+	if c.TTL <= 0 {
+		return errors.Errorf("lease ttl must be positive, got %v", c.TTL)
+	}
+	if c.ExtendInterval <= 0 || c.ExtendInterval >= c.TTL {
+		return errors.Errorf("lease extend interval must be positive and below the ttl %v, got %v", c.TTL, c.ExtendInterval)
+	}
+	if c.MaxAttempts < 1 {
+		return errors.Errorf("max attempts must be positive, got %d", c.MaxAttempts)
+	}
+	return nil
+}
+
+// syncAttemptsTTL gives the attempts key the expiry of the input key so the count
+// doesn't outlive the input, it's appended to the scripts that change either
+const syncAttemptsTTL = `
+	local function syncAttemptsTTL()
+		if redis.call('EXISTS', KEYS[3]) == 0 then
+			return
+		end
+		local inputTTL = redis.call('PTTL', KEYS[1])
+		if inputTTL > 0 then
+			redis.call('PEXPIRE', KEYS[3], inputTTL)
+		elseif inputTTL == -1 then
+			redis.call('PERSIST', KEYS[3])
+		else
+			redis.call('DEL', KEYS[3])
+		end
+	end
+`
+
+// The scripts get the input key first, then the keys derived from it with relatedKey
+// so they hash to the same cluster slot.
+var (
+	claimScript = redis.NewScript(syncAttemptsTTL + `
+		if redis.call('EXISTS', KEYS[1]) == 0 then
+			return 0
+		end
+		if not redis.call('SET', KEYS[2], ARGV[1], 'NX', 'PX', ARGV[2]) then
+			return -1
+		end
+		local pttl = redis.call('PTTL', KEYS[1])
+		if pttl > 0 and pttl < tonumber(ARGV[2]) then
+			redis.call('PEXPIRE', KEYS[1], ARGV[2])
+		end
+		syncAttemptsTTL()
+		return 1
+	`)
+	extendScript = redis.NewScript(syncAttemptsTTL + `
+		if redis.call('GET', KEYS[2]) ~= ARGV[1] then
+			return 0
+		end
+		redis.call('PEXPIRE', KEYS[2], ARGV[2])
+		local pttl = redis.call('PTTL', KEYS[1])
+		if pttl > 0 and pttl < tonumber(ARGV[2]) then
+			redis.call('PEXPIRE', KEYS[1], ARGV[2])
+		end
+		syncAttemptsTTL()
+		return 1
+	`)
+	completeScript = redis.NewScript(`
+		if redis.call('GET', KEYS[2]) ~= ARGV[1] then
+			return 0
+		end
+		redis.call('DEL', KEYS[1], KEYS[2], KEYS[3])
+		return 1
+	`)
+	failScript = redis.NewScript(syncAttemptsTTL + `
+		if redis.call('GET', KEYS[2]) ~= ARGV[1] then
+			return -1
+		end
+		redis.call('DEL', KEYS[2])
+		local attempts = redis.call('INCR', KEYS[3])
+		if ARGV[4] ~= '1' and attempts < tonumber(ARGV[5]) then
+			syncAttemptsTTL()
+			return 0
+		end
+		if redis.call('EXISTS', KEYS[1]) == 1 then
+			redis.call('RENAME', KEYS[1], KEYS[4])
+			redis.call('PERSIST', KEYS[4])
+		end
+		redis.call('HSET', KEYS[5], 'input', KEYS[1], 'error', ARGV[2], 'failed_at', ARGV[3], 'attempts', attempts)
+		redis.call('DEL', KEYS[3])
+		return 1
+	`)
+)
+
+// relatedKey derives a key in the same cluster slot as key
+func relatedKey(key, suffix string) string {
+	if open := strings.IndexByte(key, '{'); open >= 0 {
+		if end := strings.IndexByte(key[open+1:], '}'); end > 0 {
+			return key + suffix
+		}
+	}
+	return "{" + key + "}" + suffix
+}
+
+// InputLease is the exclusive claim of a processor on a redis input
+type InputLease struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+	cfg    LeaseConfig
+}
+
+func (l *InputLease) keys() []string {
+	return []string{
+		l.key,
+		relatedKey(l.key, ":lease"),
+		relatedKey(l.key, ":attempts"),
+		relatedKey(l.key, ":dead"),
+		relatedKey(l.key, ":dead:meta"),
+	}
+}
+
+// ClaimInput atomically takes the lease on key and returns its content,
+// no other processor can claim it until the lease is completed, failed or expires
+func (r *RedisClient) ClaimInput(ctx context.Context, key string, cfg LeaseConfig) (*InputLease, []byte, error) {
+	var token [16]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return nil, nil, errors.Wrap(err, "failed generating lease token")
+	}
+	lease := &InputLease{
+		client: r.Client,
+		key:    key,
+		token:  hex.EncodeToString(token[:]),
+		cfg:    cfg,
+	}
+	claimed, err := claimScript.Run(ctx, r.Client, lease.keys()[:3], lease.token, cfg.TTL.Milliseconds()).Int()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed claiming %s", key)
+	}
+	switch claimed {
+	case 0:
+		return nil, nil, errors.Wrap(ErrInputNotFound, key)
+	case -1:
+		return nil, nil, errors.Wrap(ErrInputClaimed, key)
+	}
+
+	file, err := r.Client.Get(ctx, key).Bytes()
+	if err != nil {
+		lease.release()
+		if err == redis.Nil {
+			return nil, nil, errors.Wrap(ErrInputNotFound, key)
+		}
+		return nil, nil, errors.Wrapf(err, "failed to get content %v from redis", key)
+	}
+	return lease, file, nil
+}
+
+func (l *InputLease) Extend(ctx context.Context) error {
+	extended, err := extendScript.Run(ctx, l.client, l.keys()[:3], l.token, l.cfg.TTL.Milliseconds()).Int()
+	if err != nil {
+		return errors.Wrapf(err, "failed extending lease on %s", l.key)
+	}
+	if extended == 0 {
+		return errors.Wrap(ErrLeaseLost, l.key)
+	}
+	return nil
+}
+
+// KeepAlive extends the lease every ExtendInterval until stop is called,
+// onLost is called once if the lease can't be kept before it expires
+func (l *InputLease) KeepAlive(ctx context.Context, onLost func(error)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(l.cfg.ExtendInterval)
+		defer ticker.Stop()
+		expiresAt := time.Now().Add(l.cfg.TTL)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := l.Extend(ctx)
+			if err == nil {
+				expiresAt = time.Now().Add(l.cfg.TTL)
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			// a redis hiccup is retried on the next tick while the lease is still valid
+			if !errors.Is(err, ErrLeaseLost) && time.Until(expiresAt) > l.cfg.ExtendInterval {
+				logger.FromContext(ctx).Warn("failed extending lease", zap.String("key", l.key), zap.Error(err))
+				continue
+			}
+			onLost(err)
+			return
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Complete deletes the input after a successful load
+func (l *InputLease) Complete(ctx context.Context) error {
+	completed, err := completeScript.Run(ctx, l.client, l.keys()[:3], l.token).Int()
+	if err != nil {
+		return errors.Wrapf(err, "failed deleting %s", l.key)
+	}
+	if completed == 0 {
+		return errors.Wrap(ErrLeaseLost, l.key)
+	}
+	return nil
+}
+
+// Fail releases the lease after a failed load. The input is kept for a retry unless
+// deadLetter is set or MaxAttempts is reached, it is then moved to the dead-letter key
+// with the error. It reports whether the input was dead-lettered.
+func (l *InputLease) Fail(ctx context.Context, cause error, deadLetter bool) (bool, error) {
+	flag := "0"
+	if deadLetter {
+		flag = "1"
+	}
+	moved, err := failScript.Run(ctx, l.client, l.keys(),
+		l.token, cause.Error(), time.Now().UTC().Format(time.RFC3339), flag, l.cfg.MaxAttempts,
+	).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed releasing %s", l.key)
+	}
+	if moved == -1 {
+		return false, errors.Wrap(ErrLeaseLost, l.key)
+	}
+	return moved == 1, nil
+}
+
+// DeadLetterKey is where a failed input is moved, its error is in DeadLetterKey + ":meta"
+func (l *InputLease) DeadLetterKey() string {
+	return l.keys()[3]
+}
+
+// release drops the lease without touching the input
+func (l *InputLease) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	l.client.Eval(ctx, `
+		if redis.call('GET', KEYS[1]) == ARGV[1] then
+			return redis.call('DEL', KEYS[1])
+		end
+		return 0
+	`, l.keys()[1:2], l.token)
+}
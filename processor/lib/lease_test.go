@@ -0,0 +1,180 @@
+package lib
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *RedisClient) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, &RedisClient{Client: client}
+}
+
+var testLeaseConfig = LeaseConfig{TTL: time.Minute, ExtendInterval: 20 * time.Second, MaxAttempts: 3}
+
+func TestClaimInputIsExclusive(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+	if _, _, err := client.ClaimInput(ctx, "trips.csv", testLeaseConfig); !errors.Is(err, ErrInputNotFound) {
+		t.Fatalf("expected a missing input, got %v", err)
+	}
+	server.Set("trips.csv", "content")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var claimed, refused int
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, file, err := client.ClaimInput(ctx, "trips.csv", testLeaseConfig)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil && string(file) == "content":
+				claimed++
+			case errors.Is(err, ErrInputClaimed):
+				refused++
+			default:
+				t.Errorf("unexpected claim result %q, %v", file, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if claimed != 1 || refused != 9 {
+		t.Fatalf("expected a single claim, got %d claimed and %d refused", claimed, refused)
+	}
+}
+
+func TestLeaseRenewalAndExpiry(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+	server.Set("trips.csv", "content")
+	// an input about to expire lives at least as long as the lease
+	server.SetTTL("trips.csv", time.Second)
+
+	lease, _, err := client.ClaimInput(ctx, "trips.csv", testLeaseConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL("trips.csv"); ttl != time.Minute {
+		t.Fatalf("expected the input ttl raised to the lease ttl, got %v", ttl)
+	}
+
+	// a renewed lease outlives its first ttl
+	server.FastForward(40 * time.Second)
+	if err := lease.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(40 * time.Second)
+	if _, _, err := client.ClaimInput(ctx, "trips.csv", testLeaseConfig); !errors.Is(err, ErrInputClaimed) {
+		t.Fatalf("expected the renewed lease to hold, got %v", err)
+	}
+
+	// an expired lease can be taken over and the previous holder can't renew or complete it
+	server.FastForward(time.Minute)
+	server.Set("trips.csv", "content")
+	next, _, err := client.ClaimInput(ctx, "trips.csv", testLeaseConfig)
+	if err != nil {
+		t.Fatalf("expected the expired lease to be claimable, got %v", err)
+	}
+	if err := lease.Extend(ctx); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected the lost lease not to be renewed, got %v", err)
+	}
+	if err := lease.Complete(ctx); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("expected the lost lease not to complete, got %v", err)
+	}
+	if err := next.Complete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("trips.csv") || server.Exists("{trips.csv}:lease") {
+		t.Fatal("expected the completed input and its lease to be deleted")
+	}
+}
+
+func TestLeaseKeepAliveReportsLostLease(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.Set("trips.csv", "content")
+	cfg := LeaseConfig{TTL: time.Minute, ExtendInterval: 10 * time.Millisecond, MaxAttempts: 3}
+	lease, _, err := client.ClaimInput(context.Background(), "trips.csv", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost := make(chan error, 1)
+	stop := lease.KeepAlive(context.Background(), func(err error) { lost <- err })
+	defer stop()
+
+	server.Del("{trips.csv}:lease")
+	select {
+	case err := <-lost:
+		if !errors.Is(err, ErrLeaseLost) {
+			t.Fatalf("expected a lost lease, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lost lease wasn't reported")
+	}
+}
+
+func TestLeaseFailKeepsAttemptsWithInput(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+	server.Set("trips.csv", "content")
+	server.SetTTL("trips.csv", time.Hour)
+	cause := errors.New("connection reset")
+
+	for attempt := 1; attempt < testLeaseConfig.MaxAttempts; attempt++ {
+		lease, _, err := client.ClaimInput(ctx, "trips.csv", testLeaseConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deadLettered, err := lease.Fail(ctx, cause, false)
+		if err != nil || deadLettered {
+			t.Fatalf("attempt %d: expected the input kept for a retry, got %v, %v", attempt, deadLettered, err)
+		}
+		// the attempts count expires with the input instead of outliving it
+		if inputTTL, attemptsTTL := server.TTL("trips.csv"), server.TTL("{trips.csv}:attempts"); attemptsTTL != inputTTL {
+			t.Fatalf("attempt %d: expected the attempts ttl %v, got %v", attempt, inputTTL, attemptsTTL)
+		}
+	}
+	server.FastForward(time.Hour)
+	if server.Exists("trips.csv") || server.Exists("{trips.csv}:attempts") {
+		t.Fatal("expected the attempts to expire with the input")
+	}
+
+	// the last attempt moves an input without ttl to the dead-letter key
+	server.Set("trips.csv", "content")
+	for attempt := 1; attempt <= testLeaseConfig.MaxAttempts; attempt++ {
+		lease, _, err := client.ClaimInput(ctx, "trips.csv", testLeaseConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deadLettered, err := lease.Fail(ctx, cause, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deadLettered != (attempt == testLeaseConfig.MaxAttempts) {
+			t.Fatalf("attempt %d: unexpected dead-letter %v", attempt, deadLettered)
+		}
+		if attempt < testLeaseConfig.MaxAttempts && server.TTL("{trips.csv}:attempts") != 0 {
+			t.Fatalf("expected no attempts ttl for an input without one, got %v", server.TTL("{trips.csv}:attempts"))
+		}
+	}
+	if server.Exists("trips.csv") || server.Exists("{trips.csv}:attempts") {
+		t.Fatal("expected the input and its attempts to be gone")
+	}
+	if dead, err := server.Get("{trips.csv}:dead"); err != nil || dead != "content" {
+		t.Fatalf("expected the input in the dead-letter key, got %q, %v", dead, err)
+	}
+	if msg := server.HGet("{trips.csv}:dead:meta", "error"); msg != cause.Error() {
+		t.Fatalf("expected the failure in the dead-letter meta, got %q", msg)
+	}
+}
@@ -0,0 +1,190 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"processor/logger"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const unixPrefix = "unix://"
+
+// Listen opens address, a unix:///path/to.sock address listens on a unix domain socket
+// and replaces a stale socket file left by a previous run
+func Listen(address string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(address, unixPrefix)
+	if !isUnix {
+		lis, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed listening on %s", address)
+		}
+		return lis, nil
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, errors.Errorf("%s exists and isn't a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("socket %s is in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, errors.Wrapf(err, "failed removing stale socket %s", path)
+		}
+	}
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed listening on %s", address)
+	}
+	return lis, nil
+}
+
+// CertReloader serves the current server certificate and client CA, they are
+// read again by Reload or when Watch sees the files change
+type CertReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	contents  [][]byte
+}
+
+func NewCertReloader(certFile, keyFile, clientCAFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+func (r *CertReloader) read() ([][]byte, error) {
+	files := r.files()
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed reading tls file")
+		}
+		contents[i] = content
+	}
+	return contents, nil
+}
+
+// Reload reads the files again, the previous certificate is kept if they are invalid
+func (r *CertReloader) Reload() error {
+	contents, err := r.read()
+	if err != nil {
+		return err
+	}
+	return r.load(contents)
+}
+
+func (r *CertReloader) load(contents [][]byte) error {
+	cert, err := tls.X509KeyPair(contents[0], contents[1])
+	if err != nil {
+		return errors.Wrap(err, "failed loading server certificate")
+	}
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(contents[2]) {
+			return errors.Errorf("no certificate found in client ca file %s", r.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.contents = contents
+	r.mu.Unlock()
+	if cert.Leaf != nil {
+		logger.Info("loaded tls certificate",
+			zap.String("subject", cert.Leaf.Subject.String()),
+			zap.Time("notAfter", cert.Leaf.NotAfter),
+			zap.Bool("mutualTLS", clientCAs != nil),
+		)
+	}
+	return nil
+}
+
+// Watch reloads the certificate when the content of its files changes until ctx is done
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		contents, err := r.read()
+		if err != nil {
+			logger.Warn("failed checking tls files", zap.Error(err))
+			continue
+		}
+		if !r.changed(contents) {
+			continue
+		}
+		if err := r.load(contents); err != nil {
+			// a certificate and its key are rarely replaced at the same instant, retry on the next tick
+			logger.Warn("failed reloading changed tls files", zap.Error(err))
+		}
+	}
+}
+
+func (r *CertReloader) changed(contents [][]byte) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i, content := range contents {
+		if !bytes.Equal(content, r.contents[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerConfig requires a client certificate signed by the client CA when one is configured
+func (r *CertReloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+			}
+			if r.clientCAs != nil {
+				config.ClientCAs = r.clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
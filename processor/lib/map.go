@@ -2,6 +2,7 @@ package lib
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"processor/logger"
@@ -12,8 +13,11 @@ import (
 	"go.uber.org/zap"
 )
 
+var ErrMapNotReady = errors.New("taxi zone map isn't loaded yet")
+
 type MapId struct {
 	mapTaxiZone *ristretto.Cache[int64, TaxiZone]
+	ready       atomic.Bool
 }
 
 type TaxiZone struct {
@@ -28,6 +32,7 @@ func (m *MapId) newTaxiZone() error {
 		NumCounters: 300,             // number of keys to track frequency.
 		MaxCost:     1 * 1024 * 1024, // maximum cost of cache (20MB). Please adjust this value based on use case.
 		BufferItems: 64,              // number of keys per Get buffer.
+		Metrics:     true,
 	})
 	if err != nil {
 		return err
@@ -69,28 +74,80 @@ func (m *MapId) GetTaxiZoneMap() (*ristretto.Cache[int64, TaxiZone], error) {
 	if m.mapTaxiZone == nil {
 		return nil, errors.New("mapTaxiZone map is not created")
 	}
+	if !m.ready.Load() {
+		return nil, ErrMapNotReady
+	}
 	return m.mapTaxiZone, nil
 }
 
+// Ready reports whether the first load of the map completed
+func (m *MapId) Ready() bool {
+	return m.ready.Load()
+}
+
 func NewMap(ctx context.Context, conn *pgxpool.Pool) (*MapId, error) {
-	start := time.Now()
-	mapId := new(MapId)
-	err := mapId.newTaxiZone()
+	mapId, err := NewPendingMap()
 	if err != nil {
+		return nil, err
+	}
+	if err := mapId.Load(ctx, conn); err != nil {
+		return nil, err
+	}
+	return mapId, nil
+}
+
+// NewPendingMap creates an empty map, GetTaxiZoneMap fails with ErrMapNotReady until Load completes
+func NewPendingMap() (*MapId, error) {
+	mapId := new(MapId)
+	if err := mapId.newTaxiZone(); err != nil {
 		return nil, errors.Wrap(err, "failed creating mapTaxiZone")
 	}
-	err = mapId.putTaxiZone(ctx, conn)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed putting mapTaxiZone")
+	return mapId, nil
+}
+
+// Load fills the map from taxi_zone_lookup and marks it ready
+func (m *MapId) Load(ctx context.Context, conn *pgxpool.Pool) error {
+	start := time.Now()
+	if err := m.putTaxiZone(ctx, conn); err != nil {
+		return errors.Wrap(err, "failed putting mapTaxiZone")
 	}
+	WaitMap(m)
+	m.ready.Store(true)
 	logger.Info("creating map", zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// NewStaticMap builds a map from fixed zones instead of taxi_zone_lookup
+func NewStaticMap(zones map[int64]TaxiZone) (*MapId, error) {
+	mapId := new(MapId)
+	if err := mapId.newTaxiZone(); err != nil {
+		return nil, errors.Wrap(err, "failed creating mapTaxiZone")
+	}
+	for key, zone := range zones {
+		mapId.mapTaxiZone.Set(key, zone, 1)
+	}
+	mapId.mapTaxiZone.Wait()
+	mapId.ready.Store(true)
 	return mapId, nil
 }
 
+// MustAutoRefreshMap reloads the taxi zones every 6 hours until ctx is done
 func MustAutoRefreshMap(ctx context.Context, conn *pgxpool.Pool, mapId *MapId) {
-	for range time.Tick(time.Hour * 6) {
+	ticker := time.NewTicker(time.Hour * 6)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("stopped map refresher")
+			return
+		case <-ticker.C:
+		}
 		start := time.Now()
 		err := mapId.putTaxiZone(ctx, conn)
+		if err != nil && ctx.Err() != nil {
+			logger.Info("stopped map refresher")
+			return
+		}
 		if err != nil {
 			logger.Panic("failed creating mapTaxiZone", zap.Error(err))
 		}
@@ -0,0 +1,274 @@
+package lib
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"processor/logger"
+
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const metricsNamespace = "processor"
+
+// Metrics is the registry served on /metrics, the collectors below are registered on it
+var Metrics = prometheus.NewRegistry()
+
+var (
+	RowsRead = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_read_total",
+		Help:      "Data rows read from input files.",
+	}, []string{"dataset"})
+	RowsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_processed_total",
+		Help:      "Rows parsed and enriched successfully.",
+	}, []string{"dataset"})
+	RowsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_dropped_total",
+		Help:      "Rows dropped by the parser by field and reason.",
+	}, []string{"dataset", "field", "reason"})
+	RowsInserted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_inserted_total",
+		Help:      "Rows written to the database.",
+	}, []string{"dataset"})
+	FileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "file_duration_seconds",
+		Help:      "Time to load an input file.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+	}, []string{"dataset", "dry_run", "outcome"})
+	BatchCopyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "batch_copy_duration_seconds",
+		Help:      "Latency of one CopyFrom batch attempt.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"table", "outcome"})
+	BatchRows = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "batch_rows",
+		Help:      "Rows per written batch.",
+		Buckets:   prometheus.ExponentialBuckets(50, 2, 10),
+	}, []string{"table"})
+	JobsFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_finished_total",
+		Help:      "Jobs run by this processor by final state.",
+	}, []string{"dataset", "state"})
+	ChannelDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "channel_depth",
+		Help:      "Rows queued between pipeline stages across running loads.",
+	}, []string{"dataset", "stage"})
+	AdmissionRunning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "admission_running",
+		Help:      "Loads holding an admission slot.",
+	}, []string{"dataset"})
+	AdmissionQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "admission_queued",
+		Help:      "Loads waiting for an admission slot.",
+	}, []string{"dataset"})
+	AdmissionWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "admission_wait_seconds",
+		Help:      "Time queued loads waited for a slot.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+	}, []string{"dataset"})
+	AdmissionRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "admission_rejected_total",
+		Help:      "Loads rejected by admission control by reason.",
+	}, []string{"dataset", "reason"})
+)
+
+func init() {
+	Metrics.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RowsRead, RowsProcessed, RowsDropped, RowsInserted,
+		FileDuration, BatchCopyDuration, BatchRows, ChannelDepth, JobsFinished,
+		AdmissionRunning, AdmissionQueued, AdmissionWait, AdmissionRejected,
+	)
+}
+
+// RowCounters are the row metrics of one load, they're added to while the rows flow
+// through the pipeline so a failed load counts the rows it got through
+type RowCounters struct {
+	Read      prometheus.Counter
+	Processed prometheus.Counter
+	// Dropped is curried with the dataset, field and reason are left
+	Dropped *prometheus.CounterVec
+}
+
+func NewRowCounters(dataset string, dryRun bool) RowCounters {
+	read, processed, dropped := RowsRead, RowsProcessed, RowsDropped
+	if dryRun {
+		// a dry run loads nothing, its rows go to counters that aren't registered
+		opts := prometheus.CounterOpts{Name: "dry_run_rows_total"}
+		read = prometheus.NewCounterVec(opts, []string{"dataset"})
+		processed = prometheus.NewCounterVec(opts, []string{"dataset"})
+		dropped = prometheus.NewCounterVec(opts, []string{"dataset", "field", "reason"})
+	}
+	return RowCounters{
+		Read:      read.WithLabelValues(dataset),
+		Processed: processed.WithLabelValues(dataset),
+		Dropped:   dropped.MustCurryWith(prometheus.Labels{"dataset": dataset}),
+	}
+}
+
+// DepthSampler publishes the depth of a load's channels into ChannelDepth, the
+// gauge holds the sum over every running load
+type DepthSampler struct {
+	gauges []prometheus.Gauge
+	depths []func() int
+	last   []int
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func NewDepthSampler() *DepthSampler {
+	return &DepthSampler{stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (s *DepthSampler) Add(dataset, stage string, depth func() int) {
+	s.gauges = append(s.gauges, ChannelDepth.WithLabelValues(dataset, stage))
+	s.depths = append(s.depths, depth)
+	s.last = append(s.last, 0)
+}
+
+// Start samples every interval until Stop
+func (s *DepthSampler) Start(interval time.Duration) {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				s.set(func(int) int { return 0 })
+				return
+			case <-ticker.C:
+				s.set(func(i int) int { return s.depths[i]() })
+			}
+		}
+	}()
+}
+
+func (s *DepthSampler) set(depth func(int) int) {
+	for i, gauge := range s.gauges {
+		d := depth(i)
+		gauge.Add(float64(d - s.last[i]))
+		s.last[i] = d
+	}
+}
+
+// Stop removes the load's share from the gauges
+func (s *DepthSampler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+type poolCollector struct {
+	pool         *pgxpool.Pool
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	emptyAcquire *prometheus.Desc
+	canceled     *prometheus.Desc
+	waitSeconds  *prometheus.Desc
+}
+
+// RegisterPoolMetrics exposes the pgxpool stats of pool
+func RegisterPoolMetrics(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "db_pool", name), help, nil, nil)
+	}
+	Metrics.MustRegister(&poolCollector{
+		pool:         pool,
+		acquired:     desc("acquired_conns", "Connections currently acquired."),
+		idle:         desc("idle_conns", "Idle connections."),
+		total:        desc("total_conns", "Open connections."),
+		max:          desc("max_conns", "Maximum size of the pool."),
+		acquires:     desc("acquires_total", "Successful connection acquires."),
+		emptyAcquire: desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceled:     desc("canceled_acquires_total", "Acquires canceled by their context."),
+		waitSeconds:  desc("acquire_duration_seconds_total", "Time spent acquiring connections."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.total, c.max, c.acquires, c.emptyAcquire, c.canceled, c.waitSeconds} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitSeconds, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+// RegisterMapMetrics exposes the hits and misses of the taxi zone cache
+func RegisterMapMetrics(mapId *MapId) {
+	metrics := func() *ristretto.Metrics { return mapId.mapTaxiZone.Metrics }
+	Metrics.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "taxi_zone_cache_hits_total",
+			Help:      "Taxi zone lookups found in the cache.",
+		}, func() float64 { return float64(metrics().Hits()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "taxi_zone_cache_misses_total",
+			Help:      "Taxi zone lookups missing from the cache.",
+		}, func() float64 { return float64(metrics().Misses()) }),
+	)
+}
+
+// ServeMetrics serves /metrics on address until stop is called, the address is bound
+// before it returns so a busy port fails the caller
+func ServeMetrics(address string) (stop func(), err error) {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed listening on %s", address)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Metrics, promhttp.HandlerOpts{Registry: Metrics}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		logger.Info("serving metrics", zap.String("address", address))
+		if err := server.Serve(lis); err != nil && err != http.ErrServerClosed {
+			logger.Error("failed serving metrics", zap.Error(err))
+		}
+	}()
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Error("failed stopping metrics server", zap.Error(err))
+			return
+		}
+		logger.Info("stopped metrics server")
+	}, nil
+}
@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/semaphore"
+)
+
+// PipelineConfig sizes the parser and inserter stages of a single file load
+type PipelineConfig struct {
+	ReaderWorkers   int
+	ChunkSize       int64
+	ParserWorkers   int
+	InserterWorkers int
+	BatchSize       int
+	ChannelSize     int
+	// Adaptive bounds the runtime tuning of the load, zero disables it
+	Adaptive AdaptiveConfig
+}
+
+// minChunkSize keeps chunks large enough for the newline alignment to stay cheap
+const minChunkSize = 64 * 1024
+
+func (c PipelineConfig) Validate() error {
+	if c.ReaderWorkers < 1 {
+		return errors.Errorf("reader workers must be positive, got %d", c.ReaderWorkers)
+	}
+	if c.ChunkSize < minChunkSize {
+		return errors.Errorf("chunk size must be at least %d bytes, got %d", minChunkSize, c.ChunkSize)
+	}
+	if c.ParserWorkers < 1 {
+		return errors.Errorf("parser workers must be positive, got %d", c.ParserWorkers)
+	}
+	if c.InserterWorkers < 1 {
+		return errors.Errorf("inserter workers must be positive, got %d", c.InserterWorkers)
+	}
+	if c.BatchSize < 1 {
+		return errors.Errorf("batch size must be positive, got %d", c.BatchSize)
+	}
+	if c.ChannelSize < 0 {
+		return errors.Errorf("channel size can't be negative, got %d", c.ChannelSize)
+	}
+	return nil
+}
+
+// PipelineLimits bounds the per request overrides of a PipelineConfig, a channel or
+// worker count sent by a client is allocated as is
+type PipelineLimits struct {
+	ReaderWorkers   int
+	ChunkSize       int64
+	ParserWorkers   int
+	InserterWorkers int
+	BatchSize       int
+	ChannelSize     int
+}
+
+// Within fails when c goes past one of the limits
+func (c PipelineConfig) Within(l PipelineLimits) error {
+	if c.ReaderWorkers > l.ReaderWorkers {
+		return errors.Errorf("reader workers can't exceed %d, got %d", l.ReaderWorkers, c.ReaderWorkers)
+	}
+	if c.ChunkSize > l.ChunkSize {
+		return errors.Errorf("chunk size can't exceed %d bytes, got %d", l.ChunkSize, c.ChunkSize)
+	}
+	if c.ParserWorkers > l.ParserWorkers {
+		return errors.Errorf("parser workers can't exceed %d, got %d", l.ParserWorkers, c.ParserWorkers)
+	}
+	if c.InserterWorkers > l.InserterWorkers {
+		return errors.Errorf("inserter workers can't exceed %d, got %d", l.InserterWorkers, c.InserterWorkers)
+	}
+	if c.BatchSize > l.BatchSize {
+		return errors.Errorf("batch size can't exceed %d, got %d", l.BatchSize, c.BatchSize)
+	}
+	if c.ChannelSize > l.ChannelSize {
+		return errors.Errorf("channel size can't exceed %d, got %d", l.ChannelSize, c.ChannelSize)
+	}
+	return nil
+}
+
+// WorkerLimiter caps the inserter workers running across all concurrent loads, every
+// inserter holds a pool connection while flushing so the cap is the pool size minus the
+// connections kept for the job queue, health checks and profiles
+type WorkerLimiter struct {
+	sem  *semaphore.Weighted
+	size int
+}
+
+func NewWorkerLimiter(size int) *WorkerLimiter {
+	return &WorkerLimiter{
+		sem:  semaphore.NewWeighted(int64(size)),
+		size: size,
+	}
+}
+
+// Acquire blocks until n workers are available, n is clamped to the limiter size.
+// It returns the granted worker count and a function to give them back.
+func (l *WorkerLimiter) Acquire(ctx context.Context, n int) (int, func(), error) {
+	n = min(n, l.size)
+	if err := l.sem.Acquire(ctx, int64(n)); err != nil {
+		return 0, nil, errors.Wrap(err, "failed acquiring inserter workers")
+	}
+	return n, func() { l.sem.Release(int64(n)) }, nil
+}
+
+// TryAcquire reserves one more worker without blocking
+func (l *WorkerLimiter) TryAcquire() bool {
+	return l.sem.TryAcquire(1)
+}
+
+func (l *WorkerLimiter) Release() {
+	l.sem.Release(1)
+}
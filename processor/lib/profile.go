@@ -0,0 +1,322 @@
+package lib
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+type ColumnKind int8
+
+const (
+	NumericColumn ColumnKind = iota
+	TimeColumn
+	StringColumn
+)
+
+// hllPrecision gives 1024 registers per column, about 3% standard error
+const hllPrecision = 10
+
+// hllSeed is shared by every profile of the process so sketches of different workers can be merged
+var hllSeed = maphash.MakeSeed()
+
+// HyperLogLog estimates the number of distinct values of a column
+type HyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func hashUint64(v uint64) uint64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return maphash.Bytes(hllSeed, b[:])
+}
+
+func (h *HyperLogLog) add(hash uint64) {
+	idx := hash >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	h.registers[idx] = max(h.registers[idx], rank)
+}
+
+func (h *HyperLogLog) merge(o *HyperLogLog) {
+	for i, r := range o.registers {
+		h.registers[i] = max(h.registers[i], r)
+	}
+}
+
+func (h *HyperLogLog) Estimate() int64 {
+	const m = float64(len(h.registers))
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// linear counting is more accurate for small cardinalities
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// Histogram counts values below each upper bound, the last count holds the values above every bound
+type Histogram struct {
+	UpperBounds []float64 `json:"upper_bounds"`
+	Counts      []int64   `json:"counts"`
+}
+
+func NewHistogram(upperBounds []float64) *Histogram {
+	return &Histogram{
+		UpperBounds: upperBounds,
+		Counts:      make([]int64, len(upperBounds)+1),
+	}
+}
+
+func (h *Histogram) add(v float64) {
+	i := 0
+	for i < len(h.UpperBounds) && v >= h.UpperBounds[i] {
+		i++
+	}
+	h.Counts[i]++
+}
+
+func (h *Histogram) merge(o *Histogram) {
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+}
+
+// ColumnProfile summarises the values of one column, time columns keep their bounds in unix microseconds
+type ColumnProfile struct {
+	Name      string
+	Kind      ColumnKind
+	Count     int64
+	Nulls     int64
+	Min       float64
+	Max       float64
+	Sum       float64
+	Histogram *Histogram
+	distinct  HyperLogLog
+}
+
+func (c *ColumnProfile) addNumber(v float64) {
+	if c.Count == 0 || v < c.Min {
+		c.Min = v
+	}
+	if c.Count == 0 || v > c.Max {
+		c.Max = v
+	}
+	c.Count++
+	c.Sum += v
+	if c.Histogram != nil {
+		c.Histogram.add(v)
+	}
+}
+
+func (c *ColumnProfile) Mean() float64 {
+	if c.Count == 0 || c.Kind == StringColumn {
+		return 0
+	}
+	return c.Sum / float64(c.Count)
+}
+
+func (c *ColumnProfile) Distinct() int64 {
+	return min(c.distinct.Estimate(), c.Count)
+}
+
+func (c *ColumnProfile) MinTime() time.Time {
+	return time.UnixMicro(int64(c.Min)).UTC()
+}
+
+func (c *ColumnProfile) MaxTime() time.Time {
+	return time.UnixMicro(int64(c.Max)).UTC()
+}
+
+func (c *ColumnProfile) merge(o *ColumnProfile) {
+	if o.Count > 0 {
+		if c.Count == 0 || o.Min < c.Min {
+			c.Min = o.Min
+		}
+		if c.Count == 0 || o.Max > c.Max {
+			c.Max = o.Max
+		}
+	}
+	c.Count += o.Count
+	c.Nulls += o.Nulls
+	c.Sum += o.Sum
+	if c.Histogram != nil {
+		c.Histogram.merge(o.Histogram)
+	}
+	c.distinct.merge(&o.distinct)
+}
+
+// TableProfile is the column profile of the rows of a load, values are added in
+// column order like CopyBuffer. It isn't safe for concurrent use, every worker
+// keeps its own and they are merged once the workers are done.
+type TableProfile struct {
+	Rows    int64
+	Columns []*ColumnProfile
+	col     int
+}
+
+// ProfileColumn declares a column of a TableProfile, a nil Histogram skips the histogram
+type ProfileColumn struct {
+	Name      string
+	Kind      ColumnKind
+	Histogram []float64
+}
+
+func NewTableProfile(columns []ProfileColumn) *TableProfile {
+	p := &TableProfile{Columns: make([]*ColumnProfile, len(columns))}
+	for i, col := range columns {
+		p.Columns[i] = &ColumnProfile{Name: col.Name, Kind: col.Kind}
+		if col.Histogram != nil {
+			p.Columns[i].Histogram = NewHistogram(col.Histogram)
+		}
+	}
+	return p
+}
+
+// ProfileColumnsOf declares a profile column for every column of schema
+func ProfileColumnsOf(schema TableSchema) []ProfileColumn {
+	columns := make([]ProfileColumn, len(schema.Columns))
+	for i, col := range schema.Columns {
+		columns[i] = ProfileColumn{Name: col.Name, Kind: NumericColumn}
+		switch col.GoType {
+		case reflect.TypeOf(time.Time{}):
+			columns[i].Kind = TimeColumn
+		case reflect.TypeOf(""):
+			columns[i].Kind = StringColumn
+		}
+	}
+	return columns
+}
+
+func (p *TableProfile) Column(name string) *ColumnProfile {
+	for _, col := range p.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// StartRow must be called before adding the values of a row
+func (p *TableProfile) StartRow() {
+	p.Rows++
+	p.col = 0
+}
+
+func (p *TableProfile) next() *ColumnProfile {
+	col := p.Columns[p.col]
+	p.col++
+	return col
+}
+
+func (p *TableProfile) AddNull() {
+	p.next().Nulls++
+}
+
+func (p *TableProfile) AddString(v string) {
+	col := p.next()
+	col.Count++
+	col.distinct.add(maphash.String(hllSeed, v))
+}
+
+func (p *TableProfile) AddInt(v int64) {
+	col := p.next()
+	col.addNumber(float64(v))
+	col.distinct.add(hashUint64(uint64(v)))
+}
+
+func (p *TableProfile) AddFloat(v float64) {
+	col := p.next()
+	col.addNumber(v)
+	col.distinct.add(hashUint64(math.Float64bits(v)))
+}
+
+func (p *TableProfile) AddFloatPtr(v *float64) {
+	if v == nil {
+		p.AddNull()
+		return
+	}
+	p.AddFloat(*v)
+}
+
+func (p *TableProfile) AddTime(v time.Time) {
+	col := p.next()
+	us := v.UnixMicro()
+	col.addNumber(float64(us))
+	col.distinct.add(hashUint64(uint64(us)))
+}
+
+// Merge adds the values of o, both profiles must have the same columns
+func (p *TableProfile) Merge(o *TableProfile) {
+	p.Rows += o.Rows
+	for i, col := range p.Columns {
+		col.merge(o.Columns[i])
+	}
+}
+
+// ProfileIngestion identifies the load a persisted profile belongs to
+type ProfileIngestion struct {
+	Table          string
+	FileName       string
+	RemoteFilePath string
+	IngestedAt     time.Time
+	// IngestedBy is the authenticated caller, empty when auth is disabled
+	IngestedBy string
+}
+
+// PersistProfile writes one ingestion_profile row per column of p
+func PersistProfile(ctx context.Context, conn *pgxpool.Pool, ingestion ProfileIngestion, p *TableProfile) error {
+	batch := &pgx.Batch{}
+	for _, col := range p.Columns {
+		var minValue, maxValue, meanValue *float64
+		var minTime, maxTime *time.Time
+		var histogram []byte
+		if col.Count > 0 {
+			switch col.Kind {
+			case NumericColumn:
+				mean := col.Mean()
+				minValue, maxValue, meanValue = &col.Min, &col.Max, &mean
+			case TimeColumn:
+				minT, maxT := col.MinTime(), col.MaxTime()
+				minTime, maxTime = &minT, &maxT
+			}
+		}
+		if col.Histogram != nil {
+			var err error
+			histogram, err = json.Marshal(col.Histogram)
+			if err != nil {
+				return errors.Wrapf(err, "failed encoding histogram of %s", col.Name)
+			}
+		}
+		batch.Queue(`
+			INSERT INTO ingestion_profile (
+				table_name, file_name, remote_file_path, ingested_at, ingested_by, column_name,
+				value_count, null_count, distinct_estimate, min_value, max_value, mean_value,
+				min_time, max_time, histogram
+			) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		`,
+			ingestion.Table, ingestion.FileName, ingestion.RemoteFilePath, ingestion.IngestedAt, ingestion.IngestedBy, col.Name,
+			col.Count, col.Nulls, col.Distinct(), minValue, maxValue, meanValue,
+			minTime, maxTime, histogram,
+		)
+	}
+	if err := conn.SendBatch(ctx, batch).Close(); err != nil {
+		return errors.Wrap(err, "failed inserting ingestion_profile")
+	}
+	return nil
+}
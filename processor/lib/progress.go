@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is updated by a running load and read by whoever watches it
+type Progress struct {
+	start        time.Time
+	TotalBytes   atomic.Int64
+	BytesRead    atomic.Int64
+	RowsParsed   atomic.Int64
+	RowsDropped  atomic.Int64
+	RowsInserted atomic.Int64
+	member       atomic.Pointer[string]
+}
+
+func NewProgress() *Progress {
+	return &Progress{start: time.Now()}
+}
+
+// SetMember records the archive member being read
+func (p *Progress) SetMember(name string) {
+	p.member.Store(&name)
+}
+
+// ProgressSnapshot is a point in time copy of a Progress, it's persisted as json
+type ProgressSnapshot struct {
+	BytesRead      int64   `json:"bytes_read"`
+	TotalBytes     int64   `json:"total_bytes"`
+	RowsParsed     int64   `json:"rows_parsed"`
+	RowsDropped    int64   `json:"rows_dropped"`
+	RowsInserted   int64   `json:"rows_inserted"`
+	Member         string  `json:"member"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	// RemainingSeconds is negative until enough of the input was read for an estimate
+	RemainingSeconds float64 `json:"remaining_seconds"`
+}
+
+// Snapshot estimates the remaining time from the read throughput so far
+func (p *Progress) Snapshot() ProgressSnapshot {
+	s := ProgressSnapshot{
+		BytesRead:        p.BytesRead.Load(),
+		TotalBytes:       p.TotalBytes.Load(),
+		RowsParsed:       p.RowsParsed.Load(),
+		RowsDropped:      p.RowsDropped.Load(),
+		RowsInserted:     p.RowsInserted.Load(),
+		ElapsedSeconds:   time.Since(p.start).Seconds(),
+		RemainingSeconds: -1,
+	}
+	if member := p.member.Load(); member != nil {
+		s.Member = *member
+	}
+	if s.BytesRead > 0 && s.TotalBytes >= s.BytesRead {
+		s.RemainingSeconds = s.ElapsedSeconds * float64(s.TotalBytes-s.BytesRead) / float64(s.BytesRead)
+	}
+	return s
+}
+
+type progressKey struct{}
+
+func ContextWithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ProgressFrom returns the progress a load reports to, nil when nobody watches it
+func ProgressFrom(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return p
+}
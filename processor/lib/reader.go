@@ -2,18 +2,17 @@ package lib
 
 import (
 	"archive/tar"
-	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
 )
 
-func ExtractTarGz(data []byte) (map[string][]byte, error) {
-	files := make(map[string][]byte)
-
-	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+// ForEachTarGzMember calls fn with every file of a tar.gz stream in archive order, fn must
+// read a member before the next one is reached
+func ForEachTarGzMember(r io.Reader, fn func(name string, member io.Reader) error) error {
+	gzipReader, err := gzip.NewReader(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 	defer gzipReader.Close()
 
@@ -22,24 +21,22 @@ func ExtractTarGz(data []byte) (map[string][]byte, error) {
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
-			break
+			// reading past the tar trailer checks the gzip checksum and reaches the end of r
+			if _, err := io.Copy(io.Discard, gzipReader); err != nil {
+				return fmt.Errorf("failed to read gzip trailer: %w", err)
+			}
+			return nil
 		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to read tar header: %w", err)
+			return fmt.Errorf("failed to read tar header: %w", err)
 		}
 
 		if header.Typeflag == tar.TypeDir {
 			continue
 		}
 
-		var fileContent bytes.Buffer
-		_, err = io.Copy(&fileContent, tarReader)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file content: %w", err)
+		if err := fn(header.Name, tarReader); err != nil {
+			return err
 		}
-
-		files[header.Name] = fileContent.Bytes()
 	}
-
-	return files, nil
 }
@@ -2,48 +2,212 @@ package lib
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"processor/logger"
 
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 )
 
 type RedisClient struct {
-	Client *redis.Client
+	Client redis.UniversalClient
 }
 
 type InputFile struct {
 	File []byte
 }
 
-func NewRedisClient(ctx context.Context, host string, db int) (*RedisClient, error) {
-	redisHost := fmt.Sprintf("%s:6379", host)
-	client := redis.NewClient(&redis.Options{
-		Addr: redisHost,
-		DB:   db,
-	})
-	err := client.Ping(ctx).Err()
+// RedisConfig selects the redis deployment, ClusterAddrs wins over SentinelMaster which wins over URL.
+// Without a URL the client connects to Host on the default port.
+type RedisConfig struct {
+	URL  string
+	Host string
+	DB   int
+
+	SentinelMaster   string
+	SentinelAddrs    []string
+	SentinelUsername string
+	SentinelPassword string
+	ClusterAddrs     []string
+
+	// Username and Password override the credentials of the URL
+	Username string
+	Password string
+
+	// TLS is implied by a rediss:// URL
+	TLS                   bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+}
+
+func (c RedisConfig) tlsConfig(urlTLS *tls.Config) (*tls.Config, error) {
+	if !c.TLS && urlTLS == nil {
+		return nil, nil
+	}
+	config := urlTLS
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if c.TLSServerName != "" {
+		config.ServerName = c.TLSServerName
+	}
+	config.InsecureSkipVerify = config.InsecureSkipVerify || c.TLSInsecureSkipVerify
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed reading redis ca file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificate found in redis ca file %s", c.TLSCAFile)
+		}
+		config.RootCAs = pool
+	}
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed loading redis client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func (c RedisConfig) newClient() (redis.UniversalClient, string, error) {
+	switch {
+	case len(c.ClusterAddrs) > 0:
+		tlsConfig, err := c.tlsConfig(nil)
+		if err != nil {
+			return nil, "", err
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        c.ClusterAddrs,
+			Username:     c.Username,
+			Password:     c.Password,
+			TLSConfig:    tlsConfig,
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			DialTimeout:  c.DialTimeout,
+			ReadTimeout:  c.ReadTimeout,
+			WriteTimeout: c.WriteTimeout,
+			PoolTimeout:  c.PoolTimeout,
+		}), "cluster", nil
+	case c.SentinelMaster != "":
+		if len(c.SentinelAddrs) == 0 {
+			return nil, "", errors.New("redis sentinel master is set without sentinel addresses")
+		}
+		tlsConfig, err := c.tlsConfig(nil)
+		if err != nil {
+			return nil, "", err
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       c.SentinelMaster,
+			SentinelAddrs:    c.SentinelAddrs,
+			SentinelUsername: c.SentinelUsername,
+			SentinelPassword: c.SentinelPassword,
+			DB:               c.DB,
+			Username:         c.Username,
+			Password:         c.Password,
+			TLSConfig:        tlsConfig,
+			PoolSize:         c.PoolSize,
+			MinIdleConns:     c.MinIdleConns,
+			DialTimeout:      c.DialTimeout,
+			ReadTimeout:      c.ReadTimeout,
+			WriteTimeout:     c.WriteTimeout,
+			PoolTimeout:      c.PoolTimeout,
+		}), "sentinel", nil
+	}
+
+	opts := &redis.Options{Addr: fmt.Sprintf("%s:6379", c.Host), DB: c.DB}
+	if c.URL != "" {
+		var err error
+		opts, err = redis.ParseURL(strings.TrimSpace(c.URL))
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed parsing redis url")
+		}
+	}
+	if c.Username != "" {
+		opts.Username = c.Username
+	}
+	if c.Password != "" {
+		opts.Password = c.Password
+	}
+	tlsConfig, err := c.tlsConfig(opts.TLSConfig)
+	if err != nil {
+		return nil, "", err
+	}
+	opts.TLSConfig = tlsConfig
+	if c.PoolSize > 0 {
+		opts.PoolSize = c.PoolSize
+	}
+	if c.MinIdleConns > 0 {
+		opts.MinIdleConns = c.MinIdleConns
+	}
+	if c.DialTimeout > 0 {
+		opts.DialTimeout = c.DialTimeout
+	}
+	if c.ReadTimeout > 0 {
+		opts.ReadTimeout = c.ReadTimeout
+	}
+	if c.WriteTimeout > 0 {
+		opts.WriteTimeout = c.WriteTimeout
+	}
+	if c.PoolTimeout > 0 {
+		opts.PoolTimeout = c.PoolTimeout
+	}
+	return redis.NewClient(opts), "standalone", nil
+}
+
+// NewRedisClient creates the pooled client shared by every request, it's closed with Close
+func NewRedisClient(ctx context.Context, cfg RedisConfig) (*RedisClient, error) {
+	client, mode, err := cfg.newClient()
 	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, errors.Wrap(err, "failed connecting to redis")
 	}
+	logger.Info("Redis connection established", zap.String("mode", mode), zap.Int("poolSize", cfg.PoolSize))
 	return &RedisClient{
 		Client: client,
 	}, nil
 }
 
+func (r *RedisClient) Close() error {
+	return r.Client.Close()
+}
+
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.Client.Ping(ctx).Err()
+}
+
 func (r *RedisClient) GetInputFile(ctx context.Context, key string) (*InputFile, error) {
 	file, err := r.Client.Get(ctx, key).Bytes()
 	if err == redis.Nil {
-		return nil, errors.Errorf("file %v doesn't exist in redis", key)
+		return nil, errors.Wrap(ErrInputNotFound, key)
 	} else if err != nil {
-		return nil, errors.Errorf("failed to get content %v from redis", key)
+		return nil, errors.Wrapf(err, "failed to get content %v from redis", key)
 	}
 
 	return &InputFile{
 		File: file,
 	}, nil
 }
-
-func (r *RedisClient) DeleteInputFile(ctx context.Context, key string) error {
-	return r.Client.Del(ctx, key).Err()
-}
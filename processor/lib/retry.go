@@ -0,0 +1,215 @@
+package lib
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"processor/logger"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// retryableSqlState lists the postgres error codes that are safe to retry
+// because the server guarantees the transaction was rolled back.
+// Timescale chunk creation lock timeouts surface as lock_not_available.
+var retryableSqlState = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"55P03": true, // lock_not_available
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+	"58000": true, // system_error
+}
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return errors.Errorf("retry max attempts must be positive, got %d", p.MaxAttempts)
+	}
+	if p.InitialBackoff < 0 || p.MaxBackoff < p.InitialBackoff {
+		return errors.Errorf("retry backoffs must be non-negative with the initial one at most the max %v, got %v", p.MaxBackoff, p.InitialBackoff)
+	}
+	if p.Multiplier < 1 {
+		return errors.Errorf("retry multiplier must be at least 1, got %v", p.Multiplier)
+	}
+	return nil
+}
+
+// commitError marks a failure while committing, where the server might already
+// have applied the transaction if the connection dropped.
+type commitError struct {
+	err error
+}
+
+func (e *commitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commitError) Unwrap() error {
+	return e.err
+}
+
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// class 08 is connection_exception
+		return retryableSqlState[pgErr.Code] || (len(pgErr.Code) == 5 && pgErr.Code[:2] == "08")
+	}
+
+	// without a server answer the outcome of a commit is unknown, retrying
+	// could insert the batch twice
+	var cErr *commitError
+	if errors.As(err, &cErr) {
+		return false
+	}
+
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// IsCommitUnknown reports whether a commit failed without a server answer, the batch
+// might be in the table. Rows aren't upserted so sending it again can insert it twice.
+// A commit the server rejected was rolled back, its outcome is known.
+func IsCommitUnknown(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return false
+	}
+	var cErr *commitError
+	return errors.As(err, &cErr)
+}
+
+// IsSchemaError reports whether the database rejected a batch because the table doesn't
+// match the go mapping anymore, like a dropped column or a changed type
+func IsSchemaError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	// class 42 is syntax_error_or_access_rule_violation, 42501 is a missing grant instead.
+	// COPY reports a changed column type as invalid_binary_representation.
+	return (strings.HasPrefix(pgErr.Code, "42") && pgErr.Code != "42501") || pgErr.Code == "22P03"
+}
+
+// backoff returns the full-jitter delay before the given retry attempt (starting at 1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := min(float64(p.InitialBackoff), float64(p.MaxBackoff))
+	for range attempt - 1 {
+		d *= p.Multiplier
+		if d >= float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
+
+// BatchInsertFunc writes one batch and returns the inserted rows and the retries done
+type BatchInsertFunc func(ctx context.Context, tbl *TableInsert) (int64, int64, error)
+
+// NewBatchInserter returns a BatchInsertFunc writing to conn with policy
+func NewBatchInserter(conn *pgxpool.Pool, policy RetryPolicy) BatchInsertFunc {
+	return func(ctx context.Context, tbl *TableInsert) (int64, int64, error) {
+		return InsertUpdateDuplicateBatchWithRetry(ctx, tbl, conn, policy)
+	}
+}
+
+// InsertUpdateDuplicateBatchWithRetry runs InsertUpdateDuplicateBatch and retries transient failures,
+// it returns the number of retries done alongside the result
+func InsertUpdateDuplicateBatchWithRetry(ctx context.Context, tbl *TableInsert, conn *pgxpool.Pool, policy RetryPolicy) (inserted int64, retries int64, err error) {
+	ctx, span := StartSpan(ctx, "db.batch", attribute.String("table", tbl.name), attribute.Int("rows", tbl.Rows()))
+	defer func() {
+		span.SetAttributes(attribute.Int64("inserted", inserted), attribute.Int64("retries", retries))
+		EndSpan(span, err)
+	}()
+	return retryBatch(ctx, tbl, policy, func(ctx context.Context) (int64, error) {
+		return InsertUpdateDuplicateBatch(ctx, tbl, conn)
+	})
+}
+
+// retryBatch runs insert until it succeeds, fails with an error that isn't retryable,
+// runs out of attempts or the next backoff would end past the deadline of ctx
+func retryBatch(ctx context.Context, tbl *TableInsert, policy RetryPolicy, insert func(ctx context.Context) (int64, error)) (int64, int64, error) {
+	span := trace.SpanFromContext(ctx)
+	var retries int64
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		totalInserted, err := insert(ctx)
+		if err == nil {
+			BatchCopyDuration.WithLabelValues(tbl.name, "success").Observe(time.Since(start).Seconds())
+			BatchRows.WithLabelValues(tbl.name).Observe(float64(tbl.Rows()))
+			return totalInserted, retries, nil
+		}
+		BatchCopyDuration.WithLabelValues(tbl.name, "error").Observe(time.Since(start).Seconds())
+		if attempt >= policy.MaxAttempts || !IsRetryable(err) {
+			return 0, retries, err
+		}
+
+		wait := policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return 0, retries, errors.Wrap(err, "no time left to retry before deadline")
+		}
+		logger.FromContext(ctx).Warn("retrying batch insert",
+			zap.String("table", tbl.name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", wait),
+			zap.Error(err),
+		)
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("backoff", wait.String()),
+			attribute.String("error", err.Error()),
+		))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, retries, errors.Wrap(ctx.Err(), err.Error())
+		case <-timer.C:
+		}
+		retries++
+	}
+}
@@ -0,0 +1,173 @@
+package lib
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"chunk lock timeout", &pgconn.PgError{Code: "55P03"}, true},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, true},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"protocol violation", &pgconn.PgError{Code: "08P01"}, true},
+		{"wrapped sqlstate", errors.Wrap(&pgconn.PgError{Code: "40001"}, "failed copying"), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"undefined column", &pgconn.PgError{Code: "42703"}, false},
+		{"query canceled", &pgconn.PgError{Code: "57014"}, false},
+		{"short code", &pgconn.PgError{Code: "08"}, false},
+		{"canceled", errors.Wrap(context.Canceled, "failed copying"), false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"eof", io.ErrUnexpectedEOF, true},
+		{"connection reset", errors.Wrap(syscall.ECONNRESET, "failed copying"), true},
+		{"net error", &net.OpError{Op: "read", Err: syscall.ETIMEDOUT}, true},
+		{"commit without answer", &commitError{err: io.EOF}, false},
+		{"wrapped commit without answer", errors.Wrap(&commitError{err: syscall.ECONNRESET}, "batch"), false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+	if !IsCommitUnknown(errors.Wrap(&commitError{err: io.EOF}, "batch")) || IsCommitUnknown(io.EOF) {
+		t.Error("expected only a commit error to leave the commit unknown")
+	}
+	// a deferred constraint rejected at commit was rolled back
+	if IsCommitUnknown(&commitError{err: &pgconn.PgError{Code: "23505"}}) {
+		t.Error("expected a commit rejected by the server to be known")
+	}
+}
+
+func TestBackoffCapAndJitter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	caps := []time.Duration{100, 200, 400, 800, 1000, 1000, 1000}
+	for i, limit := range caps {
+		attempt, limit := i+1, limit*time.Millisecond
+		var largest time.Duration
+		for range 1000 {
+			d := policy.backoff(attempt)
+			if d <= 0 || d > limit {
+				t.Fatalf("attempt %d: backoff %v is outside (0, %v]", attempt, d, limit)
+			}
+			largest = max(largest, d)
+		}
+		// full jitter spreads the delays over the whole range
+		if largest < limit/2 {
+			t.Fatalf("attempt %d: expected delays up to %v, the largest was %v", attempt, limit, largest)
+		}
+	}
+	if d := (RetryPolicy{Multiplier: 2, MaxBackoff: time.Second}).backoff(3); d != 0 {
+		t.Fatalf("expected no backoff without an initial backoff, got %v", d)
+	}
+	// the first retry is capped too
+	for range 100 {
+		if d := (RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Second, Multiplier: 2}).backoff(1); d > time.Second {
+			t.Fatalf("expected the first backoff capped at 1s, got %v", d)
+		}
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	valid := RetryPolicy{MaxAttempts: 5, InitialBackoff: 200 * time.Millisecond, MaxBackoff: 10 * time.Second, Multiplier: 2}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for name, change := range map[string]func(*RetryPolicy){
+		"no attempts":          func(p *RetryPolicy) { p.MaxAttempts = 0 },
+		"negative backoff":     func(p *RetryPolicy) { p.InitialBackoff = -time.Second },
+		"initial above max":    func(p *RetryPolicy) { p.InitialBackoff = time.Minute },
+		"shrinking multiplier": func(p *RetryPolicy) { p.Multiplier = 0.5 },
+	} {
+		policy := valid
+		change(&policy)
+		if err := policy.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func testTableInsert() *TableInsert {
+	return NewTableInsert("trips", nil, NewCopyBuffer(TableSchema{Name: "trips"}))
+}
+
+func TestRetryBatch(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 2}
+	retryable := &pgconn.PgError{Code: "40P01"}
+	tests := []struct {
+		name    string
+		results []error
+		calls   int
+		retries int64
+		fails   bool
+	}{
+		{"first attempt", []error{nil}, 1, 0, false},
+		{"retried until success", []error{retryable, retryable, nil}, 3, 2, false},
+		{"attempts run out", []error{retryable, retryable, retryable, nil}, 3, 2, true},
+		{"permanent error", []error{&pgconn.PgError{Code: "23505"}, nil}, 1, 0, true},
+		{"commit without answer", []error{&commitError{err: io.EOF}, nil}, 1, 0, true},
+	}
+	for _, tt := range tests {
+		var calls int
+		inserted, retries, err := retryBatch(context.Background(), testTableInsert(), policy, func(ctx context.Context) (int64, error) {
+			err := tt.results[calls]
+			calls++
+			if err != nil {
+				return 0, err
+			}
+			return 10, nil
+		})
+		if calls != tt.calls || retries != tt.retries || (err != nil) != tt.fails {
+			t.Errorf("%s: expected %d calls, %d retries and failure %v, got %d, %d and %v",
+				tt.name, tt.calls, tt.retries, tt.fails, calls, retries, err)
+		}
+		if err == nil && inserted != 10 {
+			t.Errorf("%s: expected 10 rows inserted, got %d", tt.name, inserted)
+		}
+	}
+}
+
+func TestRetryBatchStopsBeforeDeadline(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour, Multiplier: 2}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var calls int
+	start := time.Now()
+	_, _, err := retryBatch(ctx, testTableInsert(), policy, func(ctx context.Context) (int64, error) {
+		calls++
+		return 0, syscall.ECONNRESET
+	})
+	// a backoff that can't finish before the deadline isn't waited for
+	if err == nil || !strings.Contains(err.Error(), "no time left") || !errors.Is(err, syscall.ECONNRESET) {
+		t.Fatalf("expected the deadline short-circuit, got %v", err)
+	}
+	if calls != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected a single attempt without waiting, got %d calls in %v", calls, time.Since(start))
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	policy.InitialBackoff, policy.MaxBackoff = time.Minute, time.Minute
+	_, _, err = retryBatch(ctx, testTableInsert(), policy, func(ctx context.Context) (int64, error) {
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return 0, syscall.ECONNRESET
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the backoff to stop on cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package lib
+
+import (
+	"strings"
+	"time"
+
+	pb "processor/protos"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail of every failed call
+const ErrorDomain = "processor"
+
+// DefaultRetryDelay is suggested to callers hit by a transient failure without a better estimate
+const DefaultRetryDelay = 5 * time.Second
+
+// RPCError builds a grpc status carrying an ErrorInfo detail with a reason of the
+// catalog in the proto, callers pick their next step from the reason instead of the message
+type RPCError struct {
+	code     codes.Code
+	reason   pb.ErrorReason
+	msg      string
+	metadata map[string]string
+	details  []protoadapt.MessageV1
+	// fields and preconditions are collected into a single BadRequest and PreconditionFailure
+	fields        []*errdetails.BadRequest_FieldViolation
+	preconditions []*errdetails.PreconditionFailure_Violation
+}
+
+func NewRPCError(code codes.Code, reason pb.ErrorReason, err error) *RPCError {
+	return &RPCError{code: code, reason: reason, msg: err.Error()}
+}
+
+// ReasonCode is the ErrorInfo reason of r
+func ReasonCode(r pb.ErrorReason) string {
+	return strings.TrimPrefix(r.String(), "ERROR_REASON_")
+}
+
+// With adds key to the ErrorInfo metadata
+func (e *RPCError) With(key, value string) *RPCError {
+	if e.metadata == nil {
+		e.metadata = make(map[string]string)
+	}
+	e.metadata[key] = value
+	return e
+}
+
+// Field adds a BadRequest violation of field, nested fields are dotted like options.batch_size
+func (e *RPCError) Field(field, description string) *RPCError {
+	e.fields = append(e.fields, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+	return e
+}
+
+// Precondition adds a PreconditionFailure violation, typ is SCHEMA for schema mismatches
+func (e *RPCError) Precondition(typ, subject, description string) *RPCError {
+	e.preconditions = append(e.preconditions, &errdetails.PreconditionFailure_Violation{
+		Type:        typ,
+		Subject:     subject,
+		Description: description,
+	})
+	return e
+}
+
+// RetryAfter adds a RetryInfo detail, the call can be sent again as is after delay
+func (e *RPCError) RetryAfter(delay time.Duration) *RPCError {
+	e.details = append(e.details, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	return e
+}
+
+func (e *RPCError) Err() error {
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   ReasonCode(e.reason),
+		Domain:   ErrorDomain,
+		Metadata: e.metadata,
+	}}
+	if len(e.fields) > 0 {
+		details = append(details, &errdetails.BadRequest{FieldViolations: e.fields})
+	}
+	if len(e.preconditions) > 0 {
+		details = append(details, &errdetails.PreconditionFailure{Violations: e.preconditions})
+	}
+	details = append(details, e.details...)
+	st, err := status.New(e.code, e.msg).WithDetails(details...)
+	if err != nil {
+		// only an OK code can't carry details
+		return status.Error(e.code, e.msg)
+	}
+	return st.Err()
+}
+
+// InvalidField rejects a request because of one of its fields
+func InvalidField(field string, err error) error {
+	return NewRPCError(codes.InvalidArgument, pb.ErrorReason_ERROR_REASON_INVALID_REQUEST, err).Field(field, err.Error()).Err()
+}
+
+// ReasonOf returns the reason of a status built by RPCError, unspecified for any other error
+func ReasonOf(err error) pb.ErrorReason {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return pb.ErrorReason(pb.ErrorReason_value["ERROR_REASON_"+info.GetReason()])
+		}
+	}
+	return pb.ErrorReason_ERROR_REASON_UNSPECIFIED
+}
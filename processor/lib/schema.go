@@ -0,0 +1,136 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// Column is the Go side of a table column, read from the `db:"name,type"` tag of a row struct
+type Column struct {
+	Name     string
+	DataType string
+	GoType   reflect.Type
+	Nullable bool
+}
+
+type TableSchema struct {
+	Name    string
+	Columns []Column
+}
+
+// copyTypes lists the postgres types CopyBuffer can encode for each Go type of a row struct
+var copyTypes = map[reflect.Type][]string{
+	reflect.TypeOf(""):          {"character varying", "text"},
+	reflect.TypeOf(int64(0)):    {"bigint", "integer", "smallint"},
+	reflect.TypeOf(float64(0)):  {"double precision", "real"},
+	reflect.TypeOf(time.Time{}): {"timestamp without time zone", "timestamp with time zone"},
+}
+
+// TableSchemaOf builds the schema of a table from the field order and `db` tags of row,
+// pointer fields are the nullable columns
+func TableSchemaOf(table string, row any) TableSchema {
+	typ := reflect.TypeOf(row)
+	schema := TableSchema{Name: table}
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, dataType, found := strings.Cut(field.Tag.Get("db"), ",")
+		if name == "" || !found {
+			panic(fmt.Sprintf("field %s of %s needs a db:\"name,type\" tag", field.Name, typ.Name()))
+		}
+		col := Column{Name: name, DataType: dataType, GoType: field.Type}
+		if field.Type.Kind() == reflect.Ptr {
+			col.GoType = field.Type.Elem()
+			col.Nullable = true
+		}
+		if !slices.Contains(copyTypes[col.GoType], dataType) {
+			panic(fmt.Sprintf("field %s of %s can't be encoded as %s", field.Name, typ.Name(), dataType))
+		}
+		schema.Columns = append(schema.Columns, col)
+	}
+	return schema
+}
+
+func (s TableSchema) ColumnNames() []string {
+	names := make([]string, len(s.Columns))
+	for i, col := range s.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+type dbColumn struct {
+	dataType   string
+	nullable   bool
+	hasDefault bool
+}
+
+// ValidateTableSchema checks the table in the database against the Go mapping,
+// it reports every mismatch at once
+func ValidateTableSchema(ctx context.Context, conn *pgxpool.Pool, schema TableSchema) error {
+	rows, err := conn.Query(ctx, `
+		SELECT column_name, data_type, is_nullable = 'YES', column_default IS NOT NULL
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+		  AND table_name = $1
+	`, schema.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed querying columns of %s", schema.Name)
+	}
+	defer rows.Close()
+
+	dbColumns := make(map[string]dbColumn)
+	for rows.Next() {
+		var name string
+		var col dbColumn
+		if err := rows.Scan(&name, &col.dataType, &col.nullable, &col.hasDefault); err != nil {
+			return errors.Wrapf(err, "failed scanning columns of %s", schema.Name)
+		}
+		dbColumns[name] = col
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrapf(err, "failed reading columns of %s", schema.Name)
+	}
+	if len(dbColumns) == 0 {
+		return errors.Errorf("table %s doesn't exist", schema.Name)
+	}
+	return compareColumns(schema, dbColumns)
+}
+
+// compareColumns lists every difference between the columns of the table and the Go mapping
+func compareColumns(schema TableSchema, dbColumns map[string]dbColumn) error {
+	var problems []string
+	mapped := make(map[string]bool)
+	for _, col := range schema.Columns {
+		mapped[col.Name] = true
+		dbCol, found := dbColumns[col.Name]
+		if !found {
+			problems = append(problems, fmt.Sprintf("column %s is missing", col.Name))
+			continue
+		}
+		if dbCol.dataType != col.DataType {
+			problems = append(problems, fmt.Sprintf("column %s is %s but the go mapping encodes %s",
+				col.Name, dbCol.dataType, col.DataType))
+		}
+		if col.Nullable && !dbCol.nullable {
+			problems = append(problems, fmt.Sprintf("column %s is NOT NULL but the go mapping allows nulls", col.Name))
+		}
+	}
+	// map order would shuffle the message between runs
+	for _, name := range slices.Sorted(maps.Keys(dbColumns)) {
+		if dbCol := dbColumns[name]; !mapped[name] && !dbCol.nullable && !dbCol.hasDefault {
+			problems = append(problems, fmt.Sprintf("column %s is NOT NULL without default but isn't mapped", name))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("table %s doesn't match the go mapping: %s", schema.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+type schemaRow struct {
+	ID     int64      `db:"id,bigint"`
+	Name   *string    `db:"name,text"`
+	At     time.Time  `db:"at,timestamp without time zone"`
+	Amount *float64   `db:"amount,double precision"`
+	Seen   *time.Time `db:"seen,timestamp with time zone"`
+}
+
+// matchingColumns is the table as the mapping of schemaRow expects it
+func matchingColumns() map[string]dbColumn {
+	return map[string]dbColumn{
+		"id":     {dataType: "bigint"},
+		"name":   {dataType: "text", nullable: true},
+		"at":     {dataType: "timestamp without time zone"},
+		"amount": {dataType: "double precision", nullable: true},
+		"seen":   {dataType: "timestamp with time zone", nullable: true},
+	}
+}
+
+func TestCompareColumns(t *testing.T) {
+	schema := TableSchemaOf("trips", schemaRow{})
+	if err := compareColumns(schema, matchingColumns()); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		mutate func(map[string]dbColumn)
+		want   []string
+	}{
+		{"dropped column", func(cols map[string]dbColumn) { delete(cols, "amount") },
+			[]string{"column amount is missing"}},
+		{"changed type", func(cols map[string]dbColumn) { cols["id"] = dbColumn{dataType: "numeric"} },
+			[]string{"column id is numeric but the go mapping encodes bigint"}},
+		{"not null", func(cols map[string]dbColumn) { cols["name"] = dbColumn{dataType: "text"} },
+			[]string{"column name is NOT NULL but the go mapping allows nulls"}},
+		{"unmapped not null", func(cols map[string]dbColumn) { cols["zone"] = dbColumn{dataType: "text"} },
+			[]string{"column zone is NOT NULL without default but isn't mapped"}},
+		{"unmapped with default", func(cols map[string]dbColumn) { cols["zone"] = dbColumn{dataType: "text", hasDefault: true} },
+			nil},
+		{"every mismatch at once", func(cols map[string]dbColumn) {
+			delete(cols, "amount")
+			cols["at"] = dbColumn{dataType: "date"}
+		}, []string{"column at is date", "column amount is missing"}},
+	}
+	for _, tt := range tests {
+		cols := matchingColumns()
+		tt.mutate(cols)
+		err := compareColumns(schema, cols)
+		if len(tt.want) == 0 {
+			if err != nil {
+				t.Errorf("%s: expected no mismatch, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected a mismatch", tt.name)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: expected %q in %v", tt.name, want, err)
+			}
+		}
+	}
+}
+
+func TestTableSchemaOfRejectsUnencodableFields(t *testing.T) {
+	tests := map[string]any{
+		"missing tag": struct {
+			ID int64
+		}{},
+		"wrong type": struct {
+			ID int64 `db:"id,text"`
+		}{},
+	}
+	for name, row := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			TableSchemaOf("trips", row)
+		}()
+	}
+}
+
+func TestIsSchemaError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"dropped column", &pgconn.PgError{Code: "42703"}, true},
+		{"dropped table", errors.Wrap(&pgconn.PgError{Code: "42P01"}, "failed copying"), true},
+		{"changed type", &pgconn.PgError{Code: "22P03"}, true},
+		{"missing grant", &pgconn.PgError{Code: "42501"}, false},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"not a postgres error", errors.New("column is missing"), false},
+	}
+	for _, tt := range tests {
+		if got := IsSchemaError(tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
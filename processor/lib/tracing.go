@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"context"
+	"os"
+
+	"processor/logger"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/stats"
+)
+
+const (
+	TracingNone   = "none"
+	TracingOTLP   = "otlp"
+	TracingStdout = "stdout"
+	TracingFile   = "file"
+)
+
+// tracer follows the global provider, spans are dropped until SetupTracing installs one
+var tracer = otel.Tracer("processor")
+
+// TracingConfig selects where spans are exported, the otlp exporter also reads the
+// standard OTEL_EXPORTER_OTLP_* variables
+type TracingConfig struct {
+	Exporter     string
+	ServiceName  string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	File         string
+	// SampleRatio applies to traces started here, a caller's sampling decision is kept
+	SampleRatio float64
+}
+
+// SetupTracing installs the global tracer provider and the w3c trace context propagator,
+// shutdown flushes the pending spans
+func SetupTracing(ctx context.Context, cfg TracingConfig) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, errors.Errorf("trace sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+
+	var exporter sdktrace.SpanExporter
+	closeFile := func() error { return nil }
+	switch cfg.Exporter {
+	case TracingNone, "":
+		return func(context.Context) error { return nil }, nil
+	case TracingOTLP:
+		var opts []otlptracegrpc.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case TracingStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case TracingFile:
+		file, openErr := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if openErr != nil {
+			return nil, errors.Wrapf(openErr, "failed opening trace file %s", cfg.File)
+		}
+		closeFile = file.Close
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return nil, errors.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating %s trace exporter", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed building trace resource")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Info("exporting traces",
+		zap.String("exporter", cfg.Exporter),
+		zap.Float64("sampleRatio", cfg.SampleRatio),
+	)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeFile(); err == nil {
+			err = closeErr
+		}
+		return errors.Wrap(err, "failed flushing traces")
+	}, nil
+}
+
+// TraceStatsHandler starts the server span of every rpc, continuing the trace of the caller
+// from its traceparent metadata, probes and reflection aren't traced
+func TraceStatsHandler() stats.Handler {
+	return otelgrpc.NewServerHandler(otelgrpc.WithFilter(func(info *stats.RPCTagInfo) bool {
+		return !publicMethod(info.FullMethodName)
+	}))
+}
+
+// StartSpan starts a child of the span in ctx
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan marks span as failed when err is set and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
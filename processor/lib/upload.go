@@ -0,0 +1,154 @@
+package lib
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUploadTooLarge   = errors.New("upload is over the size limit")
+	ErrChecksumMismatch = errors.New("upload checksum doesn't match")
+	ErrInvalidArchive   = errors.New("upload isn't a valid archive")
+)
+
+// UploadConfig bounds the files streamed to the processor, an upload is spooled to a file
+// in SpoolDir until its checksum is checked so at most MaxConcurrent*MaxBytes is on disk
+type UploadConfig struct {
+	// MaxBytes applies both to the bytes sent and to the decompressed file
+	MaxBytes      int64
+	MaxConcurrent int
+	// SpoolDir is the directory of the spooled uploads, the temp dir when empty
+	SpoolDir string
+}
+
+func (c UploadConfig) Validate() error {
+	if c.MaxBytes <= 0 {
+		return errors.Errorf("upload max bytes must be positive, got %d", c.MaxBytes)
+	}
+	if c.MaxConcurrent < 1 {
+		return errors.Errorf("upload max concurrent must be positive, got %d", c.MaxConcurrent)
+	}
+	return nil
+}
+
+// ValidChecksum reports whether checksum is a hex sha256
+func ValidChecksum(checksum string) bool {
+	b, err := hex.DecodeString(checksum)
+	return err == nil && len(b) == sha256.Size
+}
+
+// UploadReceiver hashes an upload chunk by chunk as it's received and spools it to a file,
+// the upload is only read back once Finish matched its checksum
+type UploadReceiver struct {
+	maxBytes int64
+	gzipped  bool
+	hash     hash.Hash
+	received int64
+	spool    *os.File
+}
+
+func NewUploadReceiver(cfg UploadConfig, gzipped bool) (*UploadReceiver, error) {
+	spool, err := os.CreateTemp(cfg.SpoolDir, "upload-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating upload spool file")
+	}
+	return &UploadReceiver{
+		maxBytes: cfg.MaxBytes,
+		gzipped:  gzipped,
+		hash:     sha256.New(),
+		spool:    spool,
+	}, nil
+}
+
+// Received returns the bytes received so far, before decompression
+func (r *UploadReceiver) Received() int64 {
+	return r.received
+}
+
+// Write takes the next chunk of the upload
+func (r *UploadReceiver) Write(chunk []byte) (int, error) {
+	if r.received+int64(len(chunk)) > r.maxBytes {
+		return 0, errors.Wrapf(ErrUploadTooLarge, "more than %d bytes sent", r.maxBytes)
+	}
+	r.received += int64(len(chunk))
+	r.hash.Write(chunk)
+	n, err := r.spool.Write(chunk)
+	if err != nil {
+		return n, errors.Wrap(err, "failed spooling upload")
+	}
+	return n, nil
+}
+
+// Finish checks the upload against checksum and returns it decompressed, no checksum is
+// checked when it's empty
+func (r *UploadReceiver) Finish(checksum string) (io.Reader, error) {
+	if checksum != "" {
+		if got := hex.EncodeToString(r.hash.Sum(nil)); got != strings.ToLower(checksum) {
+			return nil, errors.Wrapf(ErrChecksumMismatch, "expected sha256 %s, got %s", checksum, got)
+		}
+	}
+	if _, err := r.spool.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "failed rewinding upload spool file")
+	}
+	var src io.Reader = r.spool
+	if r.gzipped {
+		gz, err := gzip.NewReader(r.spool)
+		if err != nil {
+			return nil, ArchiveError(err, "failed reading gzip header")
+		}
+		src = &gzipStream{gz: gz}
+	}
+	return &limitedReader{src: src, limit: r.maxBytes}, nil
+}
+
+// Close removes the spooled upload
+func (r *UploadReceiver) Close() error {
+	r.spool.Close()
+	return os.Remove(r.spool.Name())
+}
+
+// gzipStream decompresses concatenated gzip streams, a failure is an invalid archive
+type gzipStream struct {
+	gz *gzip.Reader
+}
+
+func (g *gzipStream) Read(p []byte) (int, error) {
+	n, err := g.gz.Read(p)
+	if err != nil && err != io.EOF {
+		return n, ArchiveError(err, "failed decompressing upload")
+	}
+	return n, err
+}
+
+// ArchiveError marks a failure decoding an upload as an invalid archive, the upload ending
+// early or trailing garbage included. An upload over the size limit keeps its error.
+func ArchiveError(err error, msg string) error {
+	if errors.Is(err, ErrUploadTooLarge) || errors.Is(err, ErrInvalidArchive) {
+		return err
+	}
+	return errors.Wrapf(ErrInvalidArchive, "%s: %v", msg, err)
+}
+
+// limitedReader fails a read that takes it over limit, a small gzip upload can inflate
+// to far more than the size checked on the wire
+type limitedReader struct {
+	src   io.Reader
+	read  int64
+	limit int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.src.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return 0, errors.Wrapf(ErrUploadTooLarge, "decompresses to more than %d bytes", l.limit)
+	}
+	return n, err
+}
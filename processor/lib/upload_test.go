@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// receive spools data to a new receiver in dir
+func receive(t *testing.T, dir string, maxBytes int64, gzipped bool, data []byte) *UploadReceiver {
+	t.Helper()
+	r, err := NewUploadReceiver(UploadConfig{MaxBytes: maxBytes, MaxConcurrent: 1, SpoolDir: dir}, gzipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	if _, err := r.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestUploadReceiverChecksUpload(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("a\nb\n")
+	sum := sha256.Sum256(data)
+	r := receive(t, dir, 1<<20, false, data)
+	upload, err := r.Finish(hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(upload)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("expected %q, got %q and %v", data, got, err)
+	}
+
+	// a mismatch gives no reader
+	r = receive(t, dir, 1<<20, false, data)
+	if _, err := r.Finish(hex.EncodeToString(make([]byte, sha256.Size))); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+
+	if _, err := r.Write(make([]byte, 1<<20)); !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("expected the upload to be over the limit, got %v", err)
+	}
+
+	// the spool file is removed on close
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(r.spool.Name()); !os.IsNotExist(err) {
+		t.Fatalf("expected the spool file removed, got %v", err)
+	}
+}
+
+func TestUploadReceiverLimitsInflatedSize(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(make([]byte, 1<<20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	upload, err := receive(t, dir, 64<<10, true, buf.Bytes()).Finish("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(upload); !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("expected the inflated size to be over the limit, got %v", err)
+	}
+
+	if _, err := receive(t, dir, 64<<10, true, []byte("plain text")).Finish(""); !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("expected an invalid archive, got %v", err)
+	}
+}
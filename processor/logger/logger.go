@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"go.uber.org/zap/zapcore"
 	"log"
 
@@ -56,3 +57,19 @@ func Fatal(msg string, fields ...zap.Field) {
 func Sync() error {
 	return zap.L().Sync()
 }
+
+type ctxKey struct{}
+
+// WithFields returns a context whose logger adds fields to the logger of ctx
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	return context.WithValue(ctx, ctxKey{}, FromContext(ctx).With(fields...))
+}
+
+// FromContext returns the request scoped logger of ctx, the global logger outside of a request
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+		return l
+	}
+	// the global logger skips the package level wrappers
+	return zap.L().WithOptions(zap.AddCallerSkip(-1))
+}
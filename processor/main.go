@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
-	"net"
+	"crypto/tls"
 	"os"
 	"os/signal"
+	"runtime"
 	"syscall"
+	"time"
 
 	"processor/handler"
 	"processor/handler/nyc_trip"
@@ -14,36 +16,291 @@ import (
 	pb "processor/protos"
 
 	"github.com/caarlos0/env/v11"
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
-func runGrpcServer(cfg *config, dbConn *pgxpool.Pool, mapId *lib.MapId) {
+// runGrpcServer serves until ctx is done, in-flight requests then get the shutdown grace period to finish
+func runGrpcServer(ctx context.Context, cfg *config, healthServer *health.Server, dbConn *pgxpool.Pool, redisClient *lib.RedisClient, mapId *lib.MapId, hypertable lib.Hypertable) {
 	logger.Info("starting grpc")
-	lis, err := net.Listen("tcp", "0.0.0.0:3000")
+	lis, err := lib.Listen(cfg.GrpcListenAddress)
 	if err != nil {
 		logger.Panic("failed to listen", zap.Error(err))
 	}
+	serverOpts := []grpc.ServerOption{
+		// Stop must wait for the handlers so aborted loads release their lease before redis is closed
+		grpc.WaitForHandlers(true),
+		grpc.StatsHandler(lib.TraceStatsHandler()),
+	}
+	grpcMetrics := grpcprom.NewServerMetrics(grpcprom.WithServerHandlingTimeHistogram())
+	lib.Metrics.MustRegister(grpcMetrics)
+	// the request interceptor runs first so every other log line carries the request id
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		lib.UnaryRequestInterceptor(handler.MethodDatasets),
+		grpcMetrics.UnaryServerInterceptor(),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		lib.StreamRequestInterceptor(handler.MethodDatasets),
+		grpcMetrics.StreamServerInterceptor(),
+	}
+	datasetLimits, err := lib.ParseDatasetLimits(cfg.AdmissionLimits)
+	if err != nil {
+		logger.Panic("invalid admission dataset limits", zap.Error(err))
+	}
+	admissionConfig := lib.AdmissionConfig{
+		MaxRunning:    cfg.AdmissionMaxRunning,
+		DatasetLimits: datasetLimits,
+		MaxQueued:     cfg.AdmissionMaxQueued,
+		MaxWait:       cfg.AdmissionMaxWait,
+		RetryAfter:    cfg.AdmissionRetryAfter,
+	}
+	if err := admissionConfig.Validate(); err != nil {
+		logger.Panic("invalid admission config", zap.Error(err))
+	}
+	scheduler := lib.NewScheduler(admissionConfig)
+	if cfg.AuthEnabled {
+		authenticator, err := lib.NewAuthenticator(lib.AuthConfig{
+			Roles:       cfg.AuthRoles,
+			APIKeys:     cfg.AuthAPIKeys,
+			JWTSecret:   cfg.AuthJWTSecret,
+			JWTIssuer:   cfg.AuthJWTIssuer,
+			JWTAudience: cfg.AuthJWTAudience,
+		})
+		if err != nil {
+			logger.Panic("failed configuring auth", zap.Error(err))
+		}
+		unaryInterceptors = append(unaryInterceptors, authenticator.UnaryAuthInterceptor(handler.MethodDatasets))
+		streamInterceptors = append(streamInterceptors, authenticator.StreamAuthInterceptor(handler.MethodDatasets))
+	} else {
+		logger.Warn("serving grpc without authentication")
+	}
+	// only authorized calls take a place in the admission queue
+	unaryInterceptors = append(unaryInterceptors, scheduler.UnaryAdmissionInterceptor(handler.AdmittedMethods))
+	streamInterceptors = append(streamInterceptors, scheduler.StreamAdmissionInterceptor(handler.AdmittedMethods))
+	serverOpts = append(serverOpts,
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
 
-	inputFileNYCTrip := nyc_trip.NewInputFile(cfg.RedisHost, cfg.RedisDB, dbConn, mapId)
-	InputFileTesting := handler.NewInputFileTesting(mapId)
+	tlsConfig, err := serverTLS(ctx, cfg)
+	if err != nil {
+		logger.Panic("failed configuring tls", zap.Error(err))
+	}
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else {
+		logger.Warn("serving grpc without tls")
+	}
 
-	s := grpc.NewServer()
-	pb.RegisterTransformServiceServer(s, &handler.InputFileHandler{
+	retryPolicy := lib.RetryPolicy{
+		MaxAttempts:    cfg.DBRetryMaxAttempts,
+		InitialBackoff: cfg.DBRetryInitialBackoff,
+		MaxBackoff:     cfg.DBRetryMaxBackoff,
+		Multiplier:     cfg.DBRetryMultiplier,
+	}
+	if err := retryPolicy.Validate(); err != nil {
+		logger.Panic("invalid db retry policy", zap.Error(err))
+	}
+	readerWorkers := cfg.ReaderWorkers
+	if readerWorkers == 0 {
+		readerWorkers = runtime.NumCPU()
+	}
+	pipelineConfig := lib.PipelineConfig{
+		ReaderWorkers:   readerWorkers,
+		ChunkSize:       cfg.ChunkSize,
+		ParserWorkers:   cfg.ParserWorkers,
+		InserterWorkers: cfg.InserterWorkers,
+		BatchSize:       cfg.BatchSize,
+		ChannelSize:     cfg.ChannelSize,
+	}
+	// the autoscaler maxima bound the requests too
+	pipelineLimits := lib.PipelineLimits{
+		ReaderWorkers:   cfg.ReaderWorkersMax,
+		ChunkSize:       cfg.ChunkSizeMax,
+		ParserWorkers:   cfg.ParserWorkersMax,
+		InserterWorkers: cfg.InserterWorkersMax,
+		BatchSize:       cfg.BatchSizeMax,
+		ChannelSize:     cfg.ChannelSizeMax,
+	}
+	if err := pipelineConfig.Validate(); err != nil {
+		logger.Panic("invalid pipeline config", zap.Error(err))
+	}
+	if err := pipelineConfig.Within(pipelineLimits); err != nil {
+		logger.Panic("pipeline config exceeds its limits", zap.Error(err))
+	}
+	adaptiveConfig := lib.AdaptiveConfig{
+		Enabled:            cfg.AdaptiveEnabled,
+		MinBatchSize:       cfg.BatchSizeMin,
+		MaxBatchSize:       cfg.BatchSizeMax,
+		TargetBatchLatency: cfg.BatchTargetLatency,
+		MaxParserWorkers:   cfg.ParserWorkersMax,
+		MaxInserterWorkers: cfg.InserterWorkersMax,
+		ScaleInterval:      cfg.AutoscaleInterval,
+	}
+	if err := adaptiveConfig.Validate(); err != nil {
+		logger.Panic("invalid adaptive config", zap.Error(err))
+	}
+	leaseConfig := lib.LeaseConfig{
+		TTL:            cfg.InputLeaseTTL,
+		ExtendInterval: cfg.InputLeaseExtend,
+		MaxAttempts:    cfg.InputMaxAttempts,
+	}
+	if err := leaseConfig.Validate(); err != nil {
+		logger.Panic("invalid input lease config", zap.Error(err))
+	}
+	uploadConfig := lib.UploadConfig{
+		MaxBytes:      cfg.UploadMaxBytes,
+		MaxConcurrent: cfg.UploadMaxConcurrent,
+		SpoolDir:      cfg.UploadSpoolDir,
+	}
+	if err := uploadConfig.Validate(); err != nil {
+		logger.Panic("invalid upload config", zap.Error(err))
+	}
+	// inserters can't take the connections of the job queue, health checks and profiles
+	inserterSlots := int(dbConn.Config().MaxConns - cfg.DBReservedConns)
+	if cfg.DBReservedConns < 1 || inserterSlots < 1 {
+		logger.Panic("reserved db connections must be positive and below the pool size",
+			zap.Int32("reserved", cfg.DBReservedConns),
+			zap.Int32("maxConns", dbConn.Config().MaxConns),
+		)
+	}
+	inserterLimiter := lib.NewWorkerLimiter(inserterSlots)
+	inputFileNYCTrip := &nyc_trip.InputFile{
+		Redis:           redisClient,
+		DBConn:          dbConn,
+		MapId:           mapId,
+		RetryPolicy:     retryPolicy,
+		PipelineConfig:  pipelineConfig,
+		PipelineLimits:  pipelineLimits,
+		AdaptiveConfig:  adaptiveConfig,
+		InserterLimiter: inserterLimiter,
+		Hypertable:      hypertable,
+		PersistProfile:  cfg.ProfilePersist,
+		InlineMaxBytes:  cfg.InlineMaxBytes,
+		LeaseConfig:     leaseConfig,
+		UploadConfig:    uploadConfig,
+	}
+	inputFileNYCTrip.Init()
+	InputFileTesting := handler.NewInputFileTesting(mapId)
+	inputFileHandler := &handler.InputFileHandler{
 		InputFileNYCTrip: inputFileNYCTrip,
 		InputFileTesting: InputFileTesting,
-	})
+		Admission:        scheduler,
+	}
+	jobConfig := lib.JobConfig{
+		Workers:           cfg.JobWorkers,
+		PollInterval:      cfg.JobPollInterval,
+		HeartbeatInterval: cfg.JobHeartbeatInterval,
+		StaleAfter:        cfg.JobStaleAfter,
+		MaxAttempts:       cfg.JobMaxAttempts,
+	}
+	if err := jobConfig.Validate(); err != nil {
+		logger.Panic("invalid job config", zap.Error(err))
+	}
+	inputFileHandler.Jobs = lib.NewJobPool(lib.NewJobStore(dbConn), jobConfig, inputFileHandler.RunJob, mapId.Ready)
+
+	s := grpc.NewServer(serverOpts...)
+	pb.RegisterTransformServiceServer(s, inputFileHandler)
+	healthpb.RegisterHealthServer(s, healthServer)
+	if cfg.GrpcReflection {
+		reflection.Register(s)
+		logger.Info("registered grpc reflection")
+	}
+	grpcMetrics.InitializeMetrics(s)
 	logger.Info("server listening at", zap.Any("listener", lis.Addr()))
-	if err := s.Serve(lis); err != nil {
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Serve(lis)
+	}()
+	inputFileHandler.Jobs.Start()
+	select {
+	case err := <-serveErr:
 		logger.Panic("failed to serve grpc", zap.Error(err))
+	case <-ctx.Done():
+	}
+
+	// new requests are routed away from the pod while it drains
+	healthServer.Shutdown()
+	logger.Info("draining in-flight requests",
+		zap.Int64("inFlightLoads", inputFileNYCTrip.InFlight()),
+		zap.Int("runningJobs", inputFileHandler.Jobs.Running()),
+		zap.Duration("gracePeriod", cfg.ShutdownGracePeriod),
+	)
+	deadline := time.Now().Add(cfg.ShutdownGracePeriod)
+	// jobs still running at the deadline are queued again for the next processor
+	jobsStopped := make(chan struct{})
+	go func() {
+		inputFileHandler.Jobs.Stop(time.Until(deadline))
+		close(jobsStopped)
+	}()
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		logger.Info("drained in-flight requests")
+	case <-time.After(time.Until(deadline)):
+		logger.Warn("grace period expired, aborting in-flight requests",
+			zap.Int64("inFlightLoads", inputFileNYCTrip.InFlight()),
+		)
+		s.Stop()
+		<-stopped
+	}
+	<-jobsStopped
+	logger.Info("grpc server stopped")
+}
+
+// serverTLS returns nil without a certificate, the certificate is reloaded when its files
+// change or on SIGHUP
+func serverTLS(ctx context.Context, cfg *config) (*tls.Config, error) {
+	if cfg.GrpcTLSCertFile == "" && cfg.GrpcTLSKeyFile == "" {
+		if cfg.GrpcTLSClientCAFile != "" {
+			return nil, errors.New("a client ca requires a server certificate")
+		}
+		return nil, nil
 	}
+	reloader, err := lib.NewCertReloader(cfg.GrpcTLSCertFile, cfg.GrpcTLSKeyFile, cfg.GrpcTLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	go reloader.Watch(ctx, cfg.GrpcTLSReloadInterval)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+			}
+			logger.Info("reloading tls certificate on SIGHUP")
+			if err := reloader.Reload(); err != nil {
+				logger.Error("failed reloading tls certificate", zap.Error(err))
+			}
+		}
+	}()
+	return reloader.ServerConfig(), nil
 }
 
 func main() {
 	var cfg config
 	err := env.Parse(&cfg)
+	if err == nil {
+		err = cfg.validate()
+	}
 	if err != nil {
 		logger.Panic("failed configuring instance", zap.Error(err))
 	}
@@ -63,23 +320,143 @@ func main() {
 	signal.Notify(sig, os.Interrupt)
 	signal.Notify(sig, syscall.SIGTERM)
 	go func() {
-		<-sig
+		received := <-sig
+		logger.Info("shutting down", zap.String("signal", received.String()))
 		cancel()
 	}()
 
-	dbConn, err := lib.NewDBConn(ctx, cfg.DBUrl)
+	shutdownTracing, err := lib.SetupTracing(ctx, lib.TracingConfig{
+		Exporter:     cfg.TracingExporter,
+		ServiceName:  cfg.TracingServiceName,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		OTLPInsecure: cfg.TracingOTLPInsecure,
+		File:         cfg.TracingFile,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		logger.Panic("failed configuring tracing", zap.Error(err))
+	}
+	// spans of the drained requests are flushed last
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed flushing traces", zap.Error(err))
+		}
+	}()
+
+	dbConn, err := lib.NewDBConn(ctx, cfg.DBUrl, lib.DBPoolConfig{
+		MaxConns:        cfg.DBMaxConns,
+		MinConns:        cfg.DBMinConns,
+		MaxConnLifetime: cfg.DBMaxConnLifetime,
+		MaxConnIdleTime: cfg.DBMaxConnIdleTime,
+	})
 	if err != nil {
 		logger.Panic("failed connecting to database", zap.Error(err))
 	}
-	defer dbConn.Close()
+	defer func() {
+		dbConn.Close()
+		logger.Info("closed database pool")
+	}()
 
-	mapId, err := lib.NewMap(ctx, dbConn)
+	redisClient, err := lib.NewRedisClient(ctx, lib.RedisConfig{
+		URL:                   cfg.RedisURL,
+		Host:                  cfg.RedisHost,
+		DB:                    cfg.RedisDB,
+		SentinelMaster:        cfg.RedisSentinelMaster,
+		SentinelAddrs:         cfg.RedisSentinelAddrs,
+		SentinelUsername:      cfg.RedisSentinelUsername,
+		SentinelPassword:      cfg.RedisSentinelPassword,
+		ClusterAddrs:          cfg.RedisClusterAddrs,
+		Username:              cfg.RedisUsername,
+		Password:              cfg.RedisPassword,
+		TLS:                   cfg.RedisTLS,
+		TLSCAFile:             cfg.RedisTLSCAFile,
+		TLSCertFile:           cfg.RedisTLSCertFile,
+		TLSKeyFile:            cfg.RedisTLSKeyFile,
+		TLSServerName:         cfg.RedisTLSServerName,
+		TLSInsecureSkipVerify: cfg.RedisTLSSkipVerify,
+		PoolSize:              cfg.RedisPoolSize,
+		MinIdleConns:          cfg.RedisMinIdleConns,
+		DialTimeout:           cfg.RedisDialTimeout,
+		ReadTimeout:           cfg.RedisReadTimeout,
+		WriteTimeout:          cfg.RedisWriteTimeout,
+		PoolTimeout:           cfg.RedisPoolTimeout,
+	})
+	if err != nil {
+		logger.Panic("failed connecting to redis", zap.Error(err))
+	}
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Error("failed closing redis client", zap.Error(err))
+			return
+		}
+		logger.Info("closed redis client")
+	}()
+
+	for _, schema := range []lib.TableSchema{nyc_trip.TableSchema} {
+		if err := lib.ValidateTableSchema(ctx, dbConn, schema); err != nil {
+			logger.Panic("failed validating table schema", zap.Error(err))
+		}
+		logger.Info("validated table schema", zap.String("table", schema.Name))
+	}
+
+	hypertable, err := lib.DiscoverHypertable(ctx, dbConn, nyc_trip.TableSchema.Name)
+	if err != nil {
+		logger.Panic("failed discovering hypertable", zap.Error(err))
+	}
+	logger.Info("discovered hypertable",
+		zap.String("table", hypertable.Name),
+		zap.String("timeColumn", hypertable.TimeColumn),
+		zap.Duration("chunkInterval", hypertable.ChunkInterval),
+	)
+
+	mapId, err := lib.NewPendingMap()
 	if err != nil {
 		logger.Panic("failed creating Map", zap.Error(err))
 	}
-	lib.WaitMap(mapId)
 	defer lib.CloseMap(mapId)
-	go lib.MustAutoRefreshMap(ctx, dbConn, mapId)
 
-	runGrpcServer(&cfg, dbConn, mapId)
+	healthServer := health.NewServer()
+	monitor := lib.NewHealthMonitor(healthServer,
+		[]string{"", pb.TransformService_ServiceDesc.ServiceName},
+		cfg.HealthCheckInterval, cfg.HealthCheckTimeout,
+		lib.HealthCheck{Service: "postgres", Check: dbConn.Ping},
+		lib.HealthCheck{Service: "redis", Check: redisClient.Ping},
+		lib.HealthCheck{Service: "taxi_zone_map", Check: func(context.Context) error {
+			if !mapId.Ready() {
+				return lib.ErrMapNotReady
+			}
+			return nil
+		}},
+	)
+	go monitor.Run(ctx)
+
+	// the server reports NOT_SERVING until the map is loaded
+	refresherDone := make(chan struct{})
+	go func() {
+		defer close(refresherDone)
+		if err := mapId.Load(ctx, dbConn); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Panic("failed creating Map", zap.Error(err))
+		}
+		monitor.Update()
+		lib.MustAutoRefreshMap(ctx, dbConn, mapId)
+	}()
+
+	lib.RegisterPoolMetrics(dbConn)
+	lib.RegisterMapMetrics(mapId)
+	if cfg.MetricsListenAddress != "" {
+		// metrics outlive the grpc server so the drain can be scraped
+		stopMetrics, err := lib.ServeMetrics(cfg.MetricsListenAddress)
+		if err != nil {
+			logger.Panic("failed serving metrics", zap.Error(err))
+		}
+		defer stopMetrics()
+	}
+
+	runGrpcServer(ctx, &cfg, healthServer, dbConn, redisClient, mapId, hypertable)
+	<-refresherDone
 }
@@ -22,29 +22,2328 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type JobState int32
+
+const (
+	JobState_JOB_STATE_UNSPECIFIED JobState = 0
+	JobState_JOB_STATE_QUEUED      JobState = 1
+	JobState_JOB_STATE_RUNNING     JobState = 2
+	JobState_JOB_STATE_SUCCEEDED   JobState = 3
+	JobState_JOB_STATE_FAILED      JobState = 4
+	JobState_JOB_STATE_CANCELLED   JobState = 5
+)
+
+// Enum value maps for JobState.
+var (
+	JobState_name = map[int32]string{
+		0: "JOB_STATE_UNSPECIFIED",
+		1: "JOB_STATE_QUEUED",
+		2: "JOB_STATE_RUNNING",
+		3: "JOB_STATE_SUCCEEDED",
+		4: "JOB_STATE_FAILED",
+		5: "JOB_STATE_CANCELLED",
+	}
+	JobState_value = map[string]int32{
+		"JOB_STATE_UNSPECIFIED": 0,
+		"JOB_STATE_QUEUED":      1,
+		"JOB_STATE_RUNNING":     2,
+		"JOB_STATE_SUCCEEDED":   3,
+		"JOB_STATE_FAILED":      4,
+		"JOB_STATE_CANCELLED":   5,
+	}
+)
+
+func (x JobState) Enum() *JobState {
+	p := new(JobState)
+	*p = x
+	return p
+}
+
+func (x JobState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobState) Descriptor() protoreflect.EnumDescriptor {
+	return file_transform_proto_enumTypes[0].Descriptor()
+}
+
+func (JobState) Type() protoreflect.EnumType {
+	return &file_transform_proto_enumTypes[0]
+}
+
+func (x JobState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobState.Descriptor instead.
+func (JobState) EnumDescriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{0}
+}
+
+type Compression int32
+
+const (
+	Compression_COMPRESSION_NONE Compression = 0
+	Compression_COMPRESSION_GZIP Compression = 1
+)
+
+// Enum value maps for Compression.
+var (
+	Compression_name = map[int32]string{
+		0: "COMPRESSION_NONE",
+		1: "COMPRESSION_GZIP",
+	}
+	Compression_value = map[string]int32{
+		"COMPRESSION_NONE": 0,
+		"COMPRESSION_GZIP": 1,
+	}
+)
+
+func (x Compression) Enum() *Compression {
+	p := new(Compression)
+	*p = x
+	return p
+}
+
+func (x Compression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Compression) Descriptor() protoreflect.EnumDescriptor {
+	return file_transform_proto_enumTypes[1].Descriptor()
+}
+
+func (Compression) Type() protoreflect.EnumType {
+	return &file_transform_proto_enumTypes[1]
+}
+
+func (x Compression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Compression.Descriptor instead.
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{1}
+}
+
+// Reasons of the google.rpc.ErrorInfo detail attached to failed calls, its domain is
+// processor and its reason is the name below without the ERROR_REASON_ prefix.
+// Reasons are stable, new ones are only appended.
+type ErrorReason int32
+
+const (
+	ErrorReason_ERROR_REASON_UNSPECIFIED ErrorReason = 0
+	// InvalidArgument, a BadRequest detail names the fields
+	ErrorReason_ERROR_REASON_INVALID_REQUEST       ErrorReason = 1
+	ErrorReason_ERROR_REASON_INVALID_OPTIONS       ErrorReason = 2
+	ErrorReason_ERROR_REASON_UNSUPPORTED_FILE_TYPE ErrorReason = 3
+	ErrorReason_ERROR_REASON_INVALID_ARCHIVE       ErrorReason = 4
+	// NotFound
+	ErrorReason_ERROR_REASON_INPUT_NOT_FOUND    ErrorReason = 5
+	ErrorReason_ERROR_REASON_MEMBER_NOT_FOUND   ErrorReason = 6
+	ErrorReason_ERROR_REASON_LOCATION_NOT_FOUND ErrorReason = 7
+	ErrorReason_ERROR_REASON_JOB_NOT_FOUND      ErrorReason = 8
+	// FailedPrecondition, a PreconditionFailure detail lists the mismatches
+	ErrorReason_ERROR_REASON_SCHEMA_MISMATCH ErrorReason = 9
+	ErrorReason_ERROR_REASON_JOB_FINISHED    ErrorReason = 10
+	// AlreadyExists, another processor is loading the same input
+	ErrorReason_ERROR_REASON_DUPLICATE_FILE ErrorReason = 11
+	// Aborted, the input was taken over by another processor while loading
+	ErrorReason_ERROR_REASON_LEASE_LOST ErrorReason = 12
+	// Unavailable, a RetryInfo detail gives the delay before retrying
+	ErrorReason_ERROR_REASON_DATABASE_UNAVAILABLE ErrorReason = 13
+	ErrorReason_ERROR_REASON_REDIS_UNAVAILABLE    ErrorReason = 14
+	ErrorReason_ERROR_REASON_MAP_NOT_READY        ErrorReason = 15
+	ErrorReason_ERROR_REASON_SHUTTING_DOWN        ErrorReason = 16
+	// ResourceExhausted, a RetryInfo detail gives the delay before retrying when it's worth it
+	ErrorReason_ERROR_REASON_QUEUE_FULL        ErrorReason = 17
+	ErrorReason_ERROR_REASON_ADMISSION_TIMEOUT ErrorReason = 18
+	ErrorReason_ERROR_REASON_UPLOAD_TOO_LARGE  ErrorReason = 19
+	// DataLoss
+	ErrorReason_ERROR_REASON_CHECKSUM_MISMATCH ErrorReason = 20
+	ErrorReason_ERROR_REASON_UPLOAD_INCOMPLETE ErrorReason = 21
+	// Internal
+	ErrorReason_ERROR_REASON_INTERNAL ErrorReason = 22
+	// Aborted, or the cancellation code of a caller who gave up, batches were committed
+	// before the failure and sending the file again would insert them twice, the metadata
+	// gives the committed rows, an input staged in redis is dead-lettered
+	ErrorReason_ERROR_REASON_PARTIALLY_LOADED ErrorReason = 23
+)
+
+// Enum value maps for ErrorReason.
+var (
+	ErrorReason_name = map[int32]string{
+		0:  "ERROR_REASON_UNSPECIFIED",
+		1:  "ERROR_REASON_INVALID_REQUEST",
+		2:  "ERROR_REASON_INVALID_OPTIONS",
+		3:  "ERROR_REASON_UNSUPPORTED_FILE_TYPE",
+		4:  "ERROR_REASON_INVALID_ARCHIVE",
+		5:  "ERROR_REASON_INPUT_NOT_FOUND",
+		6:  "ERROR_REASON_MEMBER_NOT_FOUND",
+		7:  "ERROR_REASON_LOCATION_NOT_FOUND",
+		8:  "ERROR_REASON_JOB_NOT_FOUND",
+		9:  "ERROR_REASON_SCHEMA_MISMATCH",
+		10: "ERROR_REASON_JOB_FINISHED",
+		11: "ERROR_REASON_DUPLICATE_FILE",
+		12: "ERROR_REASON_LEASE_LOST",
+		13: "ERROR_REASON_DATABASE_UNAVAILABLE",
+		14: "ERROR_REASON_REDIS_UNAVAILABLE",
+		15: "ERROR_REASON_MAP_NOT_READY",
+		16: "ERROR_REASON_SHUTTING_DOWN",
+		17: "ERROR_REASON_QUEUE_FULL",
+		18: "ERROR_REASON_ADMISSION_TIMEOUT",
+		19: "ERROR_REASON_UPLOAD_TOO_LARGE",
+		20: "ERROR_REASON_CHECKSUM_MISMATCH",
+		21: "ERROR_REASON_UPLOAD_INCOMPLETE",
+		22: "ERROR_REASON_INTERNAL",
+		23: "ERROR_REASON_PARTIALLY_LOADED",
+	}
+	ErrorReason_value = map[string]int32{
+		"ERROR_REASON_UNSPECIFIED":           0,
+		"ERROR_REASON_INVALID_REQUEST":       1,
+		"ERROR_REASON_INVALID_OPTIONS":       2,
+		"ERROR_REASON_UNSUPPORTED_FILE_TYPE": 3,
+		"ERROR_REASON_INVALID_ARCHIVE":       4,
+		"ERROR_REASON_INPUT_NOT_FOUND":       5,
+		"ERROR_REASON_MEMBER_NOT_FOUND":      6,
+		"ERROR_REASON_LOCATION_NOT_FOUND":    7,
+		"ERROR_REASON_JOB_NOT_FOUND":         8,
+		"ERROR_REASON_SCHEMA_MISMATCH":       9,
+		"ERROR_REASON_JOB_FINISHED":          10,
+		"ERROR_REASON_DUPLICATE_FILE":        11,
+		"ERROR_REASON_LEASE_LOST":            12,
+		"ERROR_REASON_DATABASE_UNAVAILABLE":  13,
+		"ERROR_REASON_REDIS_UNAVAILABLE":     14,
+		"ERROR_REASON_MAP_NOT_READY":         15,
+		"ERROR_REASON_SHUTTING_DOWN":         16,
+		"ERROR_REASON_QUEUE_FULL":            17,
+		"ERROR_REASON_ADMISSION_TIMEOUT":     18,
+		"ERROR_REASON_UPLOAD_TOO_LARGE":      19,
+		"ERROR_REASON_CHECKSUM_MISMATCH":     20,
+		"ERROR_REASON_UPLOAD_INCOMPLETE":     21,
+		"ERROR_REASON_INTERNAL":              22,
+		"ERROR_REASON_PARTIALLY_LOADED":      23,
+	}
+)
+
+func (x ErrorReason) Enum() *ErrorReason {
+	p := new(ErrorReason)
+	*p = x
+	return p
+}
+
+func (x ErrorReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_transform_proto_enumTypes[2].Descriptor()
+}
+
+func (ErrorReason) Type() protoreflect.EnumType {
+	return &file_transform_proto_enumTypes[2]
+}
+
+func (x ErrorReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorReason.Descriptor instead.
+func (ErrorReason) EnumDescriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{2}
+}
+
 type InputFileRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	InputFile      string                 `protobuf:"bytes,1,opt,name=input_file,json=inputFile,proto3" json:"input_file,omitempty"`
 	RemoteFilePath string                 `protobuf:"bytes,2,opt,name=remote_file_path,json=remoteFilePath,proto3" json:"remote_file_path,omitempty"`
+	// Optional, zero values fall back to the processor config
+	Options       *PipelineOptions `protobuf:"bytes,3,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InputFileRequest) Reset() {
+	*x = InputFileRequest{}
+	mi := &file_transform_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InputFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InputFileRequest) ProtoMessage() {}
+
+func (x *InputFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InputFileRequest.ProtoReflect.Descriptor instead.
+func (*InputFileRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *InputFileRequest) GetInputFile() string {
+	if x != nil {
+		return x.InputFile
+	}
+	return ""
+}
+
+func (x *InputFileRequest) GetRemoteFilePath() string {
+	if x != nil {
+		return x.RemoteFilePath
+	}
+	return ""
+}
+
+func (x *InputFileRequest) GetOptions() *PipelineOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type ValidateFileRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Source:
+	//
+	//	*ValidateFileRequest_InputFile
+	//	*ValidateFileRequest_InlineData
+	Source   isValidateFileRequest_Source `protobuf_oneof:"source"`
+	FileName string                       `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Options  *PipelineOptions             `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	// Number of rejected rows returned, 10 when zero
+	SampleSize    int32 `protobuf:"varint,5,opt,name=sample_size,json=sampleSize,proto3" json:"sample_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateFileRequest) Reset() {
+	*x = ValidateFileRequest{}
+	mi := &file_transform_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateFileRequest) ProtoMessage() {}
+
+func (x *ValidateFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateFileRequest.ProtoReflect.Descriptor instead.
+func (*ValidateFileRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ValidateFileRequest) GetSource() isValidateFileRequest_Source {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *ValidateFileRequest) GetInputFile() string {
+	if x != nil {
+		if x, ok := x.Source.(*ValidateFileRequest_InputFile); ok {
+			return x.InputFile
+		}
+	}
+	return ""
+}
+
+func (x *ValidateFileRequest) GetInlineData() []byte {
+	if x != nil {
+		if x, ok := x.Source.(*ValidateFileRequest_InlineData); ok {
+			return x.InlineData
+		}
+	}
+	return nil
+}
+
+func (x *ValidateFileRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ValidateFileRequest) GetOptions() *PipelineOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *ValidateFileRequest) GetSampleSize() int32 {
+	if x != nil {
+		return x.SampleSize
+	}
+	return 0
+}
+
+type isValidateFileRequest_Source interface {
+	isValidateFileRequest_Source()
+}
+
+type ValidateFileRequest_InputFile struct {
+	// Redis key of the input, it isn't deleted
+	InputFile string `protobuf:"bytes,1,opt,name=input_file,json=inputFile,proto3,oneof"`
+}
+
+type ValidateFileRequest_InlineData struct {
+	// Small inputs can be sent inline, file_name then gives the format
+	InlineData []byte `protobuf:"bytes,2,opt,name=inline_data,json=inlineData,proto3,oneof"`
+}
+
+func (*ValidateFileRequest_InputFile) isValidateFileRequest_Source() {}
+
+func (*ValidateFileRequest_InlineData) isValidateFileRequest_Source() {}
+
+type PreviewFileRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Redis key of the input, it isn't deleted
+	InputFile string `protobuf:"bytes,1,opt,name=input_file,json=inputFile,proto3" json:"input_file,omitempty"`
+	// Archive member to preview, the first member by name when empty
+	Member string `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	// Number of rows returned, 20 when zero
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewFileRequest) Reset() {
+	*x = PreviewFileRequest{}
+	mi := &file_transform_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewFileRequest) ProtoMessage() {}
+
+func (x *PreviewFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewFileRequest.ProtoReflect.Descriptor instead.
+func (*PreviewFileRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PreviewFileRequest) GetInputFile() string {
+	if x != nil {
+		return x.InputFile
+	}
+	return ""
+}
+
+func (x *PreviewFileRequest) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *PreviewFileRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type PreviewFileResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Member string                 `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"`
+	// Every member of the input, sorted by name
+	Members       []string      `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	Header        []string      `protobuf:"bytes,3,rep,name=header,proto3" json:"header,omitempty"`
+	Rows          []*PreviewRow `protobuf:"bytes,4,rep,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewFileResponse) Reset() {
+	*x = PreviewFileResponse{}
+	mi := &file_transform_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewFileResponse) ProtoMessage() {}
+
+func (x *PreviewFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewFileResponse.ProtoReflect.Descriptor instead.
+func (*PreviewFileResponse) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PreviewFileResponse) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *PreviewFileResponse) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *PreviewFileResponse) GetHeader() []string {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *PreviewFileResponse) GetRows() []*PreviewRow {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+type PreviewRow struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	LineNumber int64                  `protobuf:"varint,1,opt,name=line_number,json=lineNumber,proto3" json:"line_number,omitempty"`
+	RawFields  []string               `protobuf:"bytes,2,rep,name=raw_fields,json=rawFields,proto3" json:"raw_fields,omitempty"`
+	// The row as it would be written, unset when an error drops it
+	Row           *TripRow      `protobuf:"bytes,3,opt,name=row,proto3" json:"row,omitempty"`
+	Errors        []*FieldError `protobuf:"bytes,4,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewRow) Reset() {
+	*x = PreviewRow{}
+	mi := &file_transform_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewRow) ProtoMessage() {}
+
+func (x *PreviewRow) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewRow.ProtoReflect.Descriptor instead.
+func (*PreviewRow) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PreviewRow) GetLineNumber() int64 {
+	if x != nil {
+		return x.LineNumber
+	}
+	return 0
+}
+
+func (x *PreviewRow) GetRawFields() []string {
+	if x != nil {
+		return x.RawFields
+	}
+	return nil
+}
+
+func (x *PreviewRow) GetRow() *TripRow {
+	if x != nil {
+		return x.Row
+	}
+	return nil
+}
+
+func (x *PreviewRow) GetErrors() []*FieldError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type FieldError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Field string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	// invalid_value or unknown_zone
+	Reason  string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// Unset when the value is loaded as null instead
+	DropsRow      bool `protobuf:"varint,4,opt,name=drops_row,json=dropsRow,proto3" json:"drops_row,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldError) Reset() {
+	*x = FieldError{}
+	mi := &file_transform_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldError) ProtoMessage() {}
+
+func (x *FieldError) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldError.ProtoReflect.Descriptor instead.
+func (*FieldError) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FieldError) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldError) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *FieldError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *FieldError) GetDropsRow() bool {
+	if x != nil {
+		return x.DropsRow
+	}
+	return false
+}
+
+// A nyc_trip row, do_location_region is written to do_location_regin
+type TripRow struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Vendor               string                 `protobuf:"bytes,1,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	PickupTime           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=pickup_time,json=pickupTime,proto3" json:"pickup_time,omitempty"`
+	DropoffTime          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=dropoff_time,json=dropoffTime,proto3" json:"dropoff_time,omitempty"`
+	PassengerCount       int64                  `protobuf:"varint,4,opt,name=passenger_count,json=passengerCount,proto3" json:"passenger_count,omitempty"`
+	TripDistance         float64                `protobuf:"fixed64,5,opt,name=trip_distance,json=tripDistance,proto3" json:"trip_distance,omitempty"`
+	PuLocationRegion     string                 `protobuf:"bytes,6,opt,name=pu_location_region,json=puLocationRegion,proto3" json:"pu_location_region,omitempty"`
+	PuLocationZone       string                 `protobuf:"bytes,7,opt,name=pu_location_zone,json=puLocationZone,proto3" json:"pu_location_zone,omitempty"`
+	DoLocationRegion     string                 `protobuf:"bytes,8,opt,name=do_location_region,json=doLocationRegion,proto3" json:"do_location_region,omitempty"`
+	DoLocationZone       string                 `protobuf:"bytes,9,opt,name=do_location_zone,json=doLocationZone,proto3" json:"do_location_zone,omitempty"`
+	PaymentType          string                 `protobuf:"bytes,10,opt,name=payment_type,json=paymentType,proto3" json:"payment_type,omitempty"`
+	FareAmount           *float64               `protobuf:"fixed64,11,opt,name=fare_amount,json=fareAmount,proto3,oneof" json:"fare_amount,omitempty"`
+	Extra                *float64               `protobuf:"fixed64,12,opt,name=extra,proto3,oneof" json:"extra,omitempty"`
+	MtaTax               *float64               `protobuf:"fixed64,13,opt,name=mta_tax,json=mtaTax,proto3,oneof" json:"mta_tax,omitempty"`
+	TipAmount            *float64               `protobuf:"fixed64,14,opt,name=tip_amount,json=tipAmount,proto3,oneof" json:"tip_amount,omitempty"`
+	TollsAmount          *float64               `protobuf:"fixed64,15,opt,name=tolls_amount,json=tollsAmount,proto3,oneof" json:"tolls_amount,omitempty"`
+	ImprovementSurcharge *float64               `protobuf:"fixed64,16,opt,name=improvement_surcharge,json=improvementSurcharge,proto3,oneof" json:"improvement_surcharge,omitempty"`
+	TotalAmount          *float64               `protobuf:"fixed64,17,opt,name=total_amount,json=totalAmount,proto3,oneof" json:"total_amount,omitempty"`
+	CongestionSurcharge  *float64               `protobuf:"fixed64,18,opt,name=congestion_surcharge,json=congestionSurcharge,proto3,oneof" json:"congestion_surcharge,omitempty"`
+	AirportFee           *float64               `protobuf:"fixed64,19,opt,name=airport_fee,json=airportFee,proto3,oneof" json:"airport_fee,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *TripRow) Reset() {
+	*x = TripRow{}
+	mi := &file_transform_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripRow) ProtoMessage() {}
+
+func (x *TripRow) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripRow.ProtoReflect.Descriptor instead.
+func (*TripRow) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TripRow) GetVendor() string {
+	if x != nil {
+		return x.Vendor
+	}
+	return ""
+}
+
+func (x *TripRow) GetPickupTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PickupTime
+	}
+	return nil
+}
+
+func (x *TripRow) GetDropoffTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DropoffTime
+	}
+	return nil
+}
+
+func (x *TripRow) GetPassengerCount() int64 {
+	if x != nil {
+		return x.PassengerCount
+	}
+	return 0
+}
+
+func (x *TripRow) GetTripDistance() float64 {
+	if x != nil {
+		return x.TripDistance
+	}
+	return 0
+}
+
+func (x *TripRow) GetPuLocationRegion() string {
+	if x != nil {
+		return x.PuLocationRegion
+	}
+	return ""
+}
+
+func (x *TripRow) GetPuLocationZone() string {
+	if x != nil {
+		return x.PuLocationZone
+	}
+	return ""
+}
+
+func (x *TripRow) GetDoLocationRegion() string {
+	if x != nil {
+		return x.DoLocationRegion
+	}
+	return ""
+}
+
+func (x *TripRow) GetDoLocationZone() string {
+	if x != nil {
+		return x.DoLocationZone
+	}
+	return ""
+}
+
+func (x *TripRow) GetPaymentType() string {
+	if x != nil {
+		return x.PaymentType
+	}
+	return ""
+}
+
+func (x *TripRow) GetFareAmount() float64 {
+	if x != nil && x.FareAmount != nil {
+		return *x.FareAmount
+	}
+	return 0
+}
+
+func (x *TripRow) GetExtra() float64 {
+	if x != nil && x.Extra != nil {
+		return *x.Extra
+	}
+	return 0
+}
+
+func (x *TripRow) GetMtaTax() float64 {
+	if x != nil && x.MtaTax != nil {
+		return *x.MtaTax
+	}
+	return 0
+}
+
+func (x *TripRow) GetTipAmount() float64 {
+	if x != nil && x.TipAmount != nil {
+		return *x.TipAmount
+	}
+	return 0
+}
+
+func (x *TripRow) GetTollsAmount() float64 {
+	if x != nil && x.TollsAmount != nil {
+		return *x.TollsAmount
+	}
+	return 0
+}
+
+func (x *TripRow) GetImprovementSurcharge() float64 {
+	if x != nil && x.ImprovementSurcharge != nil {
+		return *x.ImprovementSurcharge
+	}
+	return 0
+}
+
+func (x *TripRow) GetTotalAmount() float64 {
+	if x != nil && x.TotalAmount != nil {
+		return *x.TotalAmount
+	}
+	return 0
+}
+
+func (x *TripRow) GetCongestionSurcharge() float64 {
+	if x != nil && x.CongestionSurcharge != nil {
+		return *x.CongestionSurcharge
+	}
+	return 0
+}
+
+func (x *TripRow) GetAirportFee() float64 {
+	if x != nil && x.AirportFee != nil {
+		return *x.AirportFee
+	}
+	return 0
+}
+
+type PipelineOptions struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ParserWorkers   int32                  `protobuf:"varint,1,opt,name=parser_workers,json=parserWorkers,proto3" json:"parser_workers,omitempty"`
+	InserterWorkers int32                  `protobuf:"varint,2,opt,name=inserter_workers,json=inserterWorkers,proto3" json:"inserter_workers,omitempty"`
+	BatchSize       int32                  `protobuf:"varint,3,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	ChannelSize     int32                  `protobuf:"varint,4,opt,name=channel_size,json=channelSize,proto3" json:"channel_size,omitempty"`
+	ReaderWorkers   int32                  `protobuf:"varint,5,opt,name=reader_workers,json=readerWorkers,proto3" json:"reader_workers,omitempty"`
+	ChunkSize       int64                  `protobuf:"varint,6,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PipelineOptions) Reset() {
+	*x = PipelineOptions{}
+	mi := &file_transform_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PipelineOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PipelineOptions) ProtoMessage() {}
+
+func (x *PipelineOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PipelineOptions.ProtoReflect.Descriptor instead.
+func (*PipelineOptions) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PipelineOptions) GetParserWorkers() int32 {
+	if x != nil {
+		return x.ParserWorkers
+	}
+	return 0
+}
+
+func (x *PipelineOptions) GetInserterWorkers() int32 {
+	if x != nil {
+		return x.InserterWorkers
+	}
+	return 0
+}
+
+func (x *PipelineOptions) GetBatchSize() int32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+func (x *PipelineOptions) GetChannelSize() int32 {
+	if x != nil {
+		return x.ChannelSize
+	}
+	return 0
+}
+
+func (x *PipelineOptions) GetReaderWorkers() int32 {
+	if x != nil {
+		return x.ReaderWorkers
+	}
+	return 0
+}
+
+func (x *PipelineOptions) GetChunkSize() int64 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+type ProcessFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TotalRows     int64                  `protobuf:"varint,1,opt,name=total_rows,json=totalRows,proto3" json:"total_rows,omitempty"`
+	DroppedRows   int64                  `protobuf:"varint,2,opt,name=dropped_rows,json=droppedRows,proto3" json:"dropped_rows,omitempty"`
+	ProcessedRows int64                  `protobuf:"varint,3,opt,name=processed_rows,json=processedRows,proto3" json:"processed_rows,omitempty"`
+	InsertedRows  int64                  `protobuf:"varint,4,opt,name=inserted_rows,json=insertedRows,proto3" json:"inserted_rows,omitempty"`
+	MaxTime       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	MinTime       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	BatchRetries  int64                  `protobuf:"varint,7,opt,name=batch_retries,json=batchRetries,proto3" json:"batch_retries,omitempty"`
+	Adaptive      *AdaptiveStats         `protobuf:"bytes,8,opt,name=adaptive,proto3" json:"adaptive,omitempty"`
+	// Hypertable chunks written, oldest first
+	Chunks  []*ChunkStats `protobuf:"bytes,9,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	Profile *Profile      `protobuf:"bytes,10,opt,name=profile,proto3" json:"profile,omitempty"`
+	// Dropped rows per field and reason, most frequent first
+	DropReasons []*DropReason `protobuf:"bytes,11,rep,name=drop_reasons,json=dropReasons,proto3" json:"drop_reasons,omitempty"`
+	// First rejected rows in input order
+	RejectedRows  []*RejectedRow `protobuf:"bytes,12,rep,name=rejected_rows,json=rejectedRows,proto3" json:"rejected_rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessFileResponse) Reset() {
+	*x = ProcessFileResponse{}
+	mi := &file_transform_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessFileResponse) ProtoMessage() {}
+
+func (x *ProcessFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessFileResponse.ProtoReflect.Descriptor instead.
+func (*ProcessFileResponse) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ProcessFileResponse) GetTotalRows() int64 {
+	if x != nil {
+		return x.TotalRows
+	}
+	return 0
+}
+
+func (x *ProcessFileResponse) GetDroppedRows() int64 {
+	if x != nil {
+		return x.DroppedRows
+	}
+	return 0
+}
+
+func (x *ProcessFileResponse) GetProcessedRows() int64 {
+	if x != nil {
+		return x.ProcessedRows
+	}
+	return 0
+}
+
+func (x *ProcessFileResponse) GetInsertedRows() int64 {
+	if x != nil {
+		return x.InsertedRows
+	}
+	return 0
+}
+
+func (x *ProcessFileResponse) GetMaxTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MaxTime
+	}
+	return nil
+}
+
+func (x *ProcessFileResponse) GetMinTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MinTime
+	}
+	return nil
+}
+
+func (x *ProcessFileResponse) GetBatchRetries() int64 {
+	if x != nil {
+		return x.BatchRetries
+	}
+	return 0
+}
+
+func (x *ProcessFileResponse) GetAdaptive() *AdaptiveStats {
+	if x != nil {
+		return x.Adaptive
+	}
+	return nil
+}
+
+func (x *ProcessFileResponse) GetChunks() []*ChunkStats {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+func (x *ProcessFileResponse) GetProfile() *Profile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+func (x *ProcessFileResponse) GetDropReasons() []*DropReason {
+	if x != nil {
+		return x.DropReasons
+	}
+	return nil
+}
+
+func (x *ProcessFileResponse) GetRejectedRows() []*RejectedRow {
+	if x != nil {
+		return x.RejectedRows
+	}
+	return nil
+}
+
+type DropReason struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Field string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	// invalid_value or unknown_zone
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Count         int64  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DropReason) Reset() {
+	*x = DropReason{}
+	mi := &file_transform_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DropReason) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DropReason) ProtoMessage() {}
+
+func (x *DropReason) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DropReason.ProtoReflect.Descriptor instead.
+func (*DropReason) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DropReason) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *DropReason) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *DropReason) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type RejectedRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Member        string                 `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"`
+	LineNumber    int64                  `protobuf:"varint,2,opt,name=line_number,json=lineNumber,proto3" json:"line_number,omitempty"`
+	Field         string                 `protobuf:"bytes,3,opt,name=field,proto3" json:"field,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	RawFields     []string               `protobuf:"bytes,5,rep,name=raw_fields,json=rawFields,proto3" json:"raw_fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RejectedRow) Reset() {
+	*x = RejectedRow{}
+	mi := &file_transform_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectedRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectedRow) ProtoMessage() {}
+
+func (x *RejectedRow) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectedRow.ProtoReflect.Descriptor instead.
+func (*RejectedRow) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RejectedRow) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *RejectedRow) GetLineNumber() int64 {
+	if x != nil {
+		return x.LineNumber
+	}
+	return 0
+}
+
+func (x *RejectedRow) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *RejectedRow) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RejectedRow) GetRawFields() []string {
+	if x != nil {
+		return x.RawFields
+	}
+	return nil
+}
+
+type AdaptiveStats struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	BatchSize        int64                  `protobuf:"varint,1,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	MinBatchSize     int64                  `protobuf:"varint,2,opt,name=min_batch_size,json=minBatchSize,proto3" json:"min_batch_size,omitempty"`
+	MaxBatchSize     int64                  `protobuf:"varint,3,opt,name=max_batch_size,json=maxBatchSize,proto3" json:"max_batch_size,omitempty"`
+	BatchAdjustments int64                  `protobuf:"varint,4,opt,name=batch_adjustments,json=batchAdjustments,proto3" json:"batch_adjustments,omitempty"`
+	ScaleEvents      int64                  `protobuf:"varint,5,opt,name=scale_events,json=scaleEvents,proto3" json:"scale_events,omitempty"`
+	Stages           []*StageStats          `protobuf:"bytes,6,rep,name=stages,proto3" json:"stages,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AdaptiveStats) Reset() {
+	*x = AdaptiveStats{}
+	mi := &file_transform_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdaptiveStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdaptiveStats) ProtoMessage() {}
+
+func (x *AdaptiveStats) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdaptiveStats.ProtoReflect.Descriptor instead.
+func (*AdaptiveStats) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AdaptiveStats) GetBatchSize() int64 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+func (x *AdaptiveStats) GetMinBatchSize() int64 {
+	if x != nil {
+		return x.MinBatchSize
+	}
+	return 0
+}
+
+func (x *AdaptiveStats) GetMaxBatchSize() int64 {
+	if x != nil {
+		return x.MaxBatchSize
+	}
+	return 0
+}
+
+func (x *AdaptiveStats) GetBatchAdjustments() int64 {
+	if x != nil {
+		return x.BatchAdjustments
+	}
+	return 0
+}
+
+func (x *AdaptiveStats) GetScaleEvents() int64 {
+	if x != nil {
+		return x.ScaleEvents
+	}
+	return 0
+}
+
+func (x *AdaptiveStats) GetStages() []*StageStats {
+	if x != nil {
+		return x.Stages
+	}
+	return nil
+}
+
+type StageStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	FinalWorkers  int64                  `protobuf:"varint,2,opt,name=final_workers,json=finalWorkers,proto3" json:"final_workers,omitempty"`
+	PeakWorkers   int64                  `protobuf:"varint,3,opt,name=peak_workers,json=peakWorkers,proto3" json:"peak_workers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StageStats) Reset() {
+	*x = StageStats{}
+	mi := &file_transform_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StageStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StageStats) ProtoMessage() {}
+
+func (x *StageStats) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StageStats.ProtoReflect.Descriptor instead.
+func (*StageStats) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StageStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StageStats) GetFinalWorkers() int64 {
+	if x != nil {
+		return x.FinalWorkers
+	}
+	return 0
+}
+
+func (x *StageStats) GetPeakWorkers() int64 {
+	if x != nil {
+		return x.PeakWorkers
+	}
+	return 0
+}
+
+// Column profile of the loaded rows
+type Profile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rows          int64                  `protobuf:"varint,1,opt,name=rows,proto3" json:"rows,omitempty"`
+	Columns       []*ColumnProfile       `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Profile) Reset() {
+	*x = Profile{}
+	mi := &file_transform_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Profile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Profile) ProtoMessage() {}
+
+func (x *Profile) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Profile.ProtoReflect.Descriptor instead.
+func (*Profile) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Profile) GetRows() int64 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *Profile) GetColumns() []*ColumnProfile {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+type ColumnProfile struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Count            int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Nulls            int64                  `protobuf:"varint,3,opt,name=nulls,proto3" json:"nulls,omitempty"`
+	DistinctEstimate int64                  `protobuf:"varint,4,opt,name=distinct_estimate,json=distinctEstimate,proto3" json:"distinct_estimate,omitempty"`
+	// Numeric columns only
+	Min  float64 `protobuf:"fixed64,5,opt,name=min,proto3" json:"min,omitempty"`
+	Max  float64 `protobuf:"fixed64,6,opt,name=max,proto3" json:"max,omitempty"`
+	Mean float64 `protobuf:"fixed64,7,opt,name=mean,proto3" json:"mean,omitempty"`
+	// Time columns only
+	MinTime       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	MaxTime       *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	Histogram     *Histogram             `protobuf:"bytes,10,opt,name=histogram,proto3" json:"histogram,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ColumnProfile) Reset() {
+	*x = ColumnProfile{}
+	mi := &file_transform_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColumnProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColumnProfile) ProtoMessage() {}
+
+func (x *ColumnProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColumnProfile.ProtoReflect.Descriptor instead.
+func (*ColumnProfile) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ColumnProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ColumnProfile) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ColumnProfile) GetNulls() int64 {
+	if x != nil {
+		return x.Nulls
+	}
+	return 0
+}
+
+func (x *ColumnProfile) GetDistinctEstimate() int64 {
+	if x != nil {
+		return x.DistinctEstimate
+	}
+	return 0
+}
+
+func (x *ColumnProfile) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *ColumnProfile) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *ColumnProfile) GetMean() float64 {
+	if x != nil {
+		return x.Mean
+	}
+	return 0
+}
+
+func (x *ColumnProfile) GetMinTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MinTime
+	}
+	return nil
+}
+
+func (x *ColumnProfile) GetMaxTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MaxTime
+	}
+	return nil
+}
+
+func (x *ColumnProfile) GetHistogram() *Histogram {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+type Histogram struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	UpperBounds []float64              `protobuf:"fixed64,1,rep,packed,name=upper_bounds,json=upperBounds,proto3" json:"upper_bounds,omitempty"`
+	// One more count than bounds, the last one counts the values above every bound
+	Counts        []int64 `protobuf:"varint,2,rep,packed,name=counts,proto3" json:"counts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Histogram) Reset() {
+	*x = Histogram{}
+	mi := &file_transform_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Histogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Histogram) ProtoMessage() {}
+
+func (x *Histogram) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Histogram.ProtoReflect.Descriptor instead.
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Histogram) GetUpperBounds() []float64 {
+	if x != nil {
+		return x.UpperBounds
+	}
+	return nil
+}
+
+func (x *Histogram) GetCounts() []int64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+type ChunkStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	Rows          int64                  `protobuf:"varint,3,opt,name=rows,proto3" json:"rows,omitempty"`
+	Batches       int64                  `protobuf:"varint,4,opt,name=batches,proto3" json:"batches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChunkStats) Reset() {
+	*x = ChunkStats{}
+	mi := &file_transform_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChunkStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkStats) ProtoMessage() {}
+
+func (x *ChunkStats) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChunkStats.ProtoReflect.Descriptor instead.
+func (*ChunkStats) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ChunkStats) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *ChunkStats) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *ChunkStats) GetRows() int64 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *ChunkStats) GetBatches() int64 {
+	if x != nil {
+		return x.Batches
+	}
+	return 0
+}
+
+type Job struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	State          JobState               `protobuf:"varint,2,opt,name=state,proto3,enum=JobState" json:"state,omitempty"`
+	Dataset        string                 `protobuf:"bytes,3,opt,name=dataset,proto3" json:"dataset,omitempty"`
+	InputFile      string                 `protobuf:"bytes,4,opt,name=input_file,json=inputFile,proto3" json:"input_file,omitempty"`
+	RemoteFilePath string                 `protobuf:"bytes,5,opt,name=remote_file_path,json=remoteFilePath,proto3" json:"remote_file_path,omitempty"`
+	Options        *PipelineOptions       `protobuf:"bytes,6,opt,name=options,proto3" json:"options,omitempty"`
+	SubmittedBy    string                 `protobuf:"bytes,7,opt,name=submitted_by,json=submittedBy,proto3" json:"submitted_by,omitempty"`
+	// Number of times the job started, a job interrupted by a restart runs again
+	Attempts   int32                  `protobuf:"varint,8,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	CreatedAt  *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartedAt  *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	// Set once the job succeeded
+	Result *ProcessFileResponse `protobuf:"bytes,12,opt,name=result,proto3" json:"result,omitempty"`
+	// grpc code name and message of a failed job
+	ErrorCode string `protobuf:"bytes,13,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	Error     string `protobuf:"bytes,14,opt,name=error,proto3" json:"error,omitempty"`
+	// Set when a cancel was requested while the job was running
+	CancelRequested bool `protobuf:"varint,15,opt,name=cancel_requested,json=cancelRequested,proto3" json:"cancel_requested,omitempty"`
+	// Last progress saved while the job ran
+	Progress      *JobProgress `protobuf:"bytes,16,opt,name=progress,proto3" json:"progress,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_transform_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetState() JobState {
+	if x != nil {
+		return x.State
+	}
+	return JobState_JOB_STATE_UNSPECIFIED
+}
+
+func (x *Job) GetDataset() string {
+	if x != nil {
+		return x.Dataset
+	}
+	return ""
+}
+
+func (x *Job) GetInputFile() string {
+	if x != nil {
+		return x.InputFile
+	}
+	return ""
+}
+
+func (x *Job) GetRemoteFilePath() string {
+	if x != nil {
+		return x.RemoteFilePath
+	}
+	return ""
+}
+
+func (x *Job) GetOptions() *PipelineOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *Job) GetSubmittedBy() string {
+	if x != nil {
+		return x.SubmittedBy
+	}
+	return ""
+}
+
+func (x *Job) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *Job) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Job) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Job) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *Job) GetResult() *ProcessFileResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Job) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetCancelRequested() bool {
+	if x != nil {
+		return x.CancelRequested
+	}
+	return false
+}
+
+func (x *Job) GetProgress() *JobProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+type GetJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_transform_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Every state when unspecified
+	State JobState `protobuf:"varint,1,opt,name=state,proto3,enum=JobState" json:"state,omitempty"`
+	// 50 when zero
+	PageSize      int32  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_transform_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListJobsRequest) GetState() JobState {
+	if x != nil {
+		return x.State
+	}
+	return JobState_JOB_STATE_UNSPECIFIED
+}
+
+func (x *ListJobsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListJobsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Jobs  []*Job                 `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// Empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_transform_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsResponse) ProtoMessage() {}
+
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListJobsResponse) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *ListJobsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CancelJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelJobRequest) Reset() {
+	*x = CancelJobRequest{}
+	mi := &file_transform_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobRequest) ProtoMessage() {}
+
+func (x *CancelJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobRequest.ProtoReflect.Descriptor instead.
+func (*CancelJobRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CancelJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WatchJobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Time between events, 1000 when zero
+	IntervalMs    int32 `protobuf:"varint,2,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobRequest) Reset() {
+	*x = WatchJobRequest{}
+	mi := &file_transform_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobRequest) ProtoMessage() {}
+
+func (x *WatchJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *WatchJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WatchJobRequest) GetIntervalMs() int32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+type JobProgress struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	JobId      string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	State      JobState               `protobuf:"varint,2,opt,name=state,proto3,enum=JobState" json:"state,omitempty"`
+	BytesRead  int64                  `protobuf:"varint,3,opt,name=bytes_read,json=bytesRead,proto3" json:"bytes_read,omitempty"`
+	TotalBytes int64                  `protobuf:"varint,4,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	// Rows parsed successfully
+	RowsParsed   int64 `protobuf:"varint,5,opt,name=rows_parsed,json=rowsParsed,proto3" json:"rows_parsed,omitempty"`
+	RowsDropped  int64 `protobuf:"varint,6,opt,name=rows_dropped,json=rowsDropped,proto3" json:"rows_dropped,omitempty"`
+	RowsInserted int64 `protobuf:"varint,7,opt,name=rows_inserted,json=rowsInserted,proto3" json:"rows_inserted,omitempty"`
+	// Archive member being read
+	Member         string  `protobuf:"bytes,8,opt,name=member,proto3" json:"member,omitempty"`
+	ElapsedSeconds float64 `protobuf:"fixed64,9,opt,name=elapsed_seconds,json=elapsedSeconds,proto3" json:"elapsed_seconds,omitempty"`
+	// Negative until an estimate is available
+	RemainingSeconds float64 `protobuf:"fixed64,10,opt,name=remaining_seconds,json=remainingSeconds,proto3" json:"remaining_seconds,omitempty"`
+	// Set on the last event only
+	Summary       *Job `protobuf:"bytes,11,opt,name=summary,proto3" json:"summary,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobProgress) Reset() {
+	*x = JobProgress{}
+	mi := &file_transform_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobProgress) ProtoMessage() {}
+
+func (x *JobProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobProgress.ProtoReflect.Descriptor instead.
+func (*JobProgress) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *JobProgress) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobProgress) GetState() JobState {
+	if x != nil {
+		return x.State
+	}
+	return JobState_JOB_STATE_UNSPECIFIED
+}
+
+func (x *JobProgress) GetBytesRead() int64 {
+	if x != nil {
+		return x.BytesRead
+	}
+	return 0
+}
+
+func (x *JobProgress) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *JobProgress) GetRowsParsed() int64 {
+	if x != nil {
+		return x.RowsParsed
+	}
+	return 0
+}
+
+func (x *JobProgress) GetRowsDropped() int64 {
+	if x != nil {
+		return x.RowsDropped
+	}
+	return 0
+}
+
+func (x *JobProgress) GetRowsInserted() int64 {
+	if x != nil {
+		return x.RowsInserted
+	}
+	return 0
+}
+
+func (x *JobProgress) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *JobProgress) GetElapsedSeconds() float64 {
+	if x != nil {
+		return x.ElapsedSeconds
+	}
+	return 0
+}
+
+func (x *JobProgress) GetRemainingSeconds() float64 {
+	if x != nil {
+		return x.RemainingSeconds
+	}
+	return 0
+}
+
+func (x *JobProgress) GetSummary() *Job {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+type UploadRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*UploadRequest_Metadata
+	//	*UploadRequest_Chunk
+	Payload       isUploadRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadRequest) Reset() {
+	*x = UploadRequest{}
+	mi := &file_transform_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadRequest) ProtoMessage() {}
+
+func (x *UploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadRequest.ProtoReflect.Descriptor instead.
+func (*UploadRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UploadRequest) GetPayload() isUploadRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *UploadRequest) GetMetadata() *UploadMetadata {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadRequest_Metadata); ok {
+			return x.Metadata
+		}
+	}
+	return nil
+}
+
+func (x *UploadRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isUploadRequest_Payload interface {
+	isUploadRequest_Payload()
+}
+
+type UploadRequest_Metadata struct {
+	Metadata *UploadMetadata `protobuf:"bytes,1,opt,name=metadata,proto3,oneof"`
+}
+
+type UploadRequest_Chunk struct {
+	// Chunks have to stay below the 4MB grpc message limit, 1MB works well
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*UploadRequest_Metadata) isUploadRequest_Payload() {}
+
+func (*UploadRequest_Chunk) isUploadRequest_Payload() {}
+
+type UploadMetadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only nyc_trip is supported
+	Dataset string `protobuf:"bytes,1,opt,name=dataset,proto3" json:"dataset,omitempty"`
+	// Name of the file once decompressed, it gives the format like input_file does
+	FileName string `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	// Compression of the bytes sent, a .tar.gz file is sent as is with COMPRESSION_NONE
+	Compression Compression `protobuf:"varint,3,opt,name=compression,proto3,enum=Compression" json:"compression,omitempty"`
+	// Hex sha256 of the bytes as sent, nothing is loaded when it doesn't match
+	Sha256 string `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	// Optional, an upload over the processor limit is then rejected before any chunk
+	Size           int64            `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	RemoteFilePath string           `protobuf:"bytes,6,opt,name=remote_file_path,json=remoteFilePath,proto3" json:"remote_file_path,omitempty"`
+	Options        *PipelineOptions `protobuf:"bytes,7,opt,name=options,proto3" json:"options,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
-func (x *InputFileRequest) Reset() {
-	*x = InputFileRequest{}
-	mi := &file_transform_proto_msgTypes[0]
+func (x *UploadMetadata) Reset() {
+	*x = UploadMetadata{}
+	mi := &file_transform_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InputFileRequest) String() string {
+func (x *UploadMetadata) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InputFileRequest) ProtoMessage() {}
+func (*UploadMetadata) ProtoMessage() {}
 
-func (x *InputFileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_transform_proto_msgTypes[0]
+func (x *UploadMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -55,52 +2354,81 @@ func (x *InputFileRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InputFileRequest.ProtoReflect.Descriptor instead.
-func (*InputFileRequest) Descriptor() ([]byte, []int) {
-	return file_transform_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use UploadMetadata.ProtoReflect.Descriptor instead.
+func (*UploadMetadata) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *InputFileRequest) GetInputFile() string {
+func (x *UploadMetadata) GetDataset() string {
 	if x != nil {
-		return x.InputFile
+		return x.Dataset
 	}
 	return ""
 }
 
-func (x *InputFileRequest) GetRemoteFilePath() string {
+func (x *UploadMetadata) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *UploadMetadata) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_COMPRESSION_NONE
+}
+
+func (x *UploadMetadata) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *UploadMetadata) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *UploadMetadata) GetRemoteFilePath() string {
 	if x != nil {
 		return x.RemoteFilePath
 	}
 	return ""
 }
 
-type ProcessFileResponse struct {
+func (x *UploadMetadata) GetOptions() *PipelineOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type GetSchedulerStatusRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TotalRows     int64                  `protobuf:"varint,1,opt,name=total_rows,json=totalRows,proto3" json:"total_rows,omitempty"`
-	DroppedRows   int64                  `protobuf:"varint,2,opt,name=dropped_rows,json=droppedRows,proto3" json:"dropped_rows,omitempty"`
-	ProcessedRows int64                  `protobuf:"varint,3,opt,name=processed_rows,json=processedRows,proto3" json:"processed_rows,omitempty"`
-	InsertedRows  int64                  `protobuf:"varint,4,opt,name=inserted_rows,json=insertedRows,proto3" json:"inserted_rows,omitempty"`
-	MaxTime       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
-	MinTime       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProcessFileResponse) Reset() {
-	*x = ProcessFileResponse{}
-	mi := &file_transform_proto_msgTypes[1]
+func (x *GetSchedulerStatusRequest) Reset() {
+	*x = GetSchedulerStatusRequest{}
+	mi := &file_transform_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProcessFileResponse) String() string {
+func (x *GetSchedulerStatusRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProcessFileResponse) ProtoMessage() {}
+func (*GetSchedulerStatusRequest) ProtoMessage() {}
 
-func (x *ProcessFileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_transform_proto_msgTypes[1]
+func (x *GetSchedulerStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -111,49 +2439,242 @@ func (x *ProcessFileResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProcessFileResponse.ProtoReflect.Descriptor instead.
-func (*ProcessFileResponse) Descriptor() ([]byte, []int) {
-	return file_transform_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use GetSchedulerStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetSchedulerStatusRequest) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *ProcessFileResponse) GetTotalRows() int64 {
+type SchedulerStatus struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	MaxRunning int32                  `protobuf:"varint,1,opt,name=max_running,json=maxRunning,proto3" json:"max_running,omitempty"`
+	MaxQueued  int32                  `protobuf:"varint,2,opt,name=max_queued,json=maxQueued,proto3" json:"max_queued,omitempty"`
+	// Wait suggested to a caller rejected now
+	RetryAfterSeconds float64             `protobuf:"fixed64,3,opt,name=retry_after_seconds,json=retryAfterSeconds,proto3" json:"retry_after_seconds,omitempty"`
+	Datasets          []*DatasetAdmission `protobuf:"bytes,4,rep,name=datasets,proto3" json:"datasets,omitempty"`
+	// Oldest first
+	Running []*AdmissionTicket `protobuf:"bytes,5,rep,name=running,proto3" json:"running,omitempty"`
+	// In admission order
+	Queued        []*AdmissionTicket `protobuf:"bytes,6,rep,name=queued,proto3" json:"queued,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchedulerStatus) Reset() {
+	*x = SchedulerStatus{}
+	mi := &file_transform_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchedulerStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchedulerStatus) ProtoMessage() {}
+
+func (x *SchedulerStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[27]
 	if x != nil {
-		return x.TotalRows
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *ProcessFileResponse) GetDroppedRows() int64 {
+// Deprecated: Use SchedulerStatus.ProtoReflect.Descriptor instead.
+func (*SchedulerStatus) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SchedulerStatus) GetMaxRunning() int32 {
 	if x != nil {
-		return x.DroppedRows
+		return x.MaxRunning
 	}
 	return 0
 }
 
-func (x *ProcessFileResponse) GetProcessedRows() int64 {
+func (x *SchedulerStatus) GetMaxQueued() int32 {
 	if x != nil {
-		return x.ProcessedRows
+		return x.MaxQueued
 	}
 	return 0
 }
 
-func (x *ProcessFileResponse) GetInsertedRows() int64 {
+func (x *SchedulerStatus) GetRetryAfterSeconds() float64 {
 	if x != nil {
-		return x.InsertedRows
+		return x.RetryAfterSeconds
 	}
 	return 0
 }
 
-func (x *ProcessFileResponse) GetMaxTime() *timestamppb.Timestamp {
+func (x *SchedulerStatus) GetDatasets() []*DatasetAdmission {
 	if x != nil {
-		return x.MaxTime
+		return x.Datasets
 	}
 	return nil
 }
 
-func (x *ProcessFileResponse) GetMinTime() *timestamppb.Timestamp {
+func (x *SchedulerStatus) GetRunning() []*AdmissionTicket {
 	if x != nil {
-		return x.MinTime
+		return x.Running
+	}
+	return nil
+}
+
+func (x *SchedulerStatus) GetQueued() []*AdmissionTicket {
+	if x != nil {
+		return x.Queued
+	}
+	return nil
+}
+
+type DatasetAdmission struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Dataset string                 `protobuf:"bytes,1,opt,name=dataset,proto3" json:"dataset,omitempty"`
+	// Zero when only the processor limit applies
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Running       int32 `protobuf:"varint,3,opt,name=running,proto3" json:"running,omitempty"`
+	Queued        int32 `protobuf:"varint,4,opt,name=queued,proto3" json:"queued,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DatasetAdmission) Reset() {
+	*x = DatasetAdmission{}
+	mi := &file_transform_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DatasetAdmission) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatasetAdmission) ProtoMessage() {}
+
+func (x *DatasetAdmission) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatasetAdmission.ProtoReflect.Descriptor instead.
+func (*DatasetAdmission) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DatasetAdmission) GetDataset() string {
+	if x != nil {
+		return x.Dataset
+	}
+	return ""
+}
+
+func (x *DatasetAdmission) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *DatasetAdmission) GetRunning() int32 {
+	if x != nil {
+		return x.Running
+	}
+	return 0
+}
+
+func (x *DatasetAdmission) GetQueued() int32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+type AdmissionTicket struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Request id of an rpc or id of a job
+	Id      string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Dataset string `protobuf:"bytes,2,opt,name=dataset,proto3" json:"dataset,omitempty"`
+	// Full rpc method name or job
+	Source string `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Caller string `protobuf:"bytes,4,opt,name=caller,proto3" json:"caller,omitempty"`
+	// When the load started or was queued
+	Since         *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdmissionTicket) Reset() {
+	*x = AdmissionTicket{}
+	mi := &file_transform_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdmissionTicket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdmissionTicket) ProtoMessage() {}
+
+func (x *AdmissionTicket) ProtoReflect() protoreflect.Message {
+	mi := &file_transform_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdmissionTicket.ProtoReflect.Descriptor instead.
+func (*AdmissionTicket) Descriptor() ([]byte, []int) {
+	return file_transform_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *AdmissionTicket) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AdmissionTicket) GetDataset() string {
+	if x != nil {
+		return x.Dataset
+	}
+	return ""
+}
+
+func (x *AdmissionTicket) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *AdmissionTicket) GetCaller() string {
+	if x != nil {
+		return x.Caller
+	}
+	return ""
+}
+
+func (x *AdmissionTicket) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
 	}
 	return nil
 }
@@ -168,7 +2689,7 @@ type InputFileTestRequest struct {
 
 func (x *InputFileTestRequest) Reset() {
 	*x = InputFileTestRequest{}
-	mi := &file_transform_proto_msgTypes[2]
+	mi := &file_transform_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -180,7 +2701,7 @@ func (x *InputFileTestRequest) String() string {
 func (*InputFileTestRequest) ProtoMessage() {}
 
 func (x *InputFileTestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_transform_proto_msgTypes[2]
+	mi := &file_transform_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -193,7 +2714,7 @@ func (x *InputFileTestRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InputFileTestRequest.ProtoReflect.Descriptor instead.
 func (*InputFileTestRequest) Descriptor() ([]byte, []int) {
-	return file_transform_proto_rawDescGZIP(), []int{2}
+	return file_transform_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *InputFileTestRequest) GetLocationId() int64 {
@@ -214,7 +2735,7 @@ type ProcessFileTestResponse struct {
 
 func (x *ProcessFileTestResponse) Reset() {
 	*x = ProcessFileTestResponse{}
-	mi := &file_transform_proto_msgTypes[3]
+	mi := &file_transform_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -226,7 +2747,7 @@ func (x *ProcessFileTestResponse) String() string {
 func (*ProcessFileTestResponse) ProtoMessage() {}
 
 func (x *ProcessFileTestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_transform_proto_msgTypes[3]
+	mi := &file_transform_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -239,7 +2760,7 @@ func (x *ProcessFileTestResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessFileTestResponse.ProtoReflect.Descriptor instead.
 func (*ProcessFileTestResponse) Descriptor() ([]byte, []int) {
-	return file_transform_proto_rawDescGZIP(), []int{3}
+	return file_transform_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *ProcessFileTestResponse) GetBorough() string {
@@ -267,11 +2788,90 @@ var File_transform_proto protoreflect.FileDescriptor
 
 const file_transform_proto_rawDesc = "" +
 	"\n" +
-	"\x0ftransform.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"[\n" +
+	"\x0ftransform.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x87\x01\n" +
 	"\x10InputFileRequest\x12\x1d\n" +
 	"\n" +
 	"input_file\x18\x01 \x01(\tR\tinputFile\x12(\n" +
-	"\x10remote_file_path\x18\x02 \x01(\tR\x0eremoteFilePath\"\x91\x02\n" +
+	"\x10remote_file_path\x18\x02 \x01(\tR\x0eremoteFilePath\x12*\n" +
+	"\aoptions\x18\x03 \x01(\v2\x10.PipelineOptionsR\aoptions\"\xcd\x01\n" +
+	"\x13ValidateFileRequest\x12\x1f\n" +
+	"\n" +
+	"input_file\x18\x01 \x01(\tH\x00R\tinputFile\x12!\n" +
+	"\vinline_data\x18\x02 \x01(\fH\x00R\n" +
+	"inlineData\x12\x1b\n" +
+	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12*\n" +
+	"\aoptions\x18\x04 \x01(\v2\x10.PipelineOptionsR\aoptions\x12\x1f\n" +
+	"\vsample_size\x18\x05 \x01(\x05R\n" +
+	"sampleSizeB\b\n" +
+	"\x06source\"a\n" +
+	"\x12PreviewFileRequest\x12\x1d\n" +
+	"\n" +
+	"input_file\x18\x01 \x01(\tR\tinputFile\x12\x16\n" +
+	"\x06member\x18\x02 \x01(\tR\x06member\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"\x80\x01\n" +
+	"\x13PreviewFileResponse\x12\x16\n" +
+	"\x06member\x18\x01 \x01(\tR\x06member\x12\x18\n" +
+	"\amembers\x18\x02 \x03(\tR\amembers\x12\x16\n" +
+	"\x06header\x18\x03 \x03(\tR\x06header\x12\x1f\n" +
+	"\x04rows\x18\x04 \x03(\v2\v.PreviewRowR\x04rows\"\x8d\x01\n" +
+	"\n" +
+	"PreviewRow\x12\x1f\n" +
+	"\vline_number\x18\x01 \x01(\x03R\n" +
+	"lineNumber\x12\x1d\n" +
+	"\n" +
+	"raw_fields\x18\x02 \x03(\tR\trawFields\x12\x1a\n" +
+	"\x03row\x18\x03 \x01(\v2\b.TripRowR\x03row\x12#\n" +
+	"\x06errors\x18\x04 \x03(\v2\v.FieldErrorR\x06errors\"q\n" +
+	"\n" +
+	"FieldError\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1b\n" +
+	"\tdrops_row\x18\x04 \x01(\bR\bdropsRow\"\xc3\a\n" +
+	"\aTripRow\x12\x16\n" +
+	"\x06vendor\x18\x01 \x01(\tR\x06vendor\x12;\n" +
+	"\vpickup_time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"pickupTime\x12=\n" +
+	"\fdropoff_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vdropoffTime\x12'\n" +
+	"\x0fpassenger_count\x18\x04 \x01(\x03R\x0epassengerCount\x12#\n" +
+	"\rtrip_distance\x18\x05 \x01(\x01R\ftripDistance\x12,\n" +
+	"\x12pu_location_region\x18\x06 \x01(\tR\x10puLocationRegion\x12(\n" +
+	"\x10pu_location_zone\x18\a \x01(\tR\x0epuLocationZone\x12,\n" +
+	"\x12do_location_region\x18\b \x01(\tR\x10doLocationRegion\x12(\n" +
+	"\x10do_location_zone\x18\t \x01(\tR\x0edoLocationZone\x12!\n" +
+	"\fpayment_type\x18\n" +
+	" \x01(\tR\vpaymentType\x12$\n" +
+	"\vfare_amount\x18\v \x01(\x01H\x00R\n" +
+	"fareAmount\x88\x01\x01\x12\x19\n" +
+	"\x05extra\x18\f \x01(\x01H\x01R\x05extra\x88\x01\x01\x12\x1c\n" +
+	"\amta_tax\x18\r \x01(\x01H\x02R\x06mtaTax\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"tip_amount\x18\x0e \x01(\x01H\x03R\ttipAmount\x88\x01\x01\x12&\n" +
+	"\ftolls_amount\x18\x0f \x01(\x01H\x04R\vtollsAmount\x88\x01\x01\x128\n" +
+	"\x15improvement_surcharge\x18\x10 \x01(\x01H\x05R\x14improvementSurcharge\x88\x01\x01\x12&\n" +
+	"\ftotal_amount\x18\x11 \x01(\x01H\x06R\vtotalAmount\x88\x01\x01\x126\n" +
+	"\x14congestion_surcharge\x18\x12 \x01(\x01H\aR\x13congestionSurcharge\x88\x01\x01\x12$\n" +
+	"\vairport_fee\x18\x13 \x01(\x01H\bR\n" +
+	"airportFee\x88\x01\x01B\x0e\n" +
+	"\f_fare_amountB\b\n" +
+	"\x06_extraB\n" +
+	"\n" +
+	"\b_mta_taxB\r\n" +
+	"\v_tip_amountB\x0f\n" +
+	"\r_tolls_amountB\x18\n" +
+	"\x16_improvement_surchargeB\x0f\n" +
+	"\r_total_amountB\x17\n" +
+	"\x15_congestion_surchargeB\x0e\n" +
+	"\f_airport_fee\"\xeb\x01\n" +
+	"\x0fPipelineOptions\x12%\n" +
+	"\x0eparser_workers\x18\x01 \x01(\x05R\rparserWorkers\x12)\n" +
+	"\x10inserter_workers\x18\x02 \x01(\x05R\x0finserterWorkers\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x03 \x01(\x05R\tbatchSize\x12!\n" +
+	"\fchannel_size\x18\x04 \x01(\x05R\vchannelSize\x12%\n" +
+	"\x0ereader_workers\x18\x05 \x01(\x05R\rreaderWorkers\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x06 \x01(\x03R\tchunkSize\"\x8e\x04\n" +
 	"\x13ProcessFileResponse\x12\x1d\n" +
 	"\n" +
 	"total_rows\x18\x01 \x01(\x03R\ttotalRows\x12!\n" +
@@ -279,16 +2879,207 @@ const file_transform_proto_rawDesc = "" +
 	"\x0eprocessed_rows\x18\x03 \x01(\x03R\rprocessedRows\x12#\n" +
 	"\rinserted_rows\x18\x04 \x01(\x03R\finsertedRows\x125\n" +
 	"\bmax_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\amaxTime\x125\n" +
-	"\bmin_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\aminTime\"7\n" +
+	"\bmin_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\aminTime\x12#\n" +
+	"\rbatch_retries\x18\a \x01(\x03R\fbatchRetries\x12*\n" +
+	"\badaptive\x18\b \x01(\v2\x0e.AdaptiveStatsR\badaptive\x12#\n" +
+	"\x06chunks\x18\t \x03(\v2\v.ChunkStatsR\x06chunks\x12\"\n" +
+	"\aprofile\x18\n" +
+	" \x01(\v2\b.ProfileR\aprofile\x12.\n" +
+	"\fdrop_reasons\x18\v \x03(\v2\v.DropReasonR\vdropReasons\x121\n" +
+	"\rrejected_rows\x18\f \x03(\v2\f.RejectedRowR\frejectedRows\"P\n" +
+	"\n" +
+	"DropReason\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\"\x93\x01\n" +
+	"\vRejectedRow\x12\x16\n" +
+	"\x06member\x18\x01 \x01(\tR\x06member\x12\x1f\n" +
+	"\vline_number\x18\x02 \x01(\x03R\n" +
+	"lineNumber\x12\x14\n" +
+	"\x05field\x18\x03 \x01(\tR\x05field\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"raw_fields\x18\x05 \x03(\tR\trawFields\"\xef\x01\n" +
+	"\rAdaptiveStats\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x01 \x01(\x03R\tbatchSize\x12$\n" +
+	"\x0emin_batch_size\x18\x02 \x01(\x03R\fminBatchSize\x12$\n" +
+	"\x0emax_batch_size\x18\x03 \x01(\x03R\fmaxBatchSize\x12+\n" +
+	"\x11batch_adjustments\x18\x04 \x01(\x03R\x10batchAdjustments\x12!\n" +
+	"\fscale_events\x18\x05 \x01(\x03R\vscaleEvents\x12#\n" +
+	"\x06stages\x18\x06 \x03(\v2\v.StageStatsR\x06stages\"h\n" +
+	"\n" +
+	"StageStats\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\rfinal_workers\x18\x02 \x01(\x03R\ffinalWorkers\x12!\n" +
+	"\fpeak_workers\x18\x03 \x01(\x03R\vpeakWorkers\"G\n" +
+	"\aProfile\x12\x12\n" +
+	"\x04rows\x18\x01 \x01(\x03R\x04rows\x12(\n" +
+	"\acolumns\x18\x02 \x03(\v2\x0e.ColumnProfileR\acolumns\"\xcc\x02\n" +
+	"\rColumnProfile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x14\n" +
+	"\x05nulls\x18\x03 \x01(\x03R\x05nulls\x12+\n" +
+	"\x11distinct_estimate\x18\x04 \x01(\x03R\x10distinctEstimate\x12\x10\n" +
+	"\x03min\x18\x05 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x06 \x01(\x01R\x03max\x12\x12\n" +
+	"\x04mean\x18\a \x01(\x01R\x04mean\x125\n" +
+	"\bmin_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\aminTime\x125\n" +
+	"\bmax_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\amaxTime\x12(\n" +
+	"\thistogram\x18\n" +
+	" \x01(\v2\n" +
+	".HistogramR\thistogram\"F\n" +
+	"\tHistogram\x12!\n" +
+	"\fupper_bounds\x18\x01 \x03(\x01R\vupperBounds\x12\x16\n" +
+	"\x06counts\x18\x02 \x03(\x03R\x06counts\"\x9a\x01\n" +
+	"\n" +
+	"ChunkStats\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\x12\x12\n" +
+	"\x04rows\x18\x03 \x01(\x03R\x04rows\x12\x18\n" +
+	"\abatches\x18\x04 \x01(\x03R\abatches\"\xef\x04\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\x05state\x18\x02 \x01(\x0e2\t.JobStateR\x05state\x12\x18\n" +
+	"\adataset\x18\x03 \x01(\tR\adataset\x12\x1d\n" +
+	"\n" +
+	"input_file\x18\x04 \x01(\tR\tinputFile\x12(\n" +
+	"\x10remote_file_path\x18\x05 \x01(\tR\x0eremoteFilePath\x12*\n" +
+	"\aoptions\x18\x06 \x01(\v2\x10.PipelineOptionsR\aoptions\x12!\n" +
+	"\fsubmitted_by\x18\a \x01(\tR\vsubmittedBy\x12\x1a\n" +
+	"\battempts\x18\b \x01(\x05R\battempts\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"started_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12,\n" +
+	"\x06result\x18\f \x01(\v2\x14.ProcessFileResponseR\x06result\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\r \x01(\tR\terrorCode\x12\x14\n" +
+	"\x05error\x18\x0e \x01(\tR\x05error\x12)\n" +
+	"\x10cancel_requested\x18\x0f \x01(\bR\x0fcancelRequested\x12(\n" +
+	"\bprogress\x18\x10 \x01(\v2\f.JobProgressR\bprogress\"\x1f\n" +
+	"\rGetJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"n\n" +
+	"\x0fListJobsRequest\x12\x1f\n" +
+	"\x05state\x18\x01 \x01(\x0e2\t.JobStateR\x05state\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"T\n" +
+	"\x10ListJobsResponse\x12\x18\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x04.JobR\x04jobs\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\"\n" +
+	"\x10CancelJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"B\n" +
+	"\x0fWatchJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vinterval_ms\x18\x02 \x01(\x05R\n" +
+	"intervalMs\"\xfc\x02\n" +
+	"\vJobProgress\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x1f\n" +
+	"\x05state\x18\x02 \x01(\x0e2\t.JobStateR\x05state\x12\x1d\n" +
+	"\n" +
+	"bytes_read\x18\x03 \x01(\x03R\tbytesRead\x12\x1f\n" +
+	"\vtotal_bytes\x18\x04 \x01(\x03R\n" +
+	"totalBytes\x12\x1f\n" +
+	"\vrows_parsed\x18\x05 \x01(\x03R\n" +
+	"rowsParsed\x12!\n" +
+	"\frows_dropped\x18\x06 \x01(\x03R\vrowsDropped\x12#\n" +
+	"\rrows_inserted\x18\a \x01(\x03R\frowsInserted\x12\x16\n" +
+	"\x06member\x18\b \x01(\tR\x06member\x12'\n" +
+	"\x0felapsed_seconds\x18\t \x01(\x01R\x0eelapsedSeconds\x12+\n" +
+	"\x11remaining_seconds\x18\n" +
+	" \x01(\x01R\x10remainingSeconds\x12\x1e\n" +
+	"\asummary\x18\v \x01(\v2\x04.JobR\asummary\"a\n" +
+	"\rUploadRequest\x12-\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x0f.UploadMetadataH\x00R\bmetadata\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"\xf9\x01\n" +
+	"\x0eUploadMetadata\x12\x18\n" +
+	"\adataset\x18\x01 \x01(\tR\adataset\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12.\n" +
+	"\vcompression\x18\x03 \x01(\x0e2\f.CompressionR\vcompression\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12(\n" +
+	"\x10remote_file_path\x18\x06 \x01(\tR\x0eremoteFilePath\x12*\n" +
+	"\aoptions\x18\a \x01(\v2\x10.PipelineOptionsR\aoptions\"\x1b\n" +
+	"\x19GetSchedulerStatusRequest\"\x86\x02\n" +
+	"\x0fSchedulerStatus\x12\x1f\n" +
+	"\vmax_running\x18\x01 \x01(\x05R\n" +
+	"maxRunning\x12\x1d\n" +
+	"\n" +
+	"max_queued\x18\x02 \x01(\x05R\tmaxQueued\x12.\n" +
+	"\x13retry_after_seconds\x18\x03 \x01(\x01R\x11retryAfterSeconds\x12-\n" +
+	"\bdatasets\x18\x04 \x03(\v2\x11.DatasetAdmissionR\bdatasets\x12*\n" +
+	"\arunning\x18\x05 \x03(\v2\x10.AdmissionTicketR\arunning\x12(\n" +
+	"\x06queued\x18\x06 \x03(\v2\x10.AdmissionTicketR\x06queued\"t\n" +
+	"\x10DatasetAdmission\x12\x18\n" +
+	"\adataset\x18\x01 \x01(\tR\adataset\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x18\n" +
+	"\arunning\x18\x03 \x01(\x05R\arunning\x12\x16\n" +
+	"\x06queued\x18\x04 \x01(\x05R\x06queued\"\x9d\x01\n" +
+	"\x0fAdmissionTicket\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\adataset\x18\x02 \x01(\tR\adataset\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x16\n" +
+	"\x06caller\x18\x04 \x01(\tR\x06caller\x120\n" +
+	"\x05since\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\"7\n" +
 	"\x14InputFileTestRequest\x12\x1f\n" +
 	"\vlocation_id\x18\x01 \x01(\x03R\n" +
 	"locationId\"j\n" +
 	"\x17ProcessFileTestResponse\x12\x18\n" +
 	"\aborough\x18\x01 \x01(\tR\aborough\x12\x12\n" +
 	"\x04zone\x18\x02 \x01(\tR\x04zone\x12!\n" +
-	"\fservice_zone\x18\x03 \x01(\tR\vserviceZone2\x94\x01\n" +
+	"\fservice_zone\x18\x03 \x01(\tR\vserviceZone*\x9a\x01\n" +
+	"\bJobState\x12\x19\n" +
+	"\x15JOB_STATE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10JOB_STATE_QUEUED\x10\x01\x12\x15\n" +
+	"\x11JOB_STATE_RUNNING\x10\x02\x12\x17\n" +
+	"\x13JOB_STATE_SUCCEEDED\x10\x03\x12\x14\n" +
+	"\x10JOB_STATE_FAILED\x10\x04\x12\x17\n" +
+	"\x13JOB_STATE_CANCELLED\x10\x05*9\n" +
+	"\vCompression\x12\x14\n" +
+	"\x10COMPRESSION_NONE\x10\x00\x12\x14\n" +
+	"\x10COMPRESSION_GZIP\x10\x01*\xb7\x06\n" +
+	"\vErrorReason\x12\x1c\n" +
+	"\x18ERROR_REASON_UNSPECIFIED\x10\x00\x12 \n" +
+	"\x1cERROR_REASON_INVALID_REQUEST\x10\x01\x12 \n" +
+	"\x1cERROR_REASON_INVALID_OPTIONS\x10\x02\x12&\n" +
+	"\"ERROR_REASON_UNSUPPORTED_FILE_TYPE\x10\x03\x12 \n" +
+	"\x1cERROR_REASON_INVALID_ARCHIVE\x10\x04\x12 \n" +
+	"\x1cERROR_REASON_INPUT_NOT_FOUND\x10\x05\x12!\n" +
+	"\x1dERROR_REASON_MEMBER_NOT_FOUND\x10\x06\x12#\n" +
+	"\x1fERROR_REASON_LOCATION_NOT_FOUND\x10\a\x12\x1e\n" +
+	"\x1aERROR_REASON_JOB_NOT_FOUND\x10\b\x12 \n" +
+	"\x1cERROR_REASON_SCHEMA_MISMATCH\x10\t\x12\x1d\n" +
+	"\x19ERROR_REASON_JOB_FINISHED\x10\n" +
+	"\x12\x1f\n" +
+	"\x1bERROR_REASON_DUPLICATE_FILE\x10\v\x12\x1b\n" +
+	"\x17ERROR_REASON_LEASE_LOST\x10\f\x12%\n" +
+	"!ERROR_REASON_DATABASE_UNAVAILABLE\x10\r\x12\"\n" +
+	"\x1eERROR_REASON_REDIS_UNAVAILABLE\x10\x0e\x12\x1e\n" +
+	"\x1aERROR_REASON_MAP_NOT_READY\x10\x0f\x12\x1e\n" +
+	"\x1aERROR_REASON_SHUTTING_DOWN\x10\x10\x12\x1b\n" +
+	"\x17ERROR_REASON_QUEUE_FULL\x10\x11\x12\"\n" +
+	"\x1eERROR_REASON_ADMISSION_TIMEOUT\x10\x12\x12!\n" +
+	"\x1dERROR_REASON_UPLOAD_TOO_LARGE\x10\x13\x12\"\n" +
+	"\x1eERROR_REASON_CHECKSUM_MISMATCH\x10\x14\x12\"\n" +
+	"\x1eERROR_REASON_UPLOAD_INCOMPLETE\x10\x15\x12\x19\n" +
+	"\x15ERROR_REASON_INTERNAL\x10\x16\x12!\n" +
+	"\x1dERROR_REASON_PARTIALLY_LOADED\x10\x172\xe7\x04\n" +
 	"\x10TransformService\x12;\n" +
-	"\x0eProcessNYCTrip\x12\x11.InputFileRequest\x1a\x14.ProcessFileResponse\"\x00\x12C\n" +
+	"\x0eProcessNYCTrip\x12\x11.InputFileRequest\x1a\x14.ProcessFileResponse\"\x00\x12<\n" +
+	"\fValidateFile\x12\x14.ValidateFileRequest\x1a\x14.ProcessFileResponse\"\x00\x12:\n" +
+	"\vPreviewFile\x12\x13.PreviewFileRequest\x1a\x14.PreviewFileResponse\"\x00\x12&\n" +
+	"\tSubmitJob\x12\x11.InputFileRequest\x1a\x04.Job\"\x00\x12 \n" +
+	"\x06GetJob\x12\x0e.GetJobRequest\x1a\x04.Job\"\x00\x121\n" +
+	"\bListJobs\x12\x10.ListJobsRequest\x1a\x11.ListJobsResponse\"\x00\x12&\n" +
+	"\tCancelJob\x12\x11.CancelJobRequest\x1a\x04.Job\"\x00\x12.\n" +
+	"\bWatchJob\x12\x10.WatchJobRequest\x1a\f.JobProgress\"\x000\x01\x12<\n" +
+	"\x10UploadAndProcess\x12\x0e.UploadRequest\x1a\x14.ProcessFileResponse\"\x00(\x01\x12D\n" +
+	"\x12GetSchedulerStatus\x12\x1a.GetSchedulerStatusRequest\x1a\x10.SchedulerStatus\"\x00\x12C\n" +
 	"\x0eProcessTesting\x12\x15.InputFileTestRequest\x1a\x18.ProcessFileTestResponse\"\x00B\x19Z\x17processor/protos;protosb\x06proto3"
 
 var (
@@ -303,26 +3094,113 @@ func file_transform_proto_rawDescGZIP() []byte {
 	return file_transform_proto_rawDescData
 }
 
-var file_transform_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_transform_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_transform_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_transform_proto_goTypes = []any{
-	(*InputFileRequest)(nil),        // 0: InputFileRequest
-	(*ProcessFileResponse)(nil),     // 1: ProcessFileResponse
-	(*InputFileTestRequest)(nil),    // 2: InputFileTestRequest
-	(*ProcessFileTestResponse)(nil), // 3: ProcessFileTestResponse
-	(*timestamppb.Timestamp)(nil),   // 4: google.protobuf.Timestamp
+	(JobState)(0),                     // 0: JobState
+	(Compression)(0),                  // 1: Compression
+	(ErrorReason)(0),                  // 2: ErrorReason
+	(*InputFileRequest)(nil),          // 3: InputFileRequest
+	(*ValidateFileRequest)(nil),       // 4: ValidateFileRequest
+	(*PreviewFileRequest)(nil),        // 5: PreviewFileRequest
+	(*PreviewFileResponse)(nil),       // 6: PreviewFileResponse
+	(*PreviewRow)(nil),                // 7: PreviewRow
+	(*FieldError)(nil),                // 8: FieldError
+	(*TripRow)(nil),                   // 9: TripRow
+	(*PipelineOptions)(nil),           // 10: PipelineOptions
+	(*ProcessFileResponse)(nil),       // 11: ProcessFileResponse
+	(*DropReason)(nil),                // 12: DropReason
+	(*RejectedRow)(nil),               // 13: RejectedRow
+	(*AdaptiveStats)(nil),             // 14: AdaptiveStats
+	(*StageStats)(nil),                // 15: StageStats
+	(*Profile)(nil),                   // 16: Profile
+	(*ColumnProfile)(nil),             // 17: ColumnProfile
+	(*Histogram)(nil),                 // 18: Histogram
+	(*ChunkStats)(nil),                // 19: ChunkStats
+	(*Job)(nil),                       // 20: Job
+	(*GetJobRequest)(nil),             // 21: GetJobRequest
+	(*ListJobsRequest)(nil),           // 22: ListJobsRequest
+	(*ListJobsResponse)(nil),          // 23: ListJobsResponse
+	(*CancelJobRequest)(nil),          // 24: CancelJobRequest
+	(*WatchJobRequest)(nil),           // 25: WatchJobRequest
+	(*JobProgress)(nil),               // 26: JobProgress
+	(*UploadRequest)(nil),             // 27: UploadRequest
+	(*UploadMetadata)(nil),            // 28: UploadMetadata
+	(*GetSchedulerStatusRequest)(nil), // 29: GetSchedulerStatusRequest
+	(*SchedulerStatus)(nil),           // 30: SchedulerStatus
+	(*DatasetAdmission)(nil),          // 31: DatasetAdmission
+	(*AdmissionTicket)(nil),           // 32: AdmissionTicket
+	(*InputFileTestRequest)(nil),      // 33: InputFileTestRequest
+	(*ProcessFileTestResponse)(nil),   // 34: ProcessFileTestResponse
+	(*timestamppb.Timestamp)(nil),     // 35: google.protobuf.Timestamp
 }
 var file_transform_proto_depIdxs = []int32{
-	4, // 0: ProcessFileResponse.max_time:type_name -> google.protobuf.Timestamp
-	4, // 1: ProcessFileResponse.min_time:type_name -> google.protobuf.Timestamp
-	0, // 2: TransformService.ProcessNYCTrip:input_type -> InputFileRequest
-	2, // 3: TransformService.ProcessTesting:input_type -> InputFileTestRequest
-	1, // 4: TransformService.ProcessNYCTrip:output_type -> ProcessFileResponse
-	3, // 5: TransformService.ProcessTesting:output_type -> ProcessFileTestResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	10, // 0: InputFileRequest.options:type_name -> PipelineOptions
+	10, // 1: ValidateFileRequest.options:type_name -> PipelineOptions
+	7,  // 2: PreviewFileResponse.rows:type_name -> PreviewRow
+	9,  // 3: PreviewRow.row:type_name -> TripRow
+	8,  // 4: PreviewRow.errors:type_name -> FieldError
+	35, // 5: TripRow.pickup_time:type_name -> google.protobuf.Timestamp
+	35, // 6: TripRow.dropoff_time:type_name -> google.protobuf.Timestamp
+	35, // 7: ProcessFileResponse.max_time:type_name -> google.protobuf.Timestamp
+	35, // 8: ProcessFileResponse.min_time:type_name -> google.protobuf.Timestamp
+	14, // 9: ProcessFileResponse.adaptive:type_name -> AdaptiveStats
+	19, // 10: ProcessFileResponse.chunks:type_name -> ChunkStats
+	16, // 11: ProcessFileResponse.profile:type_name -> Profile
+	12, // 12: ProcessFileResponse.drop_reasons:type_name -> DropReason
+	13, // 13: ProcessFileResponse.rejected_rows:type_name -> RejectedRow
+	15, // 14: AdaptiveStats.stages:type_name -> StageStats
+	17, // 15: Profile.columns:type_name -> ColumnProfile
+	35, // 16: ColumnProfile.min_time:type_name -> google.protobuf.Timestamp
+	35, // 17: ColumnProfile.max_time:type_name -> google.protobuf.Timestamp
+	18, // 18: ColumnProfile.histogram:type_name -> Histogram
+	35, // 19: ChunkStats.start:type_name -> google.protobuf.Timestamp
+	35, // 20: ChunkStats.end:type_name -> google.protobuf.Timestamp
+	0,  // 21: Job.state:type_name -> JobState
+	10, // 22: Job.options:type_name -> PipelineOptions
+	35, // 23: Job.created_at:type_name -> google.protobuf.Timestamp
+	35, // 24: Job.started_at:type_name -> google.protobuf.Timestamp
+	35, // 25: Job.finished_at:type_name -> google.protobuf.Timestamp
+	11, // 26: Job.result:type_name -> ProcessFileResponse
+	26, // 27: Job.progress:type_name -> JobProgress
+	0,  // 28: ListJobsRequest.state:type_name -> JobState
+	20, // 29: ListJobsResponse.jobs:type_name -> Job
+	0,  // 30: JobProgress.state:type_name -> JobState
+	20, // 31: JobProgress.summary:type_name -> Job
+	28, // 32: UploadRequest.metadata:type_name -> UploadMetadata
+	1,  // 33: UploadMetadata.compression:type_name -> Compression
+	10, // 34: UploadMetadata.options:type_name -> PipelineOptions
+	31, // 35: SchedulerStatus.datasets:type_name -> DatasetAdmission
+	32, // 36: SchedulerStatus.running:type_name -> AdmissionTicket
+	32, // 37: SchedulerStatus.queued:type_name -> AdmissionTicket
+	35, // 38: AdmissionTicket.since:type_name -> google.protobuf.Timestamp
+	3,  // 39: TransformService.ProcessNYCTrip:input_type -> InputFileRequest
+	4,  // 40: TransformService.ValidateFile:input_type -> ValidateFileRequest
+	5,  // 41: TransformService.PreviewFile:input_type -> PreviewFileRequest
+	3,  // 42: TransformService.SubmitJob:input_type -> InputFileRequest
+	21, // 43: TransformService.GetJob:input_type -> GetJobRequest
+	22, // 44: TransformService.ListJobs:input_type -> ListJobsRequest
+	24, // 45: TransformService.CancelJob:input_type -> CancelJobRequest
+	25, // 46: TransformService.WatchJob:input_type -> WatchJobRequest
+	27, // 47: TransformService.UploadAndProcess:input_type -> UploadRequest
+	29, // 48: TransformService.GetSchedulerStatus:input_type -> GetSchedulerStatusRequest
+	33, // 49: TransformService.ProcessTesting:input_type -> InputFileTestRequest
+	11, // 50: TransformService.ProcessNYCTrip:output_type -> ProcessFileResponse
+	11, // 51: TransformService.ValidateFile:output_type -> ProcessFileResponse
+	6,  // 52: TransformService.PreviewFile:output_type -> PreviewFileResponse
+	20, // 53: TransformService.SubmitJob:output_type -> Job
+	20, // 54: TransformService.GetJob:output_type -> Job
+	23, // 55: TransformService.ListJobs:output_type -> ListJobsResponse
+	20, // 56: TransformService.CancelJob:output_type -> Job
+	26, // 57: TransformService.WatchJob:output_type -> JobProgress
+	11, // 58: TransformService.UploadAndProcess:output_type -> ProcessFileResponse
+	30, // 59: TransformService.GetSchedulerStatus:output_type -> SchedulerStatus
+	34, // 60: TransformService.ProcessTesting:output_type -> ProcessFileTestResponse
+	50, // [50:61] is the sub-list for method output_type
+	39, // [39:50] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
 }
 
 func init() { file_transform_proto_init() }
@@ -330,18 +3208,28 @@ func file_transform_proto_init() {
 	if File_transform_proto != nil {
 		return
 	}
+	file_transform_proto_msgTypes[1].OneofWrappers = []any{
+		(*ValidateFileRequest_InputFile)(nil),
+		(*ValidateFileRequest_InlineData)(nil),
+	}
+	file_transform_proto_msgTypes[6].OneofWrappers = []any{}
+	file_transform_proto_msgTypes[24].OneofWrappers = []any{
+		(*UploadRequest_Metadata)(nil),
+		(*UploadRequest_Chunk)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_transform_proto_rawDesc), len(file_transform_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      3,
+			NumMessages:   32,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_transform_proto_goTypes,
 		DependencyIndexes: file_transform_proto_depIdxs,
+		EnumInfos:         file_transform_proto_enumTypes,
 		MessageInfos:      file_transform_proto_msgTypes,
 	}.Build()
 	File_transform_proto = out.File
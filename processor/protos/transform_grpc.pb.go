@@ -19,8 +19,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TransformService_ProcessNYCTrip_FullMethodName = "/TransformService/ProcessNYCTrip"
-	TransformService_ProcessTesting_FullMethodName = "/TransformService/ProcessTesting"
+	TransformService_ProcessNYCTrip_FullMethodName     = "/TransformService/ProcessNYCTrip"
+	TransformService_ValidateFile_FullMethodName       = "/TransformService/ValidateFile"
+	TransformService_PreviewFile_FullMethodName        = "/TransformService/PreviewFile"
+	TransformService_SubmitJob_FullMethodName          = "/TransformService/SubmitJob"
+	TransformService_GetJob_FullMethodName             = "/TransformService/GetJob"
+	TransformService_ListJobs_FullMethodName           = "/TransformService/ListJobs"
+	TransformService_CancelJob_FullMethodName          = "/TransformService/CancelJob"
+	TransformService_WatchJob_FullMethodName           = "/TransformService/WatchJob"
+	TransformService_UploadAndProcess_FullMethodName   = "/TransformService/UploadAndProcess"
+	TransformService_GetSchedulerStatus_FullMethodName = "/TransformService/GetSchedulerStatus"
+	TransformService_ProcessTesting_FullMethodName     = "/TransformService/ProcessTesting"
 )
 
 // TransformServiceClient is the client API for TransformService service.
@@ -28,6 +37,27 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TransformServiceClient interface {
 	ProcessNYCTrip(ctx context.Context, in *InputFileRequest, opts ...grpc.CallOption) (*ProcessFileResponse, error)
+	// Runs ProcessNYCTrip without writing to the database
+	ValidateFile(ctx context.Context, in *ValidateFileRequest, opts ...grpc.CallOption) (*ProcessFileResponse, error)
+	// Parses the first rows of an input without writing or deleting it, it waits for
+	// admission like a load since the input is read into memory
+	PreviewFile(ctx context.Context, in *PreviewFileRequest, opts ...grpc.CallOption) (*PreviewFileResponse, error)
+	// Queues a ProcessNYCTrip load and returns at once, the job survives processor restarts.
+	// Submitting an input that is already queued or running returns the existing job.
+	SubmitJob(ctx context.Context, in *InputFileRequest, opts ...grpc.CallOption) (*Job, error)
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error)
+	// Most recent jobs first
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	// A queued job is cancelled at once, a running one stops its load and releases the input
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*Job, error)
+	// Streams the progress of a job until it finishes, the last event carries the finished job
+	WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobProgress], error)
+	// Loads a file sent over the stream instead of staged in redis, the first message
+	// carries the metadata and the next ones the file bytes in order. The upload is spooled
+	// and no row is loaded before it fully arrived and its sha256 matched
+	UploadAndProcess(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadRequest, ProcessFileResponse], error)
+	// Loads running on this processor and waiting for a slot, for operators
+	GetSchedulerStatus(ctx context.Context, in *GetSchedulerStatusRequest, opts ...grpc.CallOption) (*SchedulerStatus, error)
 	// For Testing Load Map
 	ProcessTesting(ctx context.Context, in *InputFileTestRequest, opts ...grpc.CallOption) (*ProcessFileTestResponse, error)
 }
@@ -50,6 +80,108 @@ func (c *transformServiceClient) ProcessNYCTrip(ctx context.Context, in *InputFi
 	return out, nil
 }
 
+func (c *transformServiceClient) ValidateFile(ctx context.Context, in *ValidateFileRequest, opts ...grpc.CallOption) (*ProcessFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessFileResponse)
+	err := c.cc.Invoke(ctx, TransformService_ValidateFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transformServiceClient) PreviewFile(ctx context.Context, in *PreviewFileRequest, opts ...grpc.CallOption) (*PreviewFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PreviewFileResponse)
+	err := c.cc.Invoke(ctx, TransformService_PreviewFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transformServiceClient) SubmitJob(ctx context.Context, in *InputFileRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, TransformService_SubmitJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transformServiceClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, TransformService_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transformServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, TransformService_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transformServiceClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, TransformService_CancelJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transformServiceClient) WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TransformService_ServiceDesc.Streams[0], TransformService_WatchJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobRequest, JobProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TransformService_WatchJobClient = grpc.ServerStreamingClient[JobProgress]
+
+func (c *transformServiceClient) UploadAndProcess(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadRequest, ProcessFileResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TransformService_ServiceDesc.Streams[1], TransformService_UploadAndProcess_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadRequest, ProcessFileResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TransformService_UploadAndProcessClient = grpc.ClientStreamingClient[UploadRequest, ProcessFileResponse]
+
+func (c *transformServiceClient) GetSchedulerStatus(ctx context.Context, in *GetSchedulerStatusRequest, opts ...grpc.CallOption) (*SchedulerStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SchedulerStatus)
+	err := c.cc.Invoke(ctx, TransformService_GetSchedulerStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *transformServiceClient) ProcessTesting(ctx context.Context, in *InputFileTestRequest, opts ...grpc.CallOption) (*ProcessFileTestResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ProcessFileTestResponse)
@@ -65,6 +197,27 @@ func (c *transformServiceClient) ProcessTesting(ctx context.Context, in *InputFi
 // for forward compatibility.
 type TransformServiceServer interface {
 	ProcessNYCTrip(context.Context, *InputFileRequest) (*ProcessFileResponse, error)
+	// Runs ProcessNYCTrip without writing to the database
+	ValidateFile(context.Context, *ValidateFileRequest) (*ProcessFileResponse, error)
+	// Parses the first rows of an input without writing or deleting it, it waits for
+	// admission like a load since the input is read into memory
+	PreviewFile(context.Context, *PreviewFileRequest) (*PreviewFileResponse, error)
+	// Queues a ProcessNYCTrip load and returns at once, the job survives processor restarts.
+	// Submitting an input that is already queued or running returns the existing job.
+	SubmitJob(context.Context, *InputFileRequest) (*Job, error)
+	GetJob(context.Context, *GetJobRequest) (*Job, error)
+	// Most recent jobs first
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	// A queued job is cancelled at once, a running one stops its load and releases the input
+	CancelJob(context.Context, *CancelJobRequest) (*Job, error)
+	// Streams the progress of a job until it finishes, the last event carries the finished job
+	WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobProgress]) error
+	// Loads a file sent over the stream instead of staged in redis, the first message
+	// carries the metadata and the next ones the file bytes in order. The upload is spooled
+	// and no row is loaded before it fully arrived and its sha256 matched
+	UploadAndProcess(grpc.ClientStreamingServer[UploadRequest, ProcessFileResponse]) error
+	// Loads running on this processor and waiting for a slot, for operators
+	GetSchedulerStatus(context.Context, *GetSchedulerStatusRequest) (*SchedulerStatus, error)
 	// For Testing Load Map
 	ProcessTesting(context.Context, *InputFileTestRequest) (*ProcessFileTestResponse, error)
 	mustEmbedUnimplementedTransformServiceServer()
@@ -80,6 +233,33 @@ type UnimplementedTransformServiceServer struct{}
 func (UnimplementedTransformServiceServer) ProcessNYCTrip(context.Context, *InputFileRequest) (*ProcessFileResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ProcessNYCTrip not implemented")
 }
+func (UnimplementedTransformServiceServer) ValidateFile(context.Context, *ValidateFileRequest) (*ProcessFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateFile not implemented")
+}
+func (UnimplementedTransformServiceServer) PreviewFile(context.Context, *PreviewFileRequest) (*PreviewFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewFile not implemented")
+}
+func (UnimplementedTransformServiceServer) SubmitJob(context.Context, *InputFileRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedTransformServiceServer) GetJob(context.Context, *GetJobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedTransformServiceServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedTransformServiceServer) CancelJob(context.Context, *CancelJobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedTransformServiceServer) WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobProgress]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchJob not implemented")
+}
+func (UnimplementedTransformServiceServer) UploadAndProcess(grpc.ClientStreamingServer[UploadRequest, ProcessFileResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadAndProcess not implemented")
+}
+func (UnimplementedTransformServiceServer) GetSchedulerStatus(context.Context, *GetSchedulerStatusRequest) (*SchedulerStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSchedulerStatus not implemented")
+}
 func (UnimplementedTransformServiceServer) ProcessTesting(context.Context, *InputFileTestRequest) (*ProcessFileTestResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ProcessTesting not implemented")
 }
@@ -122,6 +302,150 @@ func _TransformService_ProcessNYCTrip_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TransformService_ValidateFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransformServiceServer).ValidateFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransformService_ValidateFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransformServiceServer).ValidateFile(ctx, req.(*ValidateFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransformService_PreviewFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransformServiceServer).PreviewFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransformService_PreviewFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransformServiceServer).PreviewFile(ctx, req.(*PreviewFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransformService_SubmitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InputFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransformServiceServer).SubmitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransformService_SubmitJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransformServiceServer).SubmitJob(ctx, req.(*InputFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransformService_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransformServiceServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransformService_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransformServiceServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransformService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransformServiceServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransformService_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransformServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransformService_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransformServiceServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransformService_CancelJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransformServiceServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransformService_WatchJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TransformServiceServer).WatchJob(m, &grpc.GenericServerStream[WatchJobRequest, JobProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TransformService_WatchJobServer = grpc.ServerStreamingServer[JobProgress]
+
+func _TransformService_UploadAndProcess_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TransformServiceServer).UploadAndProcess(&grpc.GenericServerStream[UploadRequest, ProcessFileResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TransformService_UploadAndProcessServer = grpc.ClientStreamingServer[UploadRequest, ProcessFileResponse]
+
+func _TransformService_GetSchedulerStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchedulerStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransformServiceServer).GetSchedulerStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransformService_GetSchedulerStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransformServiceServer).GetSchedulerStatus(ctx, req.(*GetSchedulerStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TransformService_ProcessTesting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(InputFileTestRequest)
 	if err := dec(in); err != nil {
@@ -151,11 +475,50 @@ var TransformService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ProcessNYCTrip",
 			Handler:    _TransformService_ProcessNYCTrip_Handler,
 		},
+		{
+			MethodName: "ValidateFile",
+			Handler:    _TransformService_ValidateFile_Handler,
+		},
+		{
+			MethodName: "PreviewFile",
+			Handler:    _TransformService_PreviewFile_Handler,
+		},
+		{
+			MethodName: "SubmitJob",
+			Handler:    _TransformService_SubmitJob_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _TransformService_GetJob_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _TransformService_ListJobs_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _TransformService_CancelJob_Handler,
+		},
+		{
+			MethodName: "GetSchedulerStatus",
+			Handler:    _TransformService_GetSchedulerStatus_Handler,
+		},
 		{
 			MethodName: "ProcessTesting",
 			Handler:    _TransformService_ProcessTesting_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchJob",
+			Handler:       _TransformService_WatchJob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadAndProcess",
+			Handler:       _TransformService_UploadAndProcess_Handler,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "transform.proto",
 }